@@ -0,0 +1,431 @@
+// Command learncode-admin seeds and bulk-manages problems from problem
+// packages (see package problempkg), and runs one-off data migrations.
+//
+// Usage:
+//
+//	learncode-admin seed [-dry-run] [-draft] [-table Problems] DIR
+//	learncode-admin export [-id ID] [-table Problems] DIR
+//	learncode-admin backfill-unrated [-table SubmissionsV2]
+//	learncode-admin backfill-judging [-table SubmissionsV2]
+//
+// seed reads every package directory under DIR (or DIR itself when it holds
+// a problem.yaml), validates it with the same rules as the add-problem
+// lambda and upserts it into the problems table. Problems whose content is
+// unchanged are left alone, so seeding is safe to repeat. With -dry-run the
+// diff is printed but nothing is written. New problems are published right
+// away unless -draft is given; existing problems keep their status. Every
+// problem gets a slug in SLUGS_TABLE (default ProblemSlugs), which also
+// backfills problems stored before slugs existed.
+//
+// Tests larger than testdata.InlineLimit, and the largest of the rest when
+// a problem would outgrow testdata.ItemLimit, are uploaded to the store named
+// by TESTDATA_BUCKET (with TESTDATA_ENDPOINT for MinIO) or TESTDATA_DIR.
+// When RUNNERS_API_URL is set, reference solutions and input validators are
+// run first and any failure aborts the seed.
+//
+// export writes stored problems back out as package directories.
+//
+// backfill-unrated adds the judged submissions that were never rated to the
+// rating job's unrated index (see db.UnratedIndex). Submissions judged
+// before the index existed aren't in it; run this once after deploying it.
+//
+// backfill-judging adds the pending and running submissions to the index
+// the reaper finds stuck submissions through (see db.JudgingIndex), for
+// submissions saved before it existed. Run it once after deploying it.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"learncode/backend/db"
+	"learncode/backend/judge"
+	"learncode/backend/problempkg"
+	"learncode/backend/testdata"
+	"learncode/backend/types"
+)
+
+const maxDiffValueLength = 72
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "seed":
+		err = seed(os.Args[2:])
+	case "export":
+		err = export(os.Args[2:])
+	case "backfill-unrated":
+		err = backfillUnrated(os.Args[2:])
+	case "backfill-judging":
+		err = backfillJudging(os.Args[2:])
+	default:
+		usage()
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "learncode-admin: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: learncode-admin seed [-dry-run] [-draft] [-table NAME] DIR")
+	fmt.Fprintln(os.Stderr, "       learncode-admin export [-id ID] [-table NAME] DIR")
+	fmt.Fprintln(os.Stderr, "       learncode-admin backfill-unrated [-table NAME]")
+	fmt.Fprintln(os.Stderr, "       learncode-admin backfill-judging [-table NAME]")
+	os.Exit(2)
+}
+
+// setTable points the db package at the problems table, keeping an existing
+// PROBLEMS_TABLE unless a table was given explicitly. Slugs go to
+// $SLUGS_TABLE, or ProblemSlugs.
+func setTable(table string) {
+	if table != "" || os.Getenv("PROBLEMS_TABLE") == "" {
+		if table == "" {
+			table = "Problems"
+		}
+		os.Setenv("PROBLEMS_TABLE", table)
+	}
+	if os.Getenv("SLUGS_TABLE") == "" {
+		os.Setenv("SLUGS_TABLE", "ProblemSlugs")
+	}
+}
+
+func seed(args []string) error {
+	flags := flag.NewFlagSet("seed", flag.ExitOnError)
+	dryRun := flags.Bool("dry-run", false, "print what would change without writing")
+	draft := flags.Bool("draft", false, "create new problems as drafts instead of publishing them")
+	table := flags.String("table", "", "problems table name (default $PROBLEMS_TABLE or Problems)")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		usage()
+	}
+	setTable(*table)
+
+	dirs, err := packageDirs(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	// Load and validate everything up front so a bad package doesn't leave
+	// the table half seeded.
+	problems := make([]*types.Problem, 0, len(dirs))
+	for _, dir := range dirs {
+		problem, err := problempkg.ReadDir(dir)
+		if err != nil {
+			return fmt.Errorf("%s: %v", dir, err)
+		}
+		if problem.ID == "" {
+			return fmt.Errorf("%s: problem.yaml needs an id so seeding can be repeated", dir)
+		}
+		if err := problem.Validate(); err != nil {
+			return fmt.Errorf("%s: %v", dir, err)
+		}
+		problems = append(problems, problem)
+	}
+
+	ctx := context.Background()
+	if os.Getenv("RUNNERS_API_URL") == "" {
+		fmt.Println("RUNNERS_API_URL not set, skipping reference solution checks")
+	} else {
+		for i, problem := range problems {
+			failures, err := judge.Verify(ctx, problem)
+			if err != nil {
+				return fmt.Errorf("%s: failed to validate: %v", dirs[i], err)
+			}
+			if len(failures) > 0 {
+				return fmt.Errorf("%s: problem failed validation:\n  %s", dirs[i], strings.Join(failures, "\n  "))
+			}
+		}
+	}
+
+	store := testdata.Discard
+	if !*dryRun {
+		var err error
+		if store, err = testdata.NewStore(ctx); err != nil {
+			return err
+		}
+	}
+
+	var created, updated, unchanged int
+	for _, problem := range problems {
+		// Test data keys are content addressed, so offloading an unchanged
+		// package yields the same keys and an empty diff.
+		if store == nil {
+			if err := testdata.OffloadDefault(ctx, problem); err != nil {
+				return fmt.Errorf("%s: %v", problem.ID, err)
+			}
+		} else if err := testdata.Offload(ctx, store, problem); err != nil {
+			return fmt.Errorf("%s: %v", problem.ID, err)
+		}
+
+		existing, err := db.GetProblem(ctx, problem.ID)
+		if err != nil && !errors.Is(err, db.ErrProblemNotFound) {
+			return fmt.Errorf("failed to fetch %s: %v", problem.ID, err)
+		}
+
+		if existing != nil {
+			problem.Slug = existing.Slug
+		}
+		// A new slug is reserved when the problem is saved, so the diff
+		// shows a preview of it
+		slug := problem.Slug
+		if !types.SlugMatchesTitle(problem.Slug, problem.Title) {
+			problem.Slug = types.Slugify(problem.Title)
+		}
+
+		now := time.Now().Unix()
+		if existing == nil {
+			problem.CreatedAt = now
+			problem.UpdatedAt = now
+			problem.Status = types.ProblemPublished
+			if *draft {
+				problem.Status = types.ProblemDraft
+			}
+			fmt.Printf("+ %s (%s)\n", problem.ID, problem.Title)
+			created++
+		} else {
+			problem.CreatedAt = existing.CreatedAt
+			problem.UpdatedAt = existing.UpdatedAt
+			problem.DeletedAt = existing.DeletedAt
+			problem.Status = existing.Status
+			problem.PublishAt = existing.PublishAt
+			problem.Reviews = existing.Reviews
+			if problem.Translations == nil {
+				problem.Translations = existing.Translations
+			}
+			// Keep the computed rating unless the package sets one
+			if problem.Rating == 0 {
+				problem.Rating = existing.Rating
+				problem.RatingLocked = existing.RatingLocked
+			}
+
+			changes, err := diff(existing, problem)
+			if err != nil {
+				return err
+			}
+			if len(changes) == 0 {
+				fmt.Printf("= %s (%s)\n", problem.ID, problem.Title)
+				unchanged++
+				continue
+			}
+
+			fmt.Printf("~ %s (%s)\n", problem.ID, problem.Title)
+			for _, change := range changes {
+				fmt.Println(change)
+			}
+			problem.UpdatedAt = now
+			updated++
+		}
+
+		if *dryRun {
+			continue
+		}
+		problem.Slug = slug
+		if existing == nil {
+			err = db.CreateProblem(ctx, problem)
+		} else {
+			err = db.UpdateProblem(ctx, problem, existing.UpdatedAt, existing.Rating)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to save %s: %v", problem.ID, err)
+		}
+	}
+
+	summary := fmt.Sprintf("%d created, %d updated, %d unchanged", created, updated, unchanged)
+	if *dryRun {
+		summary += " (dry run, nothing written)"
+	}
+	fmt.Println(summary)
+	return nil
+}
+
+func export(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	id := flags.String("id", "", "export a single problem")
+	table := flags.String("table", "", "problems table name (default $PROBLEMS_TABLE or Problems)")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		usage()
+	}
+	setTable(*table)
+
+	ctx := context.Background()
+	var problems []types.Problem
+	if *id != "" {
+		problem, err := db.GetProblem(ctx, *id)
+		if err != nil {
+			return err
+		}
+		problems = append(problems, *problem)
+	} else {
+		// The listing leaves out the tests, so each problem is read in full
+		listed, err := db.GetProblems(ctx)
+		if err != nil {
+			return err
+		}
+		for _, p := range listed {
+			problem, err := db.GetProblem(ctx, p.ID)
+			if err != nil {
+				return err
+			}
+			problems = append(problems, *problem)
+		}
+	}
+
+	for i := range problems {
+		if err := testdata.Inline(ctx, &problems[i]); err != nil {
+			return fmt.Errorf("%s: %v", problems[i].ID, err)
+		}
+		dir := filepath.Join(flags.Arg(0), problems[i].ID)
+		if err := problempkg.WriteDir(dir, &problems[i]); err != nil {
+			return fmt.Errorf("%s: %v", problems[i].ID, err)
+		}
+		fmt.Printf("wrote %s\n", dir)
+	}
+
+	return nil
+}
+
+func backfillUnrated(args []string) error {
+	flags := flag.NewFlagSet("backfill-unrated", flag.ExitOnError)
+	table := flags.String("table", "", "submissions table name (default $SUBMISSIONS_TABLE or SubmissionsV2)")
+	flags.Parse(args)
+	if flags.NArg() != 0 {
+		usage()
+	}
+	if *table != "" || os.Getenv("SUBMISSIONS_TABLE") == "" {
+		if *table == "" {
+			*table = "SubmissionsV2"
+		}
+		os.Setenv("SUBMISSIONS_TABLE", *table)
+	}
+
+	added, err := db.BackfillUnrated(context.Background())
+	fmt.Printf("%d submissions added to the unrated index\n", added)
+	return err
+}
+
+func backfillJudging(args []string) error {
+	flags := flag.NewFlagSet("backfill-judging", flag.ExitOnError)
+	table := flags.String("table", "", "submissions table name (default $SUBMISSIONS_TABLE or SubmissionsV2)")
+	flags.Parse(args)
+	if flags.NArg() != 0 {
+		usage()
+	}
+	if *table != "" || os.Getenv("SUBMISSIONS_TABLE") == "" {
+		if *table == "" {
+			*table = "SubmissionsV2"
+		}
+		os.Setenv("SUBMISSIONS_TABLE", *table)
+	}
+
+	added, err := db.BackfillJudging(context.Background())
+	fmt.Printf("%d submissions added to the judging index\n", added)
+	return err
+}
+
+// packageDirs returns dir itself if it is a package, otherwise its immediate
+// subdirectories that are packages.
+func packageDirs(dir string) ([]string, error) {
+	if _, err := os.Stat(filepath.Join(dir, "problem.yaml")); err == nil {
+		return []string{dir}, nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var dirs []string
+	for _, entry := range entries {
+		p := filepath.Join(dir, entry.Name())
+		if _, err := os.Stat(filepath.Join(p, "problem.yaml")); entry.IsDir() && err == nil {
+			dirs = append(dirs, p)
+		}
+	}
+	if len(dirs) == 0 {
+		return nil, fmt.Errorf("no problem packages found in %s", dir)
+	}
+
+	return dirs, nil
+}
+
+// diff lists the top-level fields that differ between two problems, one
+// "  field: old -> new" line each.
+func diff(before *types.Problem, after *types.Problem) ([]string, error) {
+	beforeFields, err := fields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := fields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := map[string]bool{}
+	for key := range beforeFields {
+		keys[key] = true
+	}
+	for key := range afterFields {
+		keys[key] = true
+	}
+
+	var changes []string
+	for key := range keys {
+		if reflect.DeepEqual(beforeFields[key], afterFields[key]) {
+			continue
+		}
+		beforeList, beforeIsList := beforeFields[key].([]interface{})
+		afterList, afterIsList := afterFields[key].([]interface{})
+		if beforeIsList && afterIsList && len(beforeList) == len(afterList) {
+			changes = append(changes, fmt.Sprintf("  %s: %d items changed", key, len(afterList)))
+			continue
+		}
+		changes = append(changes, fmt.Sprintf("  %s: %s -> %s", key, summarize(beforeFields[key]), summarize(afterFields[key])))
+	}
+	sort.Strings(changes)
+
+	return changes, nil
+}
+
+func fields(problem *types.Problem) (map[string]interface{}, error) {
+	data, err := json.Marshal(problem)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal problem: %v", err)
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal problem: %v", err)
+	}
+	return m, nil
+}
+
+func summarize(value interface{}) string {
+	if value == nil {
+		return "(none)"
+	}
+	if list, ok := value.([]interface{}); ok {
+		return fmt.Sprintf("[%d items]", len(list))
+	}
+
+	data, _ := json.Marshal(value)
+	s := strings.ReplaceAll(string(data), "\n", `\n`)
+	if len(s) > maxDiffValueLength {
+		s = s[:maxDiffValueLength] + "..."
+	}
+	return s
+}
@@ -0,0 +1,110 @@
+// Command learncode-events is a local stand-in for the Momento topics that
+// carry submission events (see package notify), for development and tests
+// without a Momento account.
+//
+// Usage:
+//
+//	learncode-events [-addr :8090]
+//
+// Point the runners and the submit lambda at it with
+// NOTIFY_URL=http://localhost:8090. They POST each event to
+// /topics/<topic>, and every client connected to GET /topics/<topic>
+// receives it as a Server-Sent Event:
+//
+//	curl -N http://localhost:8090/topics/submissions-<user id>
+//
+// Events are not stored; only clients connected at the time get them.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+)
+
+// Slow clients miss events rather than holding up publishers
+const subscriberBuffer = 64
+
+type broker struct {
+	mu          sync.Mutex
+	subscribers map[string]map[chan []byte]bool
+}
+
+func main() {
+	addr := flag.String("addr", ":8090", "address to listen on")
+	flag.Parse()
+
+	b := &broker{subscribers: map[string]map[chan []byte]bool{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /topics/{topic}", b.publish)
+	mux.HandleFunc("GET /topics/{topic}", b.subscribe)
+
+	log.Printf("listening on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, mux))
+}
+
+func (b *broker) publish(w http.ResponseWriter, r *http.Request) {
+	message, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	topic := r.PathValue("topic")
+	b.mu.Lock()
+	for ch := range b.subscribers[topic] {
+		select {
+		case ch <- message:
+		default:
+		}
+	}
+	b.mu.Unlock()
+
+	log.Printf("%s: %s", topic, message)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (b *broker) subscribe(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	topic := r.PathValue("topic")
+	ch := make(chan []byte, subscriberBuffer)
+	b.mu.Lock()
+	if b.subscribers[topic] == nil {
+		b.subscribers[topic] = map[chan []byte]bool{}
+	}
+	b.subscribers[topic][ch] = true
+	b.mu.Unlock()
+
+	defer func() {
+		b.mu.Lock()
+		delete(b.subscribers[topic], ch)
+		if len(b.subscribers[topic]) == 0 {
+			delete(b.subscribers, topic)
+		}
+		b.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case message := <-ch:
+			fmt.Fprintf(w, "data: %s\n\n", message)
+			flusher.Flush()
+		}
+	}
+}
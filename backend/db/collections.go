@@ -0,0 +1,99 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"learncode/backend/types"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	dbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+var ErrCollectionNotFound = errors.New("collection not found")
+
+func GetCollection(ctx context.Context, collectionID string) (*types.Collection, error) {
+	result, err := client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(os.Getenv("COLLECTIONS_TABLE")),
+		Key: map[string]dbtypes.AttributeValue{
+			"id": &dbtypes.AttributeValueMemberS{Value: collectionID},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get collection: %v", err)
+	}
+
+	if result.Item == nil {
+		return nil, fmt.Errorf("%w: %s", ErrCollectionNotFound, collectionID)
+	}
+
+	var collection types.Collection
+	if err := attributevalue.UnmarshalMap(result.Item, &collection); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal collection: %v", err)
+	}
+
+	return &collection, nil
+}
+
+func GetCollections(ctx context.Context) ([]types.Collection, error) {
+	var collections []types.Collection
+	paginator := dynamodb.NewScanPaginator(client, &dynamodb.ScanInput{
+		TableName: aws.String(os.Getenv("COLLECTIONS_TABLE")),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan collections: %v", err)
+		}
+		var items []types.Collection
+		if err := attributevalue.UnmarshalListOfMaps(page.Items, &items); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal collections: %v", err)
+		}
+		collections = append(collections, items...)
+	}
+
+	return collections, nil
+}
+
+func SaveCollection(ctx context.Context, collection *types.Collection) error {
+	item, err := attributevalue.MarshalMap(collection)
+	if err != nil {
+		return fmt.Errorf("failed to marshal collection: %v", err)
+	}
+
+	_, err = client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(os.Getenv("COLLECTIONS_TABLE")),
+		Item:      item,
+	})
+	return err
+}
+
+func DeleteCollection(ctx context.Context, collectionID string) error {
+	_, err := client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(os.Getenv("COLLECTIONS_TABLE")),
+		Key: map[string]dbtypes.AttributeValue{
+			"id": &dbtypes.AttributeValueMemberS{Value: collectionID},
+		},
+	})
+	return err
+}
+
+// CreateCollection saves a new collection, failing rather than overwriting
+// a collection with the same ID.
+func CreateCollection(ctx context.Context, collection *types.Collection) error {
+	item, err := attributevalue.MarshalMap(collection)
+	if err != nil {
+		return fmt.Errorf("failed to marshal collection: %v", err)
+	}
+
+	_, err = client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(os.Getenv("COLLECTIONS_TABLE")),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(id)"),
+	})
+	return err
+}
@@ -0,0 +1,154 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"learncode/backend/types"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	dbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+var ErrDeadLetterNotFound = errors.New("dead letter not found")
+
+// ErrDeadLetterReplayed is returned when a dead letter has been replayed
+// already.
+var ErrDeadLetterReplayed = errors.New("dead letter already replayed")
+
+func SaveDeadLetter(ctx context.Context, deadLetter *types.DeadLetter) error {
+	item, err := attributevalue.MarshalMap(deadLetter)
+	if err != nil {
+		return fmt.Errorf("failed to marshal dead letter: %v", err)
+	}
+
+	_, err = client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(os.Getenv("DEAD_LETTERS_TABLE")),
+		Item:      item,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save dead letter: %v", err)
+	}
+	return nil
+}
+
+func GetDeadLetter(ctx context.Context, id string) (*types.DeadLetter, error) {
+	result, err := client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(os.Getenv("DEAD_LETTERS_TABLE")),
+		Key: map[string]dbtypes.AttributeValue{
+			"id": &dbtypes.AttributeValueMemberS{Value: id},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dead letter: %v", err)
+	}
+	if result.Item == nil {
+		return nil, fmt.Errorf("%w: %s", ErrDeadLetterNotFound, id)
+	}
+
+	var deadLetter types.DeadLetter
+	if err := attributevalue.UnmarshalMap(result.Item, &deadLetter); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal dead letter: %v", err)
+	}
+	return &deadLetter, nil
+}
+
+func GetDeadLetters(ctx context.Context) ([]types.DeadLetter, error) {
+	paginator := dynamodb.NewScanPaginator(client, &dynamodb.ScanInput{
+		TableName: aws.String(os.Getenv("DEAD_LETTERS_TABLE")),
+	})
+
+	var deadLetters []types.DeadLetter
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan dead letters: %v", err)
+		}
+		var items []types.DeadLetter
+		if err := attributevalue.UnmarshalListOfMaps(page.Items, &items); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal dead letters: %v", err)
+		}
+		deadLetters = append(deadLetters, items...)
+	}
+	return deadLetters, nil
+}
+
+// ReplayDeadLetter puts a dead letter's submission back to pending so it
+// can be judged from scratch, clearing its earlier result, and records when
+// it was replayed, in one transaction. It fails with ErrSubmissionChanged if
+// the submission is no longer in system_error, and with
+// ErrDeadLetterReplayed if the dead letter was replayed already. A replay
+// that fails again saves a new dead letter in its place.
+func ReplayDeadLetter(ctx context.Context, deadLetter *types.DeadLetter, now int64) error {
+	submission := deadLetter.Submission
+	values := map[string]dbtypes.AttributeValue{
+		":system_error": &dbtypes.AttributeValueMemberS{Value: string(types.StatusSystemError)},
+		":now":          &dbtypes.AttributeValueMemberN{Value: strconv.FormatInt(now, 10)},
+	}
+	set, remove, err := statusTransition(types.StatusPending, now, values)
+	if err != nil {
+		return err
+	}
+
+	_, err = client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []dbtypes.TransactWriteItem{
+			{
+				Update: &dbtypes.Update{
+					TableName: aws.String(os.Getenv("SUBMISSIONS_TABLE")),
+					Key: map[string]dbtypes.AttributeValue{
+						"problem_id":    &dbtypes.AttributeValueMemberS{Value: submission.ProblemID},
+						"submission_id": &dbtypes.AttributeValueMemberS{Value: submission.SubmissionID},
+					},
+					UpdateExpression:    aws.String(buildUpdate([]string{set, "updated_at = :now"}, append(remove, "error_reason", "retries", "verdict", "tests", "#result"))),
+					ConditionExpression: aws.String("#status = :system_error"),
+					ExpressionAttributeNames: map[string]string{
+						"#status": "status",
+						"#result": "result",
+					},
+					ExpressionAttributeValues: values,
+				},
+			},
+			{
+				Update: &dbtypes.Update{
+					TableName: aws.String(os.Getenv("DEAD_LETTERS_TABLE")),
+					Key: map[string]dbtypes.AttributeValue{
+						"id": &dbtypes.AttributeValueMemberS{Value: deadLetter.ID},
+					},
+					UpdateExpression:    aws.String("SET replayed_at = :replayed_at"),
+					ConditionExpression: aws.String("attribute_exists(id) AND attribute_not_exists(replayed_at)"),
+					ExpressionAttributeValues: map[string]dbtypes.AttributeValue{
+						":replayed_at": &dbtypes.AttributeValueMemberN{Value: strconv.FormatInt(now, 10)},
+					},
+				},
+			},
+		},
+	})
+	var canceledErr *dbtypes.TransactionCanceledException
+	if errors.As(err, &canceledErr) && len(canceledErr.CancellationReasons) == 2 {
+		if aws.ToString(canceledErr.CancellationReasons[0].Code) == "ConditionalCheckFailed" {
+			return fmt.Errorf("%w: %s is no longer in %s", ErrSubmissionChanged, submission.SubmissionID, types.StatusSystemError)
+		}
+		if aws.ToString(canceledErr.CancellationReasons[1].Code) == "ConditionalCheckFailed" {
+			return fmt.Errorf("%w: %s", ErrDeadLetterReplayed, deadLetter.ID)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to replay dead letter: %v", err)
+	}
+
+	submission.Status = types.StatusPending
+	submission.UpdatedAt = now
+	submission.StatusHistory = append(submission.StatusHistory, types.StatusChange{Status: types.StatusPending, At: now})
+	submission.ErrorReason = ""
+	submission.Retries = 0
+	submission.Verdict = ""
+	submission.Tests = nil
+	submission.Result = nil
+	deadLetter.ReplayedAt = now
+	return nil
+}
@@ -2,8 +2,11 @@ package db
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"learncode/backend/types"
@@ -17,6 +20,15 @@ import (
 
 var client *dynamodb.Client
 
+var ErrProblemNotFound = errors.New("problem not found")
+
+var ErrProblemExists = errors.New("problem already exists")
+
+// ErrProblemChanged is returned when a problem changed since it was read.
+var ErrProblemChanged = errors.New("problem changed")
+
+var ErrUserNotFound = errors.New("user not found")
+
 func init() {
 	cfg, err := config.LoadDefaultConfig(context.Background())
 	if err != nil {
@@ -25,33 +37,50 @@ func init() {
 	client = dynamodb.NewFromConfig(cfg)
 }
 
-func UpdateSubmissionStatus(ctx context.Context, problemId string, submissionId string, status string, result *string) error {
-	updateExpression := "SET #status = :status, #updated_at = :updated_at"
+// UpdateSubmissionStatus moves a submission to status, and sets its result
+// if not nil. It fails with ErrInvalidTransition if the submission's current
+// status can't move to status (see types.CanTransition).
+func UpdateSubmissionStatus(ctx context.Context, problemId string, submissionId string, status types.SubmissionStatus, result *string) error {
 	expressionAttributeNames := map[string]string{
 		"#status":     "status",
 		"#updated_at": "updated_at",
 	}
+	now := time.Now().Unix()
 	expressionAttributeValues := map[string]dbtypes.AttributeValue{
-		":status":     &dbtypes.AttributeValueMemberS{Value: status},
-		":updated_at": &dbtypes.AttributeValueMemberN{Value: fmt.Sprintf("%d", time.Now().Unix())},
+		":updated_at": &dbtypes.AttributeValueMemberN{Value: fmt.Sprintf("%d", now)},
+	}
+	set, remove, err := statusTransition(status, now, expressionAttributeValues)
+	if err != nil {
+		return err
 	}
+	condition, err := allowedStatusCondition(status, expressionAttributeValues)
+	if err != nil {
+		return err
+	}
+	sets := []string{set, "#updated_at = :updated_at"}
 
 	if result != nil {
-		updateExpression += ", #result = :result"
+		sets = append(sets, "#result = :result")
 		expressionAttributeNames["#result"] = "result"
 		expressionAttributeValues[":result"] = &dbtypes.AttributeValueMemberS{Value: *result}
 	}
+	updateExpression := buildUpdate(sets, remove)
 
-	_, err := client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+	_, err = client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
 		TableName: aws.String(os.Getenv("SUBMISSIONS_TABLE")),
 		Key: map[string]dbtypes.AttributeValue{
 			"problem_id":    &dbtypes.AttributeValueMemberS{Value: problemId},
 			"submission_id": &dbtypes.AttributeValueMemberS{Value: submissionId},
 		},
 		UpdateExpression:          &updateExpression,
+		ConditionExpression:       &condition,
 		ExpressionAttributeNames:  expressionAttributeNames,
 		ExpressionAttributeValues: expressionAttributeValues,
 	})
+	var conditionErr *dbtypes.ConditionalCheckFailedException
+	if errors.As(err, &conditionErr) {
+		return fmt.Errorf("%w: %s to %s", ErrInvalidTransition, submissionId, status)
+	}
 
 	return err
 }
@@ -68,7 +97,7 @@ func GetProblem(ctx context.Context, problemID string) (*types.Problem, error) {
 	}
 
 	if result.Item == nil {
-		return nil, fmt.Errorf("problem not found: %s", problemID)
+		return nil, fmt.Errorf("%w: %s", ErrProblemNotFound, problemID)
 	}
 
 	var problem types.Problem
@@ -84,6 +113,9 @@ func SaveSubmission(ctx context.Context, submission *types.Submission) error {
 	if err != nil {
 		return fmt.Errorf("failed to marshal submission: %v", err)
 	}
+	if submission.Status.Judging() {
+		item["judging"] = &dbtypes.AttributeValueMemberS{Value: judging}
+	}
 
 	_, err = client.PutItem(ctx, &dynamodb.PutItemInput{
 		TableName: aws.String(os.Getenv("SUBMISSIONS_TABLE")),
@@ -92,17 +124,44 @@ func SaveSubmission(ctx context.Context, submission *types.Submission) error {
 	return err
 }
 
+// listingAttributes are the problem attributes GetProblems returns. Tests,
+// which can make up most of an item, and the other judge-only data are left
+// out.
+var listingAttributes = []string{
+	"id", "title", "slug", "description", "difficulty", "rating", "rating_locked",
+	"created_at", "updated_at", "deleted_at", "example_input", "example_output",
+	"time_limit_ms", "memory_limit_mb", "starter_code", "allowed_languages",
+	"signature", "query_limit", "sql", "editorial_attempts", "status", "publish_at",
+	"reviews", "locale", "translations",
+}
+
+// GetProblems returns every problem for listing, without its tests or other
+// judge-only data. Use GetProblem for the whole problem.
 func GetProblems(ctx context.Context) ([]types.Problem, error) {
-	result, err := client.Scan(ctx, &dynamodb.ScanInput{
-		TableName: aws.String(os.Getenv("PROBLEMS_TABLE")),
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to scan problems: %v", err)
+	// Attribute names such as status are reserved words, so all are aliased
+	names := map[string]string{}
+	projection := make([]string, len(listingAttributes))
+	for i, attribute := range listingAttributes {
+		names["#"+attribute] = attribute
+		projection[i] = "#" + attribute
 	}
+	paginator := dynamodb.NewScanPaginator(client, &dynamodb.ScanInput{
+		TableName:                aws.String(os.Getenv("PROBLEMS_TABLE")),
+		ProjectionExpression:     aws.String(strings.Join(projection, ", ")),
+		ExpressionAttributeNames: names,
+	})
 
 	var problems []types.Problem
-	if err := attributevalue.UnmarshalListOfMaps(result.Items, &problems); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal problems: %v", err)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan problems: %v", err)
+		}
+		var items []types.Problem
+		if err := attributevalue.UnmarshalListOfMaps(page.Items, &items); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal problems: %v", err)
+		}
+		problems = append(problems, items...)
 	}
 
 	return problems, nil
@@ -144,19 +203,44 @@ func GetUser(ctx context.Context, userID string) (*types.User, error) {
 	return &user, nil
 }
 
-func GetSubmissionsByProblemAndType(ctx context.Context, submissionID string, problemID string, submissionType string, userId string) ([]types.Submission, error) {
-	// Initialize DynamoDB client
-	cfg, err := config.LoadDefaultConfig(ctx)
-	if err != nil {
-		fmt.Printf("Failed to load AWS config: %v\n", err)
-		return nil, fmt.Errorf("failed to load AWS config: %v", err)
+// SetUserRole sets a user's role, or makes them a learner again if role is
+// empty.
+func SetUserRole(ctx context.Context, userID string, role string) error {
+	input := &dynamodb.UpdateItemInput{
+		TableName: aws.String(os.Getenv("USERS_TABLE")),
+		Key: map[string]dbtypes.AttributeValue{
+			"id": &dbtypes.AttributeValueMemberS{Value: userID},
+		},
+		UpdateExpression:    aws.String("REMOVE #role"),
+		ConditionExpression: aws.String("attribute_exists(id)"),
+		ExpressionAttributeNames: map[string]string{
+			"#role": "role",
+		},
+	}
+	if role != "" {
+		input.UpdateExpression = aws.String("SET #role = :role")
+		input.ExpressionAttributeValues = map[string]dbtypes.AttributeValue{
+			":role": &dbtypes.AttributeValueMemberS{Value: role},
+		}
 	}
 
-	client := dynamodb.NewFromConfig(cfg)
+	_, err := client.UpdateItem(ctx, input)
+	var conditionErr *dbtypes.ConditionalCheckFailedException
+	if errors.As(err, &conditionErr) {
+		return fmt.Errorf("%w: %s", ErrUserNotFound, userID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set user role: %v", err)
+	}
+	return nil
+}
 
+// GetSubmissionsByProblemAndType returns the user's submissions of one type
+// to a problem whose submission IDs start with submissionID.
+func GetSubmissionsByProblemAndType(ctx context.Context, submissionID string, problemID string, submissionType string, userId string) ([]types.Submission, error) {
 	// Query submissions using begins_with and filter by type
-	input := &dynamodb.QueryInput{
-		TableName:              aws.String("SubmissionsV2"),
+	paginator := dynamodb.NewQueryPaginator(client, &dynamodb.QueryInput{
+		TableName:              aws.String(os.Getenv("SUBMISSIONS_TABLE")),
 		KeyConditionExpression: aws.String("problem_id = :problem_id AND begins_with(submission_id, :prefix)"),
 		ExpressionAttributeValues: map[string]dbtypes.AttributeValue{
 			":problem_id": &dbtypes.AttributeValueMemberS{Value: problemID},
@@ -168,28 +252,216 @@ func GetSubmissionsByProblemAndType(ctx context.Context, submissionID string, pr
 		ExpressionAttributeNames: map[string]string{
 			"#t": "type",
 		},
+	})
+
+	var submissions []types.Submission
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query submissions: %v", err)
+		}
+		var items []types.Submission
+		if err := attributevalue.UnmarshalListOfMaps(page.Items, &items); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal submission: %v", err)
+		}
+		submissions = append(submissions, items...)
 	}
 
-	fmt.Printf("Executing DynamoDB query with input: %+v\n", input)
-	result, err := client.Query(ctx, input)
+	return submissions, nil
+}
+
+func SaveProblem(ctx context.Context, problem *types.Problem) error {
+	item, err := attributevalue.MarshalMap(problem)
 	if err != nil {
-		fmt.Printf("DynamoDB query failed: %v\n", err)
-		return nil, fmt.Errorf("failed to query submissions: %v", err)
+		return fmt.Errorf("failed to marshal problem: %v", err)
 	}
 
-	fmt.Printf("Query returned %d items\n", len(result.Items))
+	_, err = client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(os.Getenv("PROBLEMS_TABLE")),
+		Item:      item,
+	})
+	return err
+}
 
-	// Convert DynamoDB items to Submission structs
-	var submissions []types.Submission
-	for _, item := range result.Items {
-		var submission types.Submission
-		if err := attributevalue.UnmarshalMap(item, &submission); err != nil {
-			fmt.Printf("Failed to unmarshal item: %v\n", err)
-			return nil, fmt.Errorf("failed to unmarshal submission: %v", err)
+// CreateProblem saves a new problem together with a unique slug derived
+// from its title, in one transaction so that neither is left without the
+// other. It fails with ErrProblemExists rather than overwriting a problem
+// with the same ID.
+func CreateProblem(ctx context.Context, problem *types.Problem) error {
+	problem.Slug = ""
+	err := putProblem(ctx, problem, "attribute_not_exists(id)", nil)
+	if errors.Is(err, errProblemCondition) {
+		return fmt.Errorf("%w: %s", ErrProblemExists, problem.ID)
+	}
+	return err
+}
+
+// UpdateProblem saves changes to an existing problem, together with a new
+// slug if it was renamed, in one transaction. updatedAt and rating are the
+// problem's when it was read; if either changed since, for example because
+// the rating job rated it, it fails with ErrProblemChanged rather than
+// overwriting the newer version.
+func UpdateProblem(ctx context.Context, problem *types.Problem, updatedAt int64, rating int) error {
+	condition := "updated_at = :updated_at AND "
+	values := map[string]dbtypes.AttributeValue{
+		":updated_at": &dbtypes.AttributeValueMemberN{Value: strconv.FormatInt(updatedAt, 10)},
+	}
+	if rating == 0 {
+		condition += "attribute_not_exists(rating)"
+	} else {
+		condition += "rating = :rating"
+		values[":rating"] = &dbtypes.AttributeValueMemberN{Value: strconv.Itoa(rating)}
+	}
+
+	err := putProblem(ctx, problem, condition, values)
+	if errors.Is(err, errProblemCondition) {
+		return fmt.Errorf("%w: %s", ErrProblemChanged, problem.ID)
+	}
+	return err
+}
+
+// errProblemCondition is returned by putProblem when the problem's
+// condition fails.
+var errProblemCondition = errors.New("problem condition failed")
+
+// putProblem writes the problem if condition holds. A problem whose slug
+// no longer matches its title gets a new one in the same transaction,
+// trying numbered variants until a free one is found; the old slug keeps
+// pointing at it.
+func putProblem(ctx context.Context, problem *types.Problem, condition string, values map[string]dbtypes.AttributeValue) error {
+	previous := problem.Slug
+	keepSlug := previous != "" && types.SlugMatchesTitle(previous, problem.Title)
+
+	for n := 1; n <= maxSlugAttempts; n++ {
+		transactItems := []dbtypes.TransactWriteItem{{}}
+		if !keepSlug {
+			problem.Slug = types.SlugCandidate(problem.Title, n)
+			slug, err := slugPut(problem.Slug, problem.ID)
+			if err != nil {
+				problem.Slug = previous
+				return err
+			}
+			transactItems = append(transactItems, dbtypes.TransactWriteItem{Put: slug})
+		}
+		item, err := attributevalue.MarshalMap(problem)
+		if err != nil {
+			problem.Slug = previous
+			return fmt.Errorf("failed to marshal problem: %v", err)
+		}
+		transactItems[0].Put = &dbtypes.Put{
+			TableName:                 aws.String(os.Getenv("PROBLEMS_TABLE")),
+			Item:                      item,
+			ConditionExpression:       aws.String(condition),
+			ExpressionAttributeValues: values,
 		}
-		submissions = append(submissions, submission)
+
+		_, err = client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+			TransactItems: transactItems,
+		})
+		var canceledErr *dbtypes.TransactionCanceledException
+		if errors.As(err, &canceledErr) && len(canceledErr.CancellationReasons) == len(transactItems) {
+			if aws.ToString(canceledErr.CancellationReasons[0].Code) == "ConditionalCheckFailed" {
+				problem.Slug = previous
+				return errProblemCondition
+			}
+			if !keepSlug && aws.ToString(canceledErr.CancellationReasons[1].Code) == "ConditionalCheckFailed" {
+				continue // The slug is taken
+			}
+		}
+		if err != nil {
+			problem.Slug = previous
+			return fmt.Errorf("failed to save problem: %v", err)
+		}
+		return nil
 	}
+	problem.Slug = previous
+	return fmt.Errorf("no free slug for %q", problem.Title)
+}
 
-	fmt.Printf("Successfully processed %d submissions\n", len(submissions))
-	return submissions, nil
+// SetRevealedHints records how many hints of a problem the user has
+// revealed. The count only ever grows, so concurrent reveals can't undo
+// each other.
+func SetRevealedHints(ctx context.Context, userID string, problemID string, count int) error {
+	key := map[string]dbtypes.AttributeValue{
+		"id": &dbtypes.AttributeValueMemberS{Value: userID},
+	}
+	countValue := &dbtypes.AttributeValueMemberN{Value: fmt.Sprintf("%d", count)}
+
+	_, err := client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:           aws.String(os.Getenv("USERS_TABLE")),
+		Key:                 key,
+		UpdateExpression:    aws.String("SET revealed_hints.#problem = :count"),
+		ConditionExpression: aws.String("attribute_exists(revealed_hints) AND (attribute_not_exists(revealed_hints.#problem) OR revealed_hints.#problem < :count)"),
+		ExpressionAttributeNames: map[string]string{
+			"#problem": problemID,
+		},
+		ExpressionAttributeValues: map[string]dbtypes.AttributeValue{
+			":count": countValue,
+		},
+	})
+	var conditionErr *dbtypes.ConditionalCheckFailedException
+	if err == nil || !errors.As(err, &conditionErr) {
+		return err
+	}
+
+	// Either the map doesn't exist yet or the count is already higher
+	_, err = client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:           aws.String(os.Getenv("USERS_TABLE")),
+		Key:                 key,
+		UpdateExpression:    aws.String("SET revealed_hints = :hints"),
+		ConditionExpression: aws.String("attribute_not_exists(revealed_hints)"),
+		ExpressionAttributeValues: map[string]dbtypes.AttributeValue{
+			":hints": &dbtypes.AttributeValueMemberM{Value: map[string]dbtypes.AttributeValue{
+				problemID: countValue,
+			}},
+		},
+	})
+	if errors.As(err, &conditionErr) {
+		return nil
+	}
+	return err
+}
+
+// GetProblemsByID loads the given problems, keyed by ID. IDs without a
+// problem are left out of the result.
+func GetProblemsByID(ctx context.Context, problemIDs []string) (map[string]*types.Problem, error) {
+	problems := map[string]*types.Problem{}
+	table := os.Getenv("PROBLEMS_TABLE")
+
+	// BatchGetItem takes at most 100 keys per call
+	for start := 0; start < len(problemIDs); start += 100 {
+		end := min(start+100, len(problemIDs))
+		var keys []map[string]dbtypes.AttributeValue
+		seen := map[string]bool{}
+		for _, id := range problemIDs[start:end] {
+			if !seen[id] {
+				seen[id] = true
+				keys = append(keys, map[string]dbtypes.AttributeValue{
+					"id": &dbtypes.AttributeValueMemberS{Value: id},
+				})
+			}
+		}
+
+		requests := map[string]dbtypes.KeysAndAttributes{table: {Keys: keys}}
+		for len(requests) > 0 {
+			result, err := client.BatchGetItem(ctx, &dynamodb.BatchGetItemInput{RequestItems: requests})
+			if err != nil {
+				return nil, fmt.Errorf("failed to get problems: %v", err)
+			}
+			for _, item := range result.Responses[table] {
+				var problem types.Problem
+				if err := attributevalue.UnmarshalMap(item, &problem); err != nil {
+					return nil, fmt.Errorf("failed to unmarshal problem: %v", err)
+				}
+				problems[problem.ID] = &problem
+			}
+			// Throttled keys come back unprocessed; retry them after a pause
+			requests = result.UnprocessedKeys
+			if len(requests) > 0 {
+				time.Sleep(100 * time.Millisecond)
+			}
+		}
+	}
+
+	return problems, nil
 }
@@ -0,0 +1,116 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	dbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// IdempotencyRecord ties a client's idempotency key to the submission its
+// first request created. The table's TTL removes it at ExpiresAt.
+type IdempotencyRecord struct {
+	Key          string `dynamodbav:"key"` // User ID and the client's key
+	RequestHash  string `dynamodbav:"request_hash"`
+	ProblemID    string `dynamodbav:"problem_id"`
+	SubmissionID string `dynamodbav:"submission_id"`
+	CreatedAt    int64  `dynamodbav:"created_at"`
+	ExpiresAt    int64  `dynamodbav:"expires_at"`
+	Publishing   bool   `dynamodbav:"publishing,omitempty"` // Claimed by the request publishing the submission
+}
+
+// ClaimIdempotencyKey stores the record unless an unexpired record with the
+// same key exists, in which case that record is returned instead.
+func ClaimIdempotencyKey(ctx context.Context, record *IdempotencyRecord) (*IdempotencyRecord, error) {
+	item, err := attributevalue.MarshalMap(record)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal idempotency record: %v", err)
+	}
+
+	// TTL deletion can lag, so expired records count as free
+	_, err = client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(os.Getenv("IDEMPOTENCY_TABLE")),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(#key) OR expires_at < :now"),
+		ExpressionAttributeNames: map[string]string{
+			"#key": "key",
+		},
+		ExpressionAttributeValues: map[string]dbtypes.AttributeValue{
+			":now": &dbtypes.AttributeValueMemberN{Value: strconv.FormatInt(record.CreatedAt, 10)},
+		},
+		ReturnValuesOnConditionCheckFailure: dbtypes.ReturnValuesOnConditionCheckFailureAllOld,
+	})
+	var conditionErr *dbtypes.ConditionalCheckFailedException
+	if errors.As(err, &conditionErr) {
+		var existing IdempotencyRecord
+		if err := attributevalue.UnmarshalMap(conditionErr.Item, &existing); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal idempotency record: %v", err)
+		}
+		return &existing, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim idempotency key: %v", err)
+	}
+	return nil, nil
+}
+
+// ReleaseIdempotencyKey frees a key whose request failed before saving its
+// submission, so that a retry can go through.
+func ReleaseIdempotencyKey(ctx context.Context, key string) error {
+	_, err := client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(os.Getenv("IDEMPOTENCY_TABLE")),
+		Key: map[string]dbtypes.AttributeValue{
+			"key": &dbtypes.AttributeValueMemberS{Value: key},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to release idempotency key: %v", err)
+	}
+	return nil
+}
+
+// ClaimPublish claims the job of publishing the record's submission to the
+// runners, so that a retry doesn't publish a submission the first attempt
+// is publishing. It reports false if another request holds the claim.
+func ClaimPublish(ctx context.Context, key string) (bool, error) {
+	_, err := client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:           aws.String(os.Getenv("IDEMPOTENCY_TABLE")),
+		Key:                 map[string]dbtypes.AttributeValue{"key": &dbtypes.AttributeValueMemberS{Value: key}},
+		UpdateExpression:    aws.String("SET publishing = :true"),
+		ConditionExpression: aws.String("attribute_exists(#key) AND attribute_not_exists(publishing)"),
+		ExpressionAttributeNames: map[string]string{
+			"#key": "key",
+		},
+		ExpressionAttributeValues: map[string]dbtypes.AttributeValue{
+			":true": &dbtypes.AttributeValueMemberBOOL{Value: true},
+		},
+	})
+	var conditionErr *dbtypes.ConditionalCheckFailedException
+	if errors.As(err, &conditionErr) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to claim publishing: %v", err)
+	}
+	return true, nil
+}
+
+// ReleasePublish gives up a ClaimPublish claim after publishing failed, so
+// that a retry can publish the submission.
+func ReleasePublish(ctx context.Context, key string) error {
+	_, err := client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:        aws.String(os.Getenv("IDEMPOTENCY_TABLE")),
+		Key:              map[string]dbtypes.AttributeValue{"key": &dbtypes.AttributeValueMemberS{Value: key}},
+		UpdateExpression: aws.String("REMOVE publishing"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to release publishing: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	dbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Counter is a fixed-window counter in the rate limits table. Its key names
+// the window, and the table's TTL removes it once ExpiresAt has passed.
+type Counter struct {
+	Key       string
+	Limit     int
+	ExpiresAt int64
+}
+
+// IncrementCounters adds one to every counter in a single transaction, so
+// either all of them count the request or none do. If a counter is already
+// at its limit nothing changes and its index is returned; otherwise the
+// index is -1.
+func IncrementCounters(ctx context.Context, counters []Counter) (int, error) {
+	items := make([]dbtypes.TransactWriteItem, len(counters))
+	for i, counter := range counters {
+		items[i] = dbtypes.TransactWriteItem{
+			Update: &dbtypes.Update{
+				TableName: aws.String(os.Getenv("RATE_LIMITS_TABLE")),
+				Key: map[string]dbtypes.AttributeValue{
+					"key": &dbtypes.AttributeValueMemberS{Value: counter.Key},
+				},
+				UpdateExpression:    aws.String("ADD #count :one SET expires_at = :expires_at"),
+				ConditionExpression: aws.String("attribute_not_exists(#count) OR #count < :limit"),
+				ExpressionAttributeNames: map[string]string{
+					"#count": "count",
+				},
+				ExpressionAttributeValues: map[string]dbtypes.AttributeValue{
+					":one":        &dbtypes.AttributeValueMemberN{Value: "1"},
+					":limit":      &dbtypes.AttributeValueMemberN{Value: strconv.Itoa(counter.Limit)},
+					":expires_at": &dbtypes.AttributeValueMemberN{Value: strconv.FormatInt(counter.ExpiresAt, 10)},
+				},
+			},
+		}
+	}
+
+	_, err := client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: items,
+	})
+	var canceledErr *dbtypes.TransactionCanceledException
+	if errors.As(err, &canceledErr) {
+		for i, reason := range canceledErr.CancellationReasons {
+			if aws.ToString(reason.Code) == "ConditionalCheckFailed" {
+				return i, nil
+			}
+		}
+	}
+	if err != nil {
+		return -1, fmt.Errorf("failed to update rate limits: %v", err)
+	}
+	return -1, nil
+}
+
+// DecrementCounters takes back one from every counter, for requests that
+// were counted but failed before doing anything. Counters are never taken
+// below zero.
+func DecrementCounters(ctx context.Context, counters []Counter) error {
+	items := make([]dbtypes.TransactWriteItem, len(counters))
+	for i, counter := range counters {
+		items[i] = dbtypes.TransactWriteItem{
+			Update: &dbtypes.Update{
+				TableName: aws.String(os.Getenv("RATE_LIMITS_TABLE")),
+				Key: map[string]dbtypes.AttributeValue{
+					"key": &dbtypes.AttributeValueMemberS{Value: counter.Key},
+				},
+				UpdateExpression:    aws.String("ADD #count :minus_one"),
+				ConditionExpression: aws.String("#count > :zero"),
+				ExpressionAttributeNames: map[string]string{
+					"#count": "count",
+				},
+				ExpressionAttributeValues: map[string]dbtypes.AttributeValue{
+					":minus_one": &dbtypes.AttributeValueMemberN{Value: "-1"},
+					":zero":      &dbtypes.AttributeValueMemberN{Value: "0"},
+				},
+			},
+		}
+	}
+
+	_, err := client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: items,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to release rate limits: %v", err)
+	}
+	return nil
+}
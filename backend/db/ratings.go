@@ -0,0 +1,182 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"learncode/backend/types"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	dbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// UnratedIndex is the sparse submissions table GSI of the judged SUBMIT
+// submissions the rating job hasn't counted yet, which are the only ones
+// with the unrated attribute. It is sorted by created_at.
+const UnratedIndex = "unrated-created_at-index"
+
+// unrated is the value of the unrated attribute, and so the index's only
+// partition.
+const unrated = "1"
+
+// GetUnratedSubmissions returns the judged SUBMIT submissions the rating
+// job hasn't counted yet. Those being rejudged are left until they are
+// judged again.
+func GetUnratedSubmissions(ctx context.Context) ([]types.Submission, error) {
+	paginator := dynamodb.NewQueryPaginator(client, &dynamodb.QueryInput{
+		TableName:              aws.String(os.Getenv("SUBMISSIONS_TABLE")),
+		IndexName:              aws.String(UnratedIndex),
+		KeyConditionExpression: aws.String("unrated = :unrated"),
+		FilterExpression:       aws.String("NOT (#s IN (:pending, :running, :system_error))"),
+		ExpressionAttributeNames: map[string]string{
+			"#s": "status",
+		},
+		ExpressionAttributeValues: map[string]dbtypes.AttributeValue{
+			":unrated": &dbtypes.AttributeValueMemberS{Value: unrated},
+			":pending": &dbtypes.AttributeValueMemberS{Value: string(types.StatusPending)},
+			":running": &dbtypes.AttributeValueMemberS{Value: string(types.StatusRunning)},
+			// Never judged, so there is no result to rate
+			":system_error": &dbtypes.AttributeValueMemberS{Value: string(types.StatusSystemError)},
+		},
+	})
+
+	var submissions []types.Submission
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query unrated submissions: %v", err)
+		}
+		var items []types.Submission
+		if err := attributevalue.UnmarshalListOfMaps(page.Items, &items); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal submissions: %v", err)
+		}
+		submissions = append(submissions, items...)
+	}
+	return submissions, nil
+}
+
+// BackfillUnrated adds the judged SUBMIT submissions that were never rated
+// to UnratedIndex, for submissions judged before the index existed. It
+// returns how many it added.
+func BackfillUnrated(ctx context.Context) (int, error) {
+	paginator := dynamodb.NewScanPaginator(client, &dynamodb.ScanInput{
+		TableName:            aws.String(os.Getenv("SUBMISSIONS_TABLE")),
+		FilterExpression:     aws.String("#t = :submit AND attribute_not_exists(rated_at) AND attribute_not_exists(unrated) AND NOT (#s IN (:pending, :running, :system_error))"),
+		ProjectionExpression: aws.String("problem_id, submission_id"),
+		ExpressionAttributeNames: map[string]string{
+			"#t": "type",
+			"#s": "status",
+		},
+		ExpressionAttributeValues: map[string]dbtypes.AttributeValue{
+			":submit":  &dbtypes.AttributeValueMemberS{Value: "SUBMIT"},
+			":pending": &dbtypes.AttributeValueMemberS{Value: string(types.StatusPending)},
+			":running": &dbtypes.AttributeValueMemberS{Value: string(types.StatusRunning)},
+			// Never judged, so there is no result to rate
+			":system_error": &dbtypes.AttributeValueMemberS{Value: string(types.StatusSystemError)},
+		},
+	})
+
+	added := 0
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return added, fmt.Errorf("failed to scan submissions: %v", err)
+		}
+		for _, key := range page.Items {
+			_, err := client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+				TableName:           aws.String(os.Getenv("SUBMISSIONS_TABLE")),
+				Key:                 key,
+				UpdateExpression:    aws.String("SET unrated = :unrated"),
+				ConditionExpression: aws.String("attribute_not_exists(rated_at)"),
+				ExpressionAttributeValues: map[string]dbtypes.AttributeValue{
+					":unrated": &dbtypes.AttributeValueMemberS{Value: unrated},
+				},
+			})
+			var conditionErr *dbtypes.ConditionalCheckFailedException
+			if errors.As(err, &conditionErr) {
+				continue // Rated meanwhile
+			}
+			if err != nil {
+				return added, fmt.Errorf("failed to mark submission unrated: %v", err)
+			}
+			added++
+		}
+	}
+	return added, nil
+}
+
+// MarkSubmissionRated records that the rating job has counted a submission
+// and takes it out of UnratedIndex.
+func MarkSubmissionRated(ctx context.Context, submission *types.Submission, ratedAt int64) error {
+	_, err := client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(os.Getenv("SUBMISSIONS_TABLE")),
+		Key: map[string]dbtypes.AttributeValue{
+			"problem_id":    &dbtypes.AttributeValueMemberS{Value: submission.ProblemID},
+			"submission_id": &dbtypes.AttributeValueMemberS{Value: submission.SubmissionID},
+		},
+		UpdateExpression: aws.String("SET rated_at = :rated_at REMOVE unrated"),
+		ExpressionAttributeValues: map[string]dbtypes.AttributeValue{
+			":rated_at": &dbtypes.AttributeValueMemberN{Value: strconv.FormatInt(ratedAt, 10)},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to mark submission rated: %v", err)
+	}
+	return nil
+}
+
+// SetProblemRating stores a problem's difficulty rating without touching
+// the rest of the problem. Ratings set by an admin are left alone.
+func SetProblemRating(ctx context.Context, problemID string, rating int) error {
+	_, err := client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(os.Getenv("PROBLEMS_TABLE")),
+		Key: map[string]dbtypes.AttributeValue{
+			"id": &dbtypes.AttributeValueMemberS{Value: problemID},
+		},
+		UpdateExpression:    aws.String("SET rating = :rating"),
+		ConditionExpression: aws.String("attribute_exists(id) AND attribute_not_exists(rating_locked)"),
+		ExpressionAttributeValues: map[string]dbtypes.AttributeValue{
+			":rating": &dbtypes.AttributeValueMemberN{Value: strconv.Itoa(rating)},
+		},
+	})
+	var conditionErr *dbtypes.ConditionalCheckFailedException
+	if errors.As(err, &conditionErr) {
+		return nil // Deleted, or an admin set the rating meanwhile
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set problem rating: %v", err)
+	}
+	return nil
+}
+
+// SetUserRating stores a user's skill rating and adds newly solved problems
+// to the ones already rated.
+func SetUserRating(ctx context.Context, userID string, rating int, solved []string) error {
+	updateExpression := "SET rating = :rating"
+	values := map[string]dbtypes.AttributeValue{
+		":rating": &dbtypes.AttributeValueMemberN{Value: strconv.Itoa(rating)},
+	}
+	if len(solved) > 0 {
+		updateExpression += " ADD rated_solves :solved"
+		values[":solved"] = &dbtypes.AttributeValueMemberSS{Value: solved}
+	}
+
+	_, err := client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(os.Getenv("USERS_TABLE")),
+		Key: map[string]dbtypes.AttributeValue{
+			"id": &dbtypes.AttributeValueMemberS{Value: userID},
+		},
+		UpdateExpression:          aws.String(updateExpression),
+		ConditionExpression:       aws.String("attribute_exists(id)"),
+		ExpressionAttributeValues: values,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set user rating: %v", err)
+	}
+	return nil
+}
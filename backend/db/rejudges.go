@@ -0,0 +1,287 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"learncode/backend/types"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	dbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+var ErrRejudgeNotFound = errors.New("rejudge not found")
+
+func SaveRejudge(ctx context.Context, rejudge *types.Rejudge) error {
+	item, err := attributevalue.MarshalMap(rejudge)
+	if err != nil {
+		return fmt.Errorf("failed to marshal rejudge: %v", err)
+	}
+
+	_, err = client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(os.Getenv("REJUDGES_TABLE")),
+		Item:      item,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save rejudge: %v", err)
+	}
+	return nil
+}
+
+func GetRejudge(ctx context.Context, rejudgeID string) (*types.Rejudge, error) {
+	result, err := client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(os.Getenv("REJUDGES_TABLE")),
+		Key: map[string]dbtypes.AttributeValue{
+			"id": &dbtypes.AttributeValueMemberS{Value: rejudgeID},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rejudge: %v", err)
+	}
+	if result.Item == nil {
+		return nil, fmt.Errorf("%w: %s", ErrRejudgeNotFound, rejudgeID)
+	}
+
+	var rejudge types.Rejudge
+	if err := attributevalue.UnmarshalMap(result.Item, &rejudge); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal rejudge: %v", err)
+	}
+	return &rejudge, nil
+}
+
+// GetUnpublishedRejudges returns the rejudges that still have queued
+// submissions.
+func GetUnpublishedRejudges(ctx context.Context) ([]types.Rejudge, error) {
+	paginator := dynamodb.NewScanPaginator(client, &dynamodb.ScanInput{
+		TableName:        aws.String(os.Getenv("REJUDGES_TABLE")),
+		FilterExpression: aws.String("attribute_not_exists(published_at)"),
+	})
+
+	var rejudges []types.Rejudge
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan rejudges: %v", err)
+		}
+		var items []types.Rejudge
+		if err := attributevalue.UnmarshalListOfMaps(page.Items, &items); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal rejudges: %v", err)
+		}
+		rejudges = append(rejudges, items...)
+	}
+	return rejudges, nil
+}
+
+// GetProblemSubmissions returns the problem's submissions that match the
+// filter. The filter's ProblemID is ignored.
+func GetProblemSubmissions(ctx context.Context, problemID string, filter SubmissionFilter) ([]types.Submission, error) {
+	paginator := dynamodb.NewQueryPaginator(client, &dynamodb.QueryInput{
+		TableName:              aws.String(os.Getenv("SUBMISSIONS_TABLE")),
+		KeyConditionExpression: aws.String("problem_id = :problem_id"),
+		ExpressionAttributeValues: map[string]dbtypes.AttributeValue{
+			":problem_id": &dbtypes.AttributeValueMemberS{Value: problemID},
+		},
+	})
+
+	var submissions []types.Submission
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query submissions: %v", err)
+		}
+		var items []types.Submission
+		if err := attributevalue.UnmarshalListOfMaps(page.Items, &items); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal submissions: %v", err)
+		}
+		for _, submission := range items {
+			if filter.matches(&submission) {
+				submissions = append(submissions, submission)
+			}
+		}
+	}
+	return submissions, nil
+}
+
+// GetRejudgeSubmissions returns up to limit of the submissions queued or
+// rejudged by a rejudge, or all of them if limit is zero. With queuedOnly
+// set it returns only those not republished yet.
+func GetRejudgeSubmissions(ctx context.Context, rejudge *types.Rejudge, queuedOnly bool, limit int) ([]types.Submission, error) {
+	filter := "rejudge_id = :rejudge_id"
+	values := map[string]dbtypes.AttributeValue{
+		":problem_id": &dbtypes.AttributeValueMemberS{Value: rejudge.ProblemID},
+		":rejudge_id": &dbtypes.AttributeValueMemberS{Value: rejudge.ID},
+	}
+	if queuedOnly {
+		filter += " AND rejudge_queued = :queued"
+		values[":queued"] = &dbtypes.AttributeValueMemberBOOL{Value: true}
+	}
+
+	paginator := dynamodb.NewQueryPaginator(client, &dynamodb.QueryInput{
+		TableName:                 aws.String(os.Getenv("SUBMISSIONS_TABLE")),
+		KeyConditionExpression:    aws.String("problem_id = :problem_id"),
+		FilterExpression:          aws.String(filter),
+		ExpressionAttributeValues: values,
+	})
+
+	var submissions []types.Submission
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query submissions: %v", err)
+		}
+		var items []types.Submission
+		if err := attributevalue.UnmarshalListOfMaps(page.Items, &items); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal submissions: %v", err)
+		}
+		submissions = append(submissions, items...)
+		if limit > 0 && len(submissions) >= limit {
+			return submissions[:limit], nil
+		}
+	}
+	return submissions, nil
+}
+
+// QueueRejudge marks a judged submission to be rejudged, keeping its
+// current verdict as the previous one, and counts it in the rejudge's
+// total in the same transaction. It fails with ErrSubmissionChanged if the
+// submission's status changed since it was read or it is already queued.
+func QueueRejudge(ctx context.Context, submission *types.Submission, rejudge *types.Rejudge, previousVerdict string) error {
+	_, err := client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []dbtypes.TransactWriteItem{
+			{
+				Update: &dbtypes.Update{
+					TableName: aws.String(os.Getenv("SUBMISSIONS_TABLE")),
+					Key: map[string]dbtypes.AttributeValue{
+						"problem_id":    &dbtypes.AttributeValueMemberS{Value: submission.ProblemID},
+						"submission_id": &dbtypes.AttributeValueMemberS{Value: submission.SubmissionID},
+					},
+					UpdateExpression:    aws.String("SET rejudge_id = :rejudge_id, rejudge_queued = :queued, previous_verdict = :previous_verdict, previous_counted = :previous_counted"),
+					ConditionExpression: aws.String("#status = :status AND attribute_not_exists(rejudge_queued)"),
+					ExpressionAttributeNames: map[string]string{
+						"#status": "status",
+					},
+					ExpressionAttributeValues: map[string]dbtypes.AttributeValue{
+						":rejudge_id":       &dbtypes.AttributeValueMemberS{Value: rejudge.ID},
+						":queued":           &dbtypes.AttributeValueMemberBOOL{Value: true},
+						":previous_verdict": &dbtypes.AttributeValueMemberS{Value: previousVerdict},
+						":previous_counted": &dbtypes.AttributeValueMemberBOOL{Value: submission.VerdictCounted()},
+						":status":           &dbtypes.AttributeValueMemberS{Value: string(submission.Status)},
+					},
+				},
+			},
+			{
+				Update: &dbtypes.Update{
+					TableName: aws.String(os.Getenv("REJUDGES_TABLE")),
+					Key: map[string]dbtypes.AttributeValue{
+						"id": &dbtypes.AttributeValueMemberS{Value: rejudge.ID},
+					},
+					UpdateExpression: aws.String("ADD #total :one"),
+					ExpressionAttributeNames: map[string]string{
+						"#total": "total",
+					},
+					ExpressionAttributeValues: map[string]dbtypes.AttributeValue{
+						":one": &dbtypes.AttributeValueMemberN{Value: "1"},
+					},
+				},
+			},
+		},
+	})
+	var canceledErr *dbtypes.TransactionCanceledException
+	if errors.As(err, &canceledErr) && len(canceledErr.CancellationReasons) > 0 &&
+		aws.ToString(canceledErr.CancellationReasons[0].Code) == "ConditionalCheckFailed" {
+		return fmt.Errorf("%w: %s", ErrSubmissionChanged, submission.SubmissionID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to queue rejudge: %v", err)
+	}
+	submission.RejudgeID = rejudge.ID
+	submission.RejudgeQueued = true
+	submission.PreviousVerdict = previousVerdict
+	submission.PreviousCounted = submission.VerdictCounted()
+	rejudge.Total++
+	return nil
+}
+
+// MarkRejudgeQueued records that every submission matching a rejudge has
+// been queued.
+func MarkRejudgeQueued(ctx context.Context, rejudge *types.Rejudge, queuedAt int64) error {
+	_, err := client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(os.Getenv("REJUDGES_TABLE")),
+		Key: map[string]dbtypes.AttributeValue{
+			"id": &dbtypes.AttributeValueMemberS{Value: rejudge.ID},
+		},
+		UpdateExpression: aws.String("SET queued_at = :queued_at"),
+		ExpressionAttributeValues: map[string]dbtypes.AttributeValue{
+			":queued_at": &dbtypes.AttributeValueMemberN{Value: strconv.FormatInt(queuedAt, 10)},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to mark rejudge queued: %v", err)
+	}
+	rejudge.QueuedAt = queuedAt
+	return nil
+}
+
+// StartRejudge takes a queued submission off the rejudge queue and resets
+// it to pending, dropping its old verdict, test results and retries. It
+// fails with ErrSubmissionChanged if the submission is no longer queued.
+func StartRejudge(ctx context.Context, submission *types.Submission, now int64) error {
+	err := transitionSubmission(ctx, submission, types.StatusPending, now, "REMOVE rejudge_queued, verdict, tests, retries, error_reason", "rejudge_queued = :queued", map[string]dbtypes.AttributeValue{
+		":queued": &dbtypes.AttributeValueMemberBOOL{Value: true},
+	})
+	if err != nil {
+		return err
+	}
+	submission.RejudgeQueued = false
+	submission.Verdict = ""
+	submission.Tests = nil
+	submission.Retries = 0
+	submission.ErrorReason = ""
+	return nil
+}
+
+// RequeueRejudge puts a submission StartRejudge took off the queue back
+// on it, for when republishing it failed.
+func RequeueRejudge(ctx context.Context, submission *types.Submission) error {
+	_, err := client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(os.Getenv("SUBMISSIONS_TABLE")),
+		Key: map[string]dbtypes.AttributeValue{
+			"problem_id":    &dbtypes.AttributeValueMemberS{Value: submission.ProblemID},
+			"submission_id": &dbtypes.AttributeValueMemberS{Value: submission.SubmissionID},
+		},
+		UpdateExpression: aws.String("SET rejudge_queued = :queued"),
+		ExpressionAttributeValues: map[string]dbtypes.AttributeValue{
+			":queued": &dbtypes.AttributeValueMemberBOOL{Value: true},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to requeue rejudge: %v", err)
+	}
+	submission.RejudgeQueued = true
+	return nil
+}
+
+// MarkRejudgePublished records that all of a rejudge's submissions have
+// been republished.
+func MarkRejudgePublished(ctx context.Context, rejudge *types.Rejudge, publishedAt int64) error {
+	_, err := client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(os.Getenv("REJUDGES_TABLE")),
+		Key: map[string]dbtypes.AttributeValue{
+			"id": &dbtypes.AttributeValueMemberS{Value: rejudge.ID},
+		},
+		UpdateExpression: aws.String("SET published_at = :published_at"),
+		ExpressionAttributeValues: map[string]dbtypes.AttributeValue{
+			":published_at": &dbtypes.AttributeValueMemberN{Value: strconv.FormatInt(publishedAt, 10)},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to mark rejudge published: %v", err)
+	}
+	rejudge.PublishedAt = publishedAt
+	return nil
+}
@@ -0,0 +1,87 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"learncode/backend/types"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	dbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// maxSlugAttempts bounds how many numbered variants of a slug are tried
+// before giving up.
+const maxSlugAttempts = 50
+
+var ErrSlugNotFound = errors.New("slug not found")
+
+// Slug maps a slug to the problem it was given to. Slugs are never
+// released, so links to a renamed problem's old slug keep working.
+type Slug struct {
+	Slug      string `dynamodbav:"slug"`
+	ProblemID string `dynamodbav:"problem_id"`
+	CreatedAt int64  `dynamodbav:"created_at"`
+}
+
+// slugPut is the write claiming slug for the problem unless another
+// problem has it.
+func slugPut(slug string, problemID string) (*dbtypes.Put, error) {
+	item, err := attributevalue.MarshalMap(Slug{Slug: slug, ProblemID: problemID, CreatedAt: time.Now().Unix()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal slug: %v", err)
+	}
+	return &dbtypes.Put{
+		TableName:           aws.String(os.Getenv("SLUGS_TABLE")),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(slug) OR problem_id = :problem_id"),
+		ExpressionAttributeValues: map[string]dbtypes.AttributeValue{
+			":problem_id": &dbtypes.AttributeValueMemberS{Value: problemID},
+		},
+	}, nil
+}
+
+// GetProblemByIDOrSlug returns the problem with the given ID, or else the
+// one the slug was given to, current or old. Callers that need to know
+// which matched compare the argument with the problem's ID and Slug.
+func GetProblemByIDOrSlug(ctx context.Context, idOrSlug string) (*types.Problem, error) {
+	problem, err := GetProblem(ctx, idOrSlug)
+	if !errors.Is(err, ErrProblemNotFound) {
+		return problem, err
+	}
+	id, err := GetProblemIDBySlug(ctx, idOrSlug)
+	if errors.Is(err, ErrSlugNotFound) {
+		return nil, fmt.Errorf("%w: %s", ErrProblemNotFound, idOrSlug)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return GetProblem(ctx, id)
+}
+
+// GetProblemIDBySlug returns the ID of the problem a slug was given to.
+func GetProblemIDBySlug(ctx context.Context, slug string) (string, error) {
+	result, err := client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(os.Getenv("SLUGS_TABLE")),
+		Key: map[string]dbtypes.AttributeValue{
+			"slug": &dbtypes.AttributeValueMemberS{Value: slug},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get slug: %v", err)
+	}
+	if result.Item == nil {
+		return "", fmt.Errorf("%w: %s", ErrSlugNotFound, slug)
+	}
+
+	var s Slug
+	if err := attributevalue.UnmarshalMap(result.Item, &s); err != nil {
+		return "", fmt.Errorf("failed to unmarshal slug: %v", err)
+	}
+	return s.ProblemID, nil
+}
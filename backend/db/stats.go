@@ -0,0 +1,263 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"learncode/backend/types"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	dbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Verdict counters are top-level attributes named verdict#<language>#<verdict>
+// so that ADD can create them without the parent map having to exist.
+const verdictAttributePrefix = "verdict#"
+
+// RecordVerdict stores the judge's verdict and per-test results on a
+// submission and adds the verdict to its problem's statistics. Only SUBMIT
+// submissions count towards the statistics, and a rejudged one moves its
+// count from the previous verdict to the new one if the previous verdict
+// was counted (see statsUpdate). The statistics are
+// updated in the same transaction as the verdict, and only if the verdict
+// wasn't recorded yet, so recording a verdict again changes nothing.
+func RecordVerdict(ctx context.Context, submission *types.Submission, verdict string, tests []types.TestResult) error {
+	updateExpression := "SET verdict = :verdict"
+	values := map[string]dbtypes.AttributeValue{
+		":verdict": &dbtypes.AttributeValueMemberS{Value: verdict},
+	}
+	if len(tests) > 0 {
+		av, err := attributevalue.Marshal(tests)
+		if err != nil {
+			return fmt.Errorf("failed to marshal test results: %v", err)
+		}
+		updateExpression += ", tests = :tests"
+		values[":tests"] = av
+	}
+	key := map[string]dbtypes.AttributeValue{
+		"problem_id":    &dbtypes.AttributeValueMemberS{Value: submission.ProblemID},
+		"submission_id": &dbtypes.AttributeValueMemberS{Value: submission.SubmissionID},
+	}
+
+	if submission.Type != types.SubmissionSubmit {
+		_, err := client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+			TableName:                 aws.String(os.Getenv("SUBMISSIONS_TABLE")),
+			Key:                       key,
+			UpdateExpression:          aws.String(updateExpression),
+			ExpressionAttributeValues: values,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to store verdict: %v", err)
+		}
+		submission.Verdict = verdict
+		submission.Tests = tests
+		return nil
+	}
+
+	if submission.RejudgeID == "" {
+		// Rejudged submissions were counted by the rating job already
+		updateExpression += ", unrated = :unrated"
+		values[":unrated"] = &dbtypes.AttributeValueMemberS{Value: unrated}
+	}
+	items := []dbtypes.TransactWriteItem{{
+		Update: &dbtypes.Update{
+			TableName:                 aws.String(os.Getenv("SUBMISSIONS_TABLE")),
+			Key:                       key,
+			UpdateExpression:          aws.String(updateExpression),
+			ConditionExpression:       aws.String("attribute_not_exists(verdict)"),
+			ExpressionAttributeValues: values,
+		},
+	}}
+	if stats := statsUpdate(submission, verdict); stats != nil {
+		items = append(items, dbtypes.TransactWriteItem{Update: stats})
+	}
+
+	_, err := client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: items,
+	})
+	var canceledErr *dbtypes.TransactionCanceledException
+	recorded := errors.As(err, &canceledErr) && len(canceledErr.CancellationReasons) > 0 &&
+		aws.ToString(canceledErr.CancellationReasons[0].Code) == "ConditionalCheckFailed"
+	if err != nil && !recorded {
+		return fmt.Errorf("failed to store verdict: %v", err)
+	}
+	submission.Verdict = verdict
+	submission.Tests = tests
+
+	// Marking the problem solved is idempotent, so it is retried even if an
+	// earlier attempt already recorded the verdict. A rejudge that turns an
+	// acceptance into a rejection leaves the problem solved.
+	if verdict != types.VerdictAccepted {
+		return nil
+	}
+	firstSolve, err := markSolved(ctx, submission.UserID, submission.ProblemID)
+	if err != nil || !firstSolve {
+		return err
+	}
+
+	_, err = client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(os.Getenv("STATS_TABLE")),
+		Key: map[string]dbtypes.AttributeValue{
+			"problem_id": &dbtypes.AttributeValueMemberS{Value: submission.ProblemID},
+		},
+		UpdateExpression: aws.String("ADD solvers :one"),
+		ExpressionAttributeValues: map[string]dbtypes.AttributeValue{
+			":one": &dbtypes.AttributeValueMemberN{Value: "1"},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update problem stats: %v", err)
+	}
+	return nil
+}
+
+// statsUpdate returns the update counting a SUBMIT submission's verdict in
+// its problem's statistics, or nil if a rejudge left the verdict unchanged.
+// A rejudged submission whose previous verdict was counted moves that count
+// to the new verdict. One judged before the statistics existed, or that
+// ended in a system error without a verdict, is counted as a new attempt.
+func statsUpdate(submission *types.Submission, verdict string) *dbtypes.Update {
+	accepted := 0
+	if verdict == types.VerdictAccepted {
+		accepted = 1
+	}
+	names := map[string]string{
+		"#verdict": verdictAttributePrefix + submission.Language + "#" + verdict,
+	}
+	values := map[string]dbtypes.AttributeValue{
+		":one": &dbtypes.AttributeValueMemberN{Value: "1"},
+	}
+	update := "ADD attempts :one, accepted :accepted, #verdict :one"
+
+	previous := submission.PreviousVerdict
+	if submission.RejudgeID != "" && submission.PreviousCounted && previous != "" {
+		if previous == verdict {
+			return nil
+		}
+		if previous == types.VerdictAccepted {
+			accepted--
+		}
+		names["#previous"] = verdictAttributePrefix + submission.Language + "#" + previous
+		values[":minus_one"] = &dbtypes.AttributeValueMemberN{Value: "-1"}
+		update = "ADD accepted :accepted, #verdict :one, #previous :minus_one"
+	}
+	values[":accepted"] = &dbtypes.AttributeValueMemberN{Value: strconv.Itoa(accepted)}
+
+	return &dbtypes.Update{
+		TableName: aws.String(os.Getenv("STATS_TABLE")),
+		Key: map[string]dbtypes.AttributeValue{
+			"problem_id": &dbtypes.AttributeValueMemberS{Value: submission.ProblemID},
+		},
+		UpdateExpression:          aws.String(update),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+	}
+}
+
+// markSolved adds the problem to the user's solved problems and reports
+// whether it wasn't there yet.
+func markSolved(ctx context.Context, userID string, problemID string) (bool, error) {
+	_, err := client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(os.Getenv("USERS_TABLE")),
+		Key: map[string]dbtypes.AttributeValue{
+			"id": &dbtypes.AttributeValueMemberS{Value: userID},
+		},
+		UpdateExpression:    aws.String("ADD solved_problems :problems"),
+		ConditionExpression: aws.String("attribute_exists(id) AND NOT contains(solved_problems, :problem)"),
+		ExpressionAttributeValues: map[string]dbtypes.AttributeValue{
+			":problems": &dbtypes.AttributeValueMemberSS{Value: []string{problemID}},
+			":problem":  &dbtypes.AttributeValueMemberS{Value: problemID},
+		},
+	})
+	var conditionErr *dbtypes.ConditionalCheckFailedException
+	if errors.As(err, &conditionErr) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to mark problem solved: %v", err)
+	}
+	return true, nil
+}
+
+// GetProblemStats returns a problem's statistics. Problems nobody has
+// submitted to yet get zeroed statistics.
+func GetProblemStats(ctx context.Context, problemID string) (*types.ProblemStats, error) {
+	result, err := client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(os.Getenv("STATS_TABLE")),
+		Key: map[string]dbtypes.AttributeValue{
+			"problem_id": &dbtypes.AttributeValueMemberS{Value: problemID},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get problem stats: %v", err)
+	}
+	if result.Item == nil {
+		return emptyStats(problemID), nil
+	}
+
+	return unmarshalStats(result.Item)
+}
+
+// GetAllProblemStats returns the statistics of every problem that has any,
+// keyed by problem ID.
+func GetAllProblemStats(ctx context.Context) (map[string]*types.ProblemStats, error) {
+	stats := map[string]*types.ProblemStats{}
+	paginator := dynamodb.NewScanPaginator(client, &dynamodb.ScanInput{
+		TableName: aws.String(os.Getenv("STATS_TABLE")),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan problem stats: %v", err)
+		}
+		for _, item := range page.Items {
+			s, err := unmarshalStats(item)
+			if err != nil {
+				return nil, err
+			}
+			stats[s.ProblemID] = s
+		}
+	}
+	return stats, nil
+}
+
+func emptyStats(problemID string) *types.ProblemStats {
+	return &types.ProblemStats{ProblemID: problemID, Verdicts: map[string]map[string]int{}}
+}
+
+func unmarshalStats(item map[string]dbtypes.AttributeValue) (*types.ProblemStats, error) {
+	var stats types.ProblemStats
+	if err := attributevalue.UnmarshalMap(item, &stats); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal problem stats: %v", err)
+	}
+	stats.Verdicts = map[string]map[string]int{}
+
+	for name, value := range item {
+		if !strings.HasPrefix(name, verdictAttributePrefix) {
+			continue
+		}
+		language, verdict, ok := strings.Cut(strings.TrimPrefix(name, verdictAttributePrefix), "#")
+		if !ok {
+			continue
+		}
+		var count int
+		if err := attributevalue.Unmarshal(value, &count); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal problem stats: %v", err)
+		}
+		if stats.Verdicts[language] == nil {
+			stats.Verdicts[language] = map[string]int{}
+		}
+		stats.Verdicts[language][verdict] = count
+	}
+
+	if stats.Attempts > 0 {
+		stats.AcceptanceRate = float64(stats.Accepted) / float64(stats.Attempts)
+	}
+	return &stats, nil
+}
@@ -0,0 +1,71 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"learncode/backend/types"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	dbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// ErrInvalidTransition is returned when a submission's stored status can't
+// move to the one being set, e.g. a judged submission being set running by
+// a redelivered job.
+var ErrInvalidTransition = errors.New("invalid status transition")
+
+// JudgingIndex is the sparse submissions table GSI of the pending and
+// running submissions, which are the only ones with the judging attribute.
+// It is sorted by updated_at.
+const JudgingIndex = "judging-updated_at-index"
+
+// judging is the value of the judging attribute, and so the index's only
+// partition.
+const judging = "1"
+
+// statusTransition returns the SET actions moving a submission to status
+// and appending it to the status history, and the REMOVE actions taking a
+// finished submission out of JudgingIndex, adding the values they use.
+// Callers must map #status to "status".
+func statusTransition(status types.SubmissionStatus, now int64, values map[string]dbtypes.AttributeValue) (set string, remove []string, err error) {
+	history, err := attributevalue.Marshal([]types.StatusChange{{Status: status, At: now}})
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to marshal status history: %v", err)
+	}
+	values[":to_status"] = &dbtypes.AttributeValueMemberS{Value: string(status)}
+	values[":history"] = history
+	values[":no_history"] = &dbtypes.AttributeValueMemberL{Value: []dbtypes.AttributeValue{}}
+	set = "#status = :to_status, status_history = list_append(if_not_exists(status_history, :no_history), :history)"
+	if !status.Judging() {
+		return set, []string{"judging"}, nil
+	}
+	values[":judging"] = &dbtypes.AttributeValueMemberS{Value: judging}
+	return set + ", judging = :judging", nil, nil
+}
+
+// buildUpdate joins SET and REMOVE actions into an update expression.
+func buildUpdate(set []string, remove []string) string {
+	expression := "SET " + strings.Join(set, ", ")
+	if len(remove) > 0 {
+		expression += " REMOVE " + strings.Join(remove, ", ")
+	}
+	return expression
+}
+
+// allowedStatusCondition returns the condition that a submission's stored
+// status can move to status, adding the values it uses.
+func allowedStatusCondition(status types.SubmissionStatus, values map[string]dbtypes.AttributeValue) (string, error) {
+	from := types.StatusesBefore(status)
+	if len(from) == 0 {
+		return "", fmt.Errorf("%w: unknown status %q", ErrInvalidTransition, status)
+	}
+	placeholders := make([]string, len(from))
+	for i, s := range from {
+		placeholders[i] = ":from_" + strconv.Itoa(i)
+		values[placeholders[i]] = &dbtypes.AttributeValueMemberS{Value: string(s)}
+	}
+	return "#status IN (" + strings.Join(placeholders, ", ") + ")", nil
+}
@@ -0,0 +1,478 @@
+package db
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"learncode/backend/types"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	dbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// SubmissionsByUserIndex is the submissions table GSI keyed by user_id and
+// created_at.
+const SubmissionsByUserIndex = "user_id-created_at-index"
+
+// SubmissionsByIDIndex is the keys-only submissions table GSI keyed by
+// submission_id.
+const SubmissionsByIDIndex = "submission_id-index"
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+var ErrSubmissionNotFound = errors.New("submission not found")
+
+// ErrSubmissionChanged is returned when a submission changed since it was
+// read, so that an update meant for its old state is not applied.
+var ErrSubmissionChanged = errors.New("submission changed")
+
+// GetSubmission looks a submission up by its ID alone.
+func GetSubmission(ctx context.Context, submissionID string) (*types.Submission, error) {
+	result, err := client.Query(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(os.Getenv("SUBMISSIONS_TABLE")),
+		IndexName:              aws.String(SubmissionsByIDIndex),
+		KeyConditionExpression: aws.String("submission_id = :submission_id"),
+		ExpressionAttributeValues: map[string]dbtypes.AttributeValue{
+			":submission_id": &dbtypes.AttributeValueMemberS{Value: submissionID},
+		},
+		Limit: aws.Int32(1),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query submission: %v", err)
+	}
+	if len(result.Items) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrSubmissionNotFound, submissionID)
+	}
+
+	// The index only holds keys; read the item itself for the latest status
+	var key struct {
+		ProblemID string `dynamodbav:"problem_id"`
+	}
+	if err := attributevalue.UnmarshalMap(result.Items[0], &key); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal submission key: %v", err)
+	}
+	return GetSubmissionByKey(ctx, key.ProblemID, submissionID)
+}
+
+// GetSubmissionByKey reads a submission with a strongly consistent read.
+func GetSubmissionByKey(ctx context.Context, problemID string, submissionID string) (*types.Submission, error) {
+	item, err := client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(os.Getenv("SUBMISSIONS_TABLE")),
+		Key: map[string]dbtypes.AttributeValue{
+			"problem_id":    &dbtypes.AttributeValueMemberS{Value: problemID},
+			"submission_id": &dbtypes.AttributeValueMemberS{Value: submissionID},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get submission: %v", err)
+	}
+	if item.Item == nil {
+		return nil, fmt.Errorf("%w: %s", ErrSubmissionNotFound, submissionID)
+	}
+
+	var submission types.Submission
+	if err := attributevalue.UnmarshalMap(item.Item, &submission); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal submission: %v", err)
+	}
+	return &submission, nil
+}
+
+// SetSubmissionPublic sets whether other users may see a submission.
+func SetSubmissionPublic(ctx context.Context, submission *types.Submission, public bool) error {
+	_, err := client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(os.Getenv("SUBMISSIONS_TABLE")),
+		Key: map[string]dbtypes.AttributeValue{
+			"problem_id":    &dbtypes.AttributeValueMemberS{Value: submission.ProblemID},
+			"submission_id": &dbtypes.AttributeValueMemberS{Value: submission.SubmissionID},
+		},
+		UpdateExpression: aws.String("SET #public = :public"),
+		ExpressionAttributeNames: map[string]string{
+			"#public": "public",
+		},
+		ExpressionAttributeValues: map[string]dbtypes.AttributeValue{
+			":public": &dbtypes.AttributeValueMemberBOOL{Value: public},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update submission: %v", err)
+	}
+	submission.Public = public
+	return nil
+}
+
+// SubmissionFilter narrows down a user's submission history. Zero fields
+// don't filter.
+type SubmissionFilter struct {
+	ProblemID string
+	Language  string
+	Verdict   string // Submissions without a stored verdict match by status (see types.Submission.JudgedVerdict)
+	Type      string
+	From      int64 // Unix timestamp, inclusive
+	To        int64 // Unix timestamp, inclusive
+}
+
+// matches applies the filter to a submission that has already been read,
+// except for ProblemID.
+func (f SubmissionFilter) matches(submission *types.Submission) bool {
+	return (f.Language == "" || submission.Language == f.Language) &&
+		(f.Verdict == "" || (submission.Finished() && submission.JudgedVerdict() == f.Verdict)) &&
+		(f.Type == "" || submission.Type == f.Type) &&
+		(f.From == 0 || submission.CreatedAt >= f.From) &&
+		(f.To == 0 || submission.CreatedAt <= f.To)
+}
+
+// submissionCursor is the last evaluated key of a page of the user index.
+type submissionCursor struct {
+	UserID       string `json:"-" dynamodbav:"user_id"`
+	CreatedAt    int64  `json:"created_at" dynamodbav:"created_at"`
+	ProblemID    string `json:"problem_id" dynamodbav:"problem_id"`
+	SubmissionID string `json:"submission_id" dynamodbav:"submission_id"`
+}
+
+// GetUserSubmissions returns a page of up to limit of the user's
+// submissions, newest first, and the cursor of the next page. The cursor is
+// empty on the last page.
+func GetUserSubmissions(ctx context.Context, userID string, filter SubmissionFilter, limit int, cursor string) ([]types.Submission, string, error) {
+	keyCondition := "user_id = :user_id"
+	values := map[string]dbtypes.AttributeValue{
+		":user_id": &dbtypes.AttributeValueMemberS{Value: userID},
+	}
+	names := map[string]string{}
+
+	switch {
+	case filter.From > 0 && filter.To > 0:
+		keyCondition += " AND created_at BETWEEN :from AND :to"
+	case filter.From > 0:
+		keyCondition += " AND created_at >= :from"
+	case filter.To > 0:
+		keyCondition += " AND created_at <= :to"
+	}
+	if filter.From > 0 {
+		values[":from"] = &dbtypes.AttributeValueMemberN{Value: strconv.FormatInt(filter.From, 10)}
+	}
+	if filter.To > 0 {
+		values[":to"] = &dbtypes.AttributeValueMemberN{Value: strconv.FormatInt(filter.To, 10)}
+	}
+
+	var conditions []string
+	for attribute, value := range map[string]string{
+		"problem_id": filter.ProblemID,
+		"language":   filter.Language,
+		"type":       filter.Type,
+	} {
+		if value == "" {
+			continue
+		}
+		conditions = append(conditions, fmt.Sprintf("#%s = :%s", attribute, attribute))
+		names["#"+attribute] = attribute
+		values[":"+attribute] = &dbtypes.AttributeValueMemberS{Value: value}
+	}
+	if filter.Verdict != "" {
+		conditions = append(conditions, verdictCondition(filter.Verdict, names, values))
+	}
+
+	input := &dynamodb.QueryInput{
+		TableName:                 aws.String(os.Getenv("SUBMISSIONS_TABLE")),
+		IndexName:                 aws.String(SubmissionsByUserIndex),
+		KeyConditionExpression:    aws.String(keyCondition),
+		ExpressionAttributeValues: values,
+		ScanIndexForward:          aws.Bool(false),
+		Limit:                     aws.Int32(int32(limit)),
+	}
+	if len(conditions) > 0 {
+		input.FilterExpression = aws.String(strings.Join(conditions, " AND "))
+		input.ExpressionAttributeNames = names
+	}
+	if cursor != "" {
+		startKey, err := decodeSubmissionCursor(userID, cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		input.ExclusiveStartKey = startKey
+	}
+
+	// Filtered pages can come back short, so keep reading until the page is
+	// full or the index runs out
+	var submissions []types.Submission
+	for {
+		result, err := client.Query(ctx, input)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to query submissions: %v", err)
+		}
+
+		var items []types.Submission
+		if err := attributevalue.UnmarshalListOfMaps(result.Items, &items); err != nil {
+			return nil, "", fmt.Errorf("failed to unmarshal submissions: %v", err)
+		}
+		submissions = append(submissions, items...)
+
+		if result.LastEvaluatedKey == nil {
+			return submissions, "", nil
+		}
+		if len(submissions) >= limit {
+			next, err := encodeSubmissionCursor(result.LastEvaluatedKey)
+			return submissions, next, err
+		}
+		input.ExclusiveStartKey = result.LastEvaluatedKey
+		input.Limit = aws.Int32(int32(limit - len(submissions)))
+	}
+}
+
+// verdictCondition returns the condition that a submission was judged with
+// verdict. Submissions judged before verdicts were stored, or by a runner
+// that didn't store them, are matched by their status instead.
+func verdictCondition(verdict string, names map[string]string, values map[string]dbtypes.AttributeValue) string {
+	names["#verdict"] = "verdict"
+	values[":verdict"] = &dbtypes.AttributeValueMemberS{Value: verdict}
+	statuses := types.StatusesJudgedAs(verdict)
+	if len(statuses) == 0 {
+		return "#verdict = :verdict"
+	}
+
+	names["#status"] = "status"
+	placeholders := make([]string, len(statuses))
+	for i, status := range statuses {
+		placeholders[i] = ":verdict_status_" + strconv.Itoa(i)
+		values[placeholders[i]] = &dbtypes.AttributeValueMemberS{Value: string(status)}
+	}
+	return "(#verdict = :verdict OR (attribute_not_exists(#verdict) AND #status IN (" + strings.Join(placeholders, ", ") + ")))"
+}
+
+func encodeSubmissionCursor(key map[string]dbtypes.AttributeValue) (string, error) {
+	var cursor submissionCursor
+	if err := attributevalue.UnmarshalMap(key, &cursor); err != nil {
+		return "", fmt.Errorf("failed to unmarshal cursor: %v", err)
+	}
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal cursor: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeSubmissionCursor turns a cursor back into a start key. The user ID
+// isn't part of the cursor, so it can't page through someone else's index.
+func decodeSubmissionCursor(userID string, encoded string) (map[string]dbtypes.AttributeValue, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var cursor submissionCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ProblemID == "" || cursor.SubmissionID == "" {
+		return nil, ErrInvalidCursor
+	}
+	cursor.UserID = userID
+
+	key, err := attributevalue.MarshalMap(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal cursor: %v", err)
+	}
+	return key, nil
+}
+
+// GetStuckSubmissions returns the pending and running submissions that
+// haven't been updated since before, not counting those waiting in a
+// rejudge queue. They are found through JudgingIndex, which holds only the
+// attributes needed to retry or fail them, not their code; get the full
+// submission with GetSubmissionByKey.
+func GetStuckSubmissions(ctx context.Context, before int64) ([]types.Submission, error) {
+	paginator := dynamodb.NewQueryPaginator(client, &dynamodb.QueryInput{
+		TableName:              aws.String(os.Getenv("SUBMISSIONS_TABLE")),
+		IndexName:              aws.String(JudgingIndex),
+		KeyConditionExpression: aws.String("judging = :judging AND updated_at < :before"),
+		FilterExpression:       aws.String("attribute_not_exists(rejudge_queued)"),
+		ProjectionExpression:   aws.String("problem_id, submission_id, user_id, #language, #status, updated_at, retries"),
+		ExpressionAttributeNames: map[string]string{
+			"#status":   "status",
+			"#language": "language",
+		},
+		ExpressionAttributeValues: map[string]dbtypes.AttributeValue{
+			":judging": &dbtypes.AttributeValueMemberS{Value: judging},
+			":before":  &dbtypes.AttributeValueMemberN{Value: strconv.FormatInt(before, 10)},
+		},
+	})
+
+	var submissions []types.Submission
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query stuck submissions: %v", err)
+		}
+		var items []types.Submission
+		if err := attributevalue.UnmarshalListOfMaps(page.Items, &items); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal submissions: %v", err)
+		}
+		submissions = append(submissions, items...)
+	}
+	return submissions, nil
+}
+
+// BackfillJudging adds the pending and running submissions to
+// JudgingIndex, for submissions saved before the index existed. It returns
+// how many it added.
+func BackfillJudging(ctx context.Context) (int, error) {
+	paginator := dynamodb.NewScanPaginator(client, &dynamodb.ScanInput{
+		TableName:            aws.String(os.Getenv("SUBMISSIONS_TABLE")),
+		FilterExpression:     aws.String("#status IN (:pending, :running) AND attribute_not_exists(judging)"),
+		ProjectionExpression: aws.String("problem_id, submission_id"),
+		ExpressionAttributeNames: map[string]string{
+			"#status": "status",
+		},
+		ExpressionAttributeValues: map[string]dbtypes.AttributeValue{
+			":pending": &dbtypes.AttributeValueMemberS{Value: string(types.StatusPending)},
+			":running": &dbtypes.AttributeValueMemberS{Value: string(types.StatusRunning)},
+		},
+	})
+
+	added := 0
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return added, fmt.Errorf("failed to scan submissions: %v", err)
+		}
+		for _, key := range page.Items {
+			_, err := client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+				TableName:           aws.String(os.Getenv("SUBMISSIONS_TABLE")),
+				Key:                 key,
+				UpdateExpression:    aws.String("SET judging = :judging"),
+				ConditionExpression: aws.String("#status IN (:pending, :running)"),
+				ExpressionAttributeNames: map[string]string{
+					"#status": "status",
+				},
+				ExpressionAttributeValues: map[string]dbtypes.AttributeValue{
+					":judging": &dbtypes.AttributeValueMemberS{Value: judging},
+					":pending": &dbtypes.AttributeValueMemberS{Value: string(types.StatusPending)},
+					":running": &dbtypes.AttributeValueMemberS{Value: string(types.StatusRunning)},
+				},
+			})
+			var conditionErr *dbtypes.ConditionalCheckFailedException
+			if errors.As(err, &conditionErr) {
+				continue // Judged meanwhile
+			}
+			if err != nil {
+				return added, fmt.Errorf("failed to mark submission judging: %v", err)
+			}
+			added++
+		}
+	}
+	return added, nil
+}
+
+// RetrySubmission resets a stuck submission to pending and counts the
+// retry. It fails with ErrSubmissionChanged if a runner updated the
+// submission since it was read.
+func RetrySubmission(ctx context.Context, submission *types.Submission, now int64) error {
+	err := updateStuckSubmission(ctx, submission, types.StatusPending, now, "ADD retries :one", map[string]dbtypes.AttributeValue{
+		":one": &dbtypes.AttributeValueMemberN{Value: "1"},
+	})
+	if err != nil {
+		return err
+	}
+	submission.Retries++
+	return nil
+}
+
+// FailSubmission gives up on a stuck submission, marking it system_error
+// with the reason. It fails with ErrSubmissionChanged if a runner updated
+// the submission since it was read.
+func FailSubmission(ctx context.Context, submission *types.Submission, reason string, now int64) error {
+	err := updateStuckSubmission(ctx, submission, types.StatusSystemError, now, "SET error_reason = :reason", map[string]dbtypes.AttributeValue{
+		":reason": &dbtypes.AttributeValueMemberS{Value: reason},
+	})
+	if err != nil {
+		return err
+	}
+	submission.ErrorReason = reason
+	return nil
+}
+
+// updateStuckSubmission moves a submission to status and applies the rest
+// of the update only if the submission's status and updated_at are still
+// the ones that were read.
+func updateStuckSubmission(ctx context.Context, submission *types.Submission, status types.SubmissionStatus, now int64, update string, values map[string]dbtypes.AttributeValue) error {
+	if !types.CanTransition(submission.Status, status) {
+		return fmt.Errorf("%w: %s from %s to %s", ErrInvalidTransition, submission.SubmissionID, submission.Status, status)
+	}
+	values[":status"] = &dbtypes.AttributeValueMemberS{Value: string(submission.Status)}
+	values[":updated_at"] = &dbtypes.AttributeValueMemberN{Value: strconv.FormatInt(submission.UpdatedAt, 10)}
+	return transitionSubmission(ctx, submission, status, now, update, "#status = :status AND updated_at = :updated_at", values)
+}
+
+// SetSystemError marks a pending or running submission system_error with
+// the reason. It fails with ErrInvalidTransition if the submission has been
+// judged meanwhile.
+func SetSystemError(ctx context.Context, submission *types.Submission, reason string, now int64) error {
+	err := transitionSubmission(ctx, submission, types.StatusSystemError, now, "SET error_reason = :reason", "", map[string]dbtypes.AttributeValue{
+		":reason": &dbtypes.AttributeValueMemberS{Value: reason},
+	})
+	if err != nil {
+		return err
+	}
+	submission.ErrorReason = reason
+	return nil
+}
+
+// transitionSubmission moves a submission to status and applies the rest
+// of the update, a single SET, REMOVE or ADD clause. Without a condition of
+// its own it fails with ErrInvalidTransition if the stored status can't
+// move to status. With one, which must imply the move is allowed, a failed
+// check means the submission changed and it fails with ErrSubmissionChanged.
+func transitionSubmission(ctx context.Context, submission *types.Submission, status types.SubmissionStatus, now int64, update string, condition string, values map[string]dbtypes.AttributeValue) error {
+	set, remove, err := statusTransition(status, now, values)
+	if err != nil {
+		return err
+	}
+	changedErr := ErrSubmissionChanged
+	if condition == "" {
+		changedErr = ErrInvalidTransition
+		if condition, err = allowedStatusCondition(status, values); err != nil {
+			return err
+		}
+	}
+	values[":now"] = &dbtypes.AttributeValueMemberN{Value: strconv.FormatInt(now, 10)}
+
+	sets := []string{set, "updated_at = :now"}
+	var add string
+	if rest, ok := strings.CutPrefix(update, "SET "); ok {
+		sets = append(sets, rest)
+	} else if rest, ok := strings.CutPrefix(update, "REMOVE "); ok {
+		remove = append(remove, rest)
+	} else {
+		add = " " + update
+	}
+	updateExpression := buildUpdate(sets, remove) + add
+
+	_, err = client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(os.Getenv("SUBMISSIONS_TABLE")),
+		Key: map[string]dbtypes.AttributeValue{
+			"problem_id":    &dbtypes.AttributeValueMemberS{Value: submission.ProblemID},
+			"submission_id": &dbtypes.AttributeValueMemberS{Value: submission.SubmissionID},
+		},
+		UpdateExpression:    aws.String(updateExpression),
+		ConditionExpression: aws.String(condition),
+		ExpressionAttributeNames: map[string]string{
+			"#status": "status",
+		},
+		ExpressionAttributeValues: values,
+	})
+	var conditionErr *dbtypes.ConditionalCheckFailedException
+	if errors.As(err, &conditionErr) {
+		return fmt.Errorf("%w: %s to %s", changedErr, submission.SubmissionID, status)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update submission: %v", err)
+	}
+	submission.Status = status
+	submission.UpdatedAt = now
+	submission.StatusHistory = append(submission.StatusHistory, types.StatusChange{Status: status, At: now})
+	return nil
+}
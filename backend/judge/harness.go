@@ -0,0 +1,748 @@
+package judge
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	"learncode/backend/types"
+)
+
+// Harness wraps a learner's solution to a function-signature problem in a
+// program that reads the arguments from stdin, calls the function and
+// prints the JSON-encoded result on its last line of output. Anything the
+// solution prints itself ends up before that line.
+func Harness(language string, signature *types.Signature, code string) (string, error) {
+	switch language {
+	case "python":
+		return pythonHarness(signature, code), nil
+	case "nodejs":
+		return nodejsHarness(signature, code), nil
+	case "cpp":
+		return cppHarness(signature, code), nil
+	}
+	return "", fmt.Errorf("function-signature problems are not supported in %s", language)
+}
+
+// Stub returns the empty function learners start from.
+func Stub(language string, signature *types.Signature) (string, bool) {
+	switch language {
+	case "python":
+		return pythonStub(signature), true
+	case "nodejs":
+		return nodejsStub(signature), true
+	case "cpp":
+		return cppStub(signature), true
+	}
+	return "", false
+}
+
+// AddStubs fills in generated starter code for every allowed language the
+// setter didn't write starter code for.
+func AddStubs(problem *types.Problem) {
+	if problem.Signature == nil {
+		return
+	}
+	for _, language := range types.SignatureLanguages {
+		if _, ok := problem.StarterCode[language]; ok || !problem.AllowsLanguage(language) {
+			continue
+		}
+		stub, ok := Stub(language, problem.Signature)
+		if !ok {
+			continue
+		}
+		if problem.StarterCode == nil {
+			problem.StarterCode = map[string]string{}
+		}
+		problem.StarterCode[language] = stub
+	}
+}
+
+func paramTypes(signature *types.Signature) string {
+	names := make([]string, len(signature.Params))
+	for i, param := range signature.Params {
+		names[i] = param.Type
+	}
+	data, _ := json.Marshal(names)
+	return string(data)
+}
+
+// compareValues compares the last line of a harness's output with the
+// expected JSON value. Numbers are compared with a tolerance so that
+// floating point results don't need to match digit for digit.
+func compareValues(output string, expected string) (bool, string) {
+	actual := lastLine(output)
+	expected = strings.TrimSpace(expected)
+
+	var a, e interface{}
+	if err := unmarshalNumbers(actual, &a); err != nil {
+		return false, fmt.Sprintf("output mismatch\nExpected:\n%s\nGot:\n%s", expected, actual)
+	}
+	if err := unmarshalNumbers(expected, &e); err != nil {
+		// Not JSON, so the setter wrote the answer as plain text
+		if actual == expected {
+			return true, ""
+		}
+		return false, fmt.Sprintf("output mismatch\nExpected:\n%s\nGot:\n%s", expected, actual)
+	}
+
+	if !sameValue(a, e) {
+		return false, fmt.Sprintf("output mismatch\nExpected:\n%s\nGot:\n%s", expected, actual)
+	}
+	return true, ""
+}
+
+func lastLine(output string) string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
+
+func unmarshalNumbers(data string, v interface{}) error {
+	decoder := json.NewDecoder(strings.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+	if decoder.More() {
+		return fmt.Errorf("trailing data after JSON value")
+	}
+	return nil
+}
+
+const floatTolerance = 1e-6
+
+func sameValue(a interface{}, e interface{}) bool {
+	switch e := e.(type) {
+	case json.Number:
+		a, ok := a.(json.Number)
+		if !ok {
+			return false
+		}
+		if ai, err := a.Int64(); err == nil {
+			if ei, err := e.Int64(); err == nil {
+				return ai == ei
+			}
+		}
+		af, err1 := a.Float64()
+		ef, err2 := e.Float64()
+		if err1 != nil || err2 != nil {
+			return false
+		}
+		return math.Abs(af-ef) <= floatTolerance*math.Max(1, math.Abs(ef))
+	case []interface{}:
+		a, ok := a.([]interface{})
+		if !ok || len(a) != len(e) {
+			return false
+		}
+		for i := range e {
+			if !sameValue(a[i], e[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}:
+		a, ok := a.(map[string]interface{})
+		if !ok || len(a) != len(e) {
+			return false
+		}
+		for k := range e {
+			if !sameValue(a[k], e[k]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return a == e
+	}
+}
+
+const pythonPrologue = `import json
+import sys
+from typing import *
+
+
+class ListNode:
+    def __init__(self, val=0, next=None):
+        self.val = val
+        self.next = next
+
+
+class TreeNode:
+    def __init__(self, val=0, left=None, right=None):
+        self.val = val
+        self.left = left
+        self.right = right
+
+`
+
+const pythonEpilogue = `
+
+def _decode(t, v):
+    if t.endswith("[]"):
+        return [_decode(t[:-2], x) for x in v]
+    if t == "ListNode":
+        head = None
+        for x in reversed(v):
+            head = ListNode(x, head)
+        return head
+    if t == "TreeNode":
+        if not v or v[0] is None:
+            return None
+        root = TreeNode(v[0])
+        queue, i = [root], 1
+        for node in queue:
+            if i < len(v) and v[i] is not None:
+                node.left = TreeNode(v[i])
+                queue.append(node.left)
+            i += 1
+            if i < len(v) and v[i] is not None:
+                node.right = TreeNode(v[i])
+                queue.append(node.right)
+            i += 1
+        return root
+    return v
+
+
+def _encode(t, v):
+    if t.endswith("[]"):
+        return [_encode(t[:-2], x) for x in v]
+    if t == "ListNode":
+        out = []
+        while v is not None:
+            out.append(v.val)
+            v = v.next
+        return out
+    if t == "TreeNode":
+        out, queue = [], [v]
+        for node in queue:
+            if node is None:
+                out.append(None)
+                continue
+            out.append(node.val)
+            queue.append(node.left)
+            queue.append(node.right)
+        while out and out[-1] is None:
+            out.pop()
+        return out
+    if t == "double":
+        return float(v)
+    return v
+
+
+def _main():
+    sys.setrecursionlimit(1000000)
+    types = _PARAM_TYPES
+    lines = [line for line in sys.stdin.read().splitlines() if line.strip()]
+    if len(lines) != len(types):
+        sys.exit("expected %d input lines, got %d" % (len(types), len(lines)))
+    args = [_decode(t, json.loads(line)) for t, line in zip(types, lines)]
+    fn = getattr(Solution(), _FUNCTION) if "Solution" in globals() else globals()[_FUNCTION]
+    result = fn(*args)
+    sys.stdout.flush()
+    sys.stdout.write("\n" + json.dumps(_encode(_RETURNS, result), separators=(",", ":")) + "\n")
+
+
+_main()
+`
+
+func pythonHarness(signature *types.Signature, code string) string {
+	var b strings.Builder
+	b.WriteString(pythonPrologue)
+	b.WriteString("\n")
+	b.WriteString(code)
+	b.WriteString("\n\n")
+	fmt.Fprintf(&b, "_PARAM_TYPES = %s\n", paramTypes(signature))
+	fmt.Fprintf(&b, "_FUNCTION = %q\n", signature.Function)
+	fmt.Fprintf(&b, "_RETURNS = %q\n", signature.Returns)
+	b.WriteString(pythonEpilogue)
+	return b.String()
+}
+
+func pythonType(t string) string {
+	if element, ok := types.ElementType(t); ok {
+		return "List[" + pythonType(element) + "]"
+	}
+	switch t {
+	case "int", "long":
+		return "int"
+	case "double":
+		return "float"
+	case "string":
+		return "str"
+	case "ListNode", "TreeNode":
+		return "Optional[" + t + "]"
+	}
+	return t
+}
+
+func pythonStub(signature *types.Signature) string {
+	params := []string{"self"}
+	for _, param := range signature.Params {
+		params = append(params, param.Name+": "+pythonType(param.Type))
+	}
+	return fmt.Sprintf("class Solution:\n    def %s(%s) -> %s:\n        pass\n",
+		signature.Function, strings.Join(params, ", "), pythonType(signature.Returns))
+}
+
+const nodejsPrologue = `class ListNode {
+    constructor(val = 0, next = null) {
+        this.val = val;
+        this.next = next;
+    }
+}
+
+class TreeNode {
+    constructor(val = 0, left = null, right = null) {
+        this.val = val;
+        this.left = left;
+        this.right = right;
+    }
+}
+
+`
+
+// The learner's function is passed into the harness's own scope, so that
+// the harness's names can't shadow it whatever it is called.
+const nodejsEpilogue = `
+;((solution) => {
+    const decode = (t, v) => {
+        if (t.endsWith("[]")) {
+            return v.map((x) => decode(t.slice(0, -2), x));
+        }
+        if (t === "ListNode") {
+            let head = null;
+            for (let i = v.length - 1; i >= 0; i--) {
+                head = new ListNode(v[i], head);
+            }
+            return head;
+        }
+        if (t === "TreeNode") {
+            if (v.length === 0 || v[0] === null) {
+                return null;
+            }
+            const root = new TreeNode(v[0]);
+            const queue = [root];
+            let i = 1;
+            for (let q = 0; q < queue.length; q++) {
+                const node = queue[q];
+                if (i < v.length && v[i] !== null) {
+                    node.left = new TreeNode(v[i]);
+                    queue.push(node.left);
+                }
+                i++;
+                if (i < v.length && v[i] !== null) {
+                    node.right = new TreeNode(v[i]);
+                    queue.push(node.right);
+                }
+                i++;
+            }
+            return root;
+        }
+        return v;
+    };
+
+    const encode = (t, v) => {
+        if (t.endsWith("[]")) {
+            return v.map((x) => encode(t.slice(0, -2), x));
+        }
+        if (t === "ListNode") {
+            const out = [];
+            for (; v !== null && v !== undefined; v = v.next) {
+                out.push(v.val);
+            }
+            return out;
+        }
+        if (t === "TreeNode") {
+            const out = [];
+            const queue = [v];
+            for (let q = 0; q < queue.length; q++) {
+                const node = queue[q];
+                if (node === null || node === undefined) {
+                    out.push(null);
+                    continue;
+                }
+                out.push(node.val);
+                queue.push(node.left, node.right);
+            }
+            while (out.length > 0 && out[out.length - 1] === null) {
+                out.pop();
+            }
+            return out;
+        }
+        return v;
+    };
+
+    const lines = require("fs").readFileSync(0, "utf8").split("\n").filter((line) => line.trim() !== "");
+    if (lines.length !== PARAM_TYPES.length) {
+        console.error("expected " + PARAM_TYPES.length + " input lines, got " + lines.length);
+        process.exit(1);
+    }
+    const args = lines.map((line, i) => decode(PARAM_TYPES[i], JSON.parse(line)));
+    const result = solution(...args);
+    process.stdout.write("\n" + JSON.stringify(encode(RETURNS, result)) + "\n");
+})(FUNCTION);
+`
+
+func nodejsHarness(signature *types.Signature, code string) string {
+	epilogue := strings.NewReplacer(
+		"PARAM_TYPES", paramTypes(signature),
+		"FUNCTION", signature.Function,
+		"RETURNS", fmt.Sprintf("%q", signature.Returns),
+	).Replace(nodejsEpilogue)
+
+	var b strings.Builder
+	b.WriteString(nodejsPrologue)
+	b.WriteString(code)
+	b.WriteString("\n")
+	b.WriteString(epilogue)
+	return b.String()
+}
+
+func nodejsType(t string) string {
+	if element, ok := types.ElementType(t); ok {
+		return nodejsType(element) + "[]"
+	}
+	switch t {
+	case "int", "long", "double":
+		return "number"
+	case "bool":
+		return "boolean"
+	case "ListNode", "TreeNode":
+		return t + "|null"
+	}
+	return t
+}
+
+func nodejsStub(signature *types.Signature) string {
+	var b strings.Builder
+	b.WriteString("/**\n")
+	names := make([]string, len(signature.Params))
+	for i, param := range signature.Params {
+		names[i] = param.Name
+		fmt.Fprintf(&b, " * @param {%s} %s\n", nodejsType(param.Type), param.Name)
+	}
+	fmt.Fprintf(&b, " * @return {%s}\n", nodejsType(signature.Returns))
+	b.WriteString(" */\n")
+	fmt.Fprintf(&b, "var %s = function(%s) {\n\n};\n", signature.Function, strings.Join(names, ", "))
+	return b.String()
+}
+
+const cppPrologue = `#include <bits/stdc++.h>
+using namespace std;
+
+struct ListNode {
+    int val;
+    ListNode *next;
+    ListNode() : val(0), next(nullptr) {}
+    ListNode(int x) : val(x), next(nullptr) {}
+    ListNode(int x, ListNode *next) : val(x), next(next) {}
+};
+
+struct TreeNode {
+    int val;
+    TreeNode *left;
+    TreeNode *right;
+    TreeNode() : val(0), left(nullptr), right(nullptr) {}
+    TreeNode(int x) : val(x), left(nullptr), right(nullptr) {}
+    TreeNode(int x, TreeNode *left, TreeNode *right) : val(x), left(left), right(right) {}
+};
+
+`
+
+// The C++ harness carries a small JSON reader and writer for the value
+// types, since the runners have no JSON library to link against.
+const cppHarnessCode = `
+namespace harness {
+
+struct Reader {
+    const std::string &s;
+    size_t i = 0;
+    explicit Reader(const std::string &s) : s(s) {}
+
+    void ws() {
+        while (i < s.size() && isspace((unsigned char)s[i])) i++;
+    }
+    bool peek(char c) {
+        ws();
+        return i < s.size() && s[i] == c;
+    }
+    void expect(char c) {
+        if (!peek(c)) throw std::runtime_error(std::string("expected '") + c + "' in " + s);
+        i++;
+    }
+    bool literal(const char *word) {
+        ws();
+        size_t n = strlen(word);
+        if (s.compare(i, n, word) != 0) return false;
+        i += n;
+        return true;
+    }
+    std::string number() {
+        ws();
+        size_t start = i;
+        while (i < s.size() && (isdigit((unsigned char)s[i]) || strchr("+-.eE", s[i]))) i++;
+        if (start == i) throw std::runtime_error("expected a number in " + s);
+        return s.substr(start, i - start);
+    }
+};
+
+void read(Reader &r, int &v);
+void read(Reader &r, long long &v);
+void read(Reader &r, double &v);
+void read(Reader &r, bool &v);
+void read(Reader &r, std::string &v);
+void read(Reader &r, std::optional<int> &v);
+void read(Reader &r, ListNode *&v);
+void read(Reader &r, TreeNode *&v);
+template <class T> void read(Reader &r, std::vector<T> &v);
+
+void write(std::ostream &o, int v);
+void write(std::ostream &o, long long v);
+void write(std::ostream &o, double v);
+void write(std::ostream &o, bool v);
+void write(std::ostream &o, const std::string &v);
+void write(std::ostream &o, ListNode *v);
+void write(std::ostream &o, TreeNode *v);
+template <class T> void write(std::ostream &o, const std::vector<T> &v);
+
+void read(Reader &r, int &v) { v = std::stoi(r.number()); }
+void read(Reader &r, long long &v) { v = std::stoll(r.number()); }
+void read(Reader &r, double &v) { v = std::stod(r.number()); }
+
+void read(Reader &r, bool &v) {
+    if (r.literal("true")) v = true;
+    else if (r.literal("false")) v = false;
+    else throw std::runtime_error("expected a boolean in " + r.s);
+}
+
+void appendUtf8(std::string &out, unsigned code) {
+    if (code < 0x80) {
+        out += (char)code;
+    } else if (code < 0x800) {
+        out += (char)(0xC0 | (code >> 6));
+        out += (char)(0x80 | (code & 0x3F));
+    } else {
+        out += (char)(0xE0 | (code >> 12));
+        out += (char)(0x80 | ((code >> 6) & 0x3F));
+        out += (char)(0x80 | (code & 0x3F));
+    }
+}
+
+void read(Reader &r, std::string &v) {
+    r.expect('"');
+    v.clear();
+    while (r.i < r.s.size() && r.s[r.i] != '"') {
+        char c = r.s[r.i++];
+        if (c != '\\' || r.i >= r.s.size()) {
+            v += c;
+            continue;
+        }
+        char e = r.s[r.i++];
+        switch (e) {
+        case 'n': v += '\n'; break;
+        case 't': v += '\t'; break;
+        case 'r': v += '\r'; break;
+        case 'b': v += '\b'; break;
+        case 'f': v += '\f'; break;
+        case 'u':
+            appendUtf8(v, std::stoul(r.s.substr(r.i, 4), nullptr, 16));
+            r.i += 4;
+            break;
+        default: v += e;
+        }
+    }
+    r.expect('"');
+}
+
+void read(Reader &r, std::optional<int> &v) {
+    if (r.literal("null")) {
+        v = std::nullopt;
+        return;
+    }
+    int x;
+    read(r, x);
+    v = x;
+}
+
+void read(Reader &r, ListNode *&v) {
+    std::vector<int> values;
+    read(r, values);
+    v = nullptr;
+    for (auto it = values.rbegin(); it != values.rend(); ++it) v = new ListNode(*it, v);
+}
+
+void read(Reader &r, TreeNode *&v) {
+    std::vector<std::optional<int>> values;
+    read(r, values);
+    v = nullptr;
+    if (values.empty() || !values[0]) return;
+    v = new TreeNode(*values[0]);
+    std::vector<TreeNode *> queue = {v};
+    size_t i = 1;
+    for (size_t q = 0; q < queue.size(); q++) {
+        TreeNode *node = queue[q];
+        if (i < values.size() && values[i]) {
+            node->left = new TreeNode(*values[i]);
+            queue.push_back(node->left);
+        }
+        i++;
+        if (i < values.size() && values[i]) {
+            node->right = new TreeNode(*values[i]);
+            queue.push_back(node->right);
+        }
+        i++;
+    }
+}
+
+template <class T> void read(Reader &r, std::vector<T> &v) {
+    r.expect('[');
+    v.clear();
+    if (r.peek(']')) {
+        r.i++;
+        return;
+    }
+    while (true) {
+        T x;
+        read(r, x);
+        v.push_back(std::move(x));
+        if (!r.peek(',')) break;
+        r.i++;
+    }
+    r.expect(']');
+}
+
+void write(std::ostream &o, int v) { o << v; }
+void write(std::ostream &o, long long v) { o << v; }
+void write(std::ostream &o, double v) { o << std::setprecision(17) << v; }
+void write(std::ostream &o, bool v) { o << (v ? "true" : "false"); }
+
+void write(std::ostream &o, const std::string &v) {
+    o << '"';
+    for (char c : v) {
+        switch (c) {
+        case '"': o << "\\\""; break;
+        case '\\': o << "\\\\"; break;
+        case '\n': o << "\\n"; break;
+        case '\t': o << "\\t"; break;
+        case '\r': o << "\\r"; break;
+        default:
+            if ((unsigned char)c < 0x20) {
+                char buf[8];
+                snprintf(buf, sizeof(buf), "\\u%04x", c);
+                o << buf;
+            } else {
+                o << c;
+            }
+        }
+    }
+    o << '"';
+}
+
+void write(std::ostream &o, ListNode *v) {
+    std::vector<int> values;
+    for (; v; v = v->next) values.push_back(v->val);
+    write(o, values);
+}
+
+void write(std::ostream &o, TreeNode *v) {
+    std::vector<std::string> out;
+    std::vector<TreeNode *> queue = {v};
+    for (size_t q = 0; q < queue.size(); q++) {
+        TreeNode *node = queue[q];
+        if (!node) {
+            out.push_back("null");
+            continue;
+        }
+        out.push_back(std::to_string(node->val));
+        queue.push_back(node->left);
+        queue.push_back(node->right);
+    }
+    while (!out.empty() && out.back() == "null") out.pop_back();
+    o << '[';
+    for (size_t i = 0; i < out.size(); i++) o << (i ? "," : "") << out[i];
+    o << ']';
+}
+
+template <class T> void write(std::ostream &o, const std::vector<T> &v) {
+    o << '[';
+    for (size_t i = 0; i < v.size(); i++) {
+        if (i) o << ',';
+        write(o, static_cast<T>(v[i]));
+    }
+    o << ']';
+}
+
+} // namespace harness
+`
+
+func cppType(t string) string {
+	if element, ok := types.ElementType(t); ok {
+		return "vector<" + cppType(element) + ">"
+	}
+	switch t {
+	case "long":
+		return "long long"
+	case "ListNode", "TreeNode":
+		return t + "*"
+	}
+	return t
+}
+
+func cppParamType(t string) string {
+	if _, ok := types.ElementType(t); ok || t == "string" {
+		return cppType(t) + "&"
+	}
+	return cppType(t)
+}
+
+func cppHarness(signature *types.Signature, code string) string {
+	var b strings.Builder
+	b.WriteString(cppPrologue)
+	b.WriteString(code)
+	b.WriteString("\n")
+	b.WriteString(cppHarnessCode)
+
+	b.WriteString("\nint main() {\n")
+	b.WriteString("    std::vector<std::string> lines;\n")
+	b.WriteString("    std::string line;\n")
+	b.WriteString("    while (std::getline(std::cin, line)) {\n")
+	b.WriteString("        if (line.find_first_not_of(\" \\t\\r\") != std::string::npos) lines.push_back(line);\n")
+	b.WriteString("    }\n")
+	fmt.Fprintf(&b, "    if (lines.size() != %d) {\n", len(signature.Params))
+	fmt.Fprintf(&b, "        std::cerr << \"expected %d input lines, got \" << lines.size() << std::endl;\n", len(signature.Params))
+	b.WriteString("        return 1;\n")
+	b.WriteString("    }\n")
+
+	args := make([]string, len(signature.Params))
+	for i, param := range signature.Params {
+		args[i] = fmt.Sprintf("arg%d", i)
+		fmt.Fprintf(&b, "    %s arg%d{};\n", cppType(param.Type), i)
+	}
+	b.WriteString("    try {\n")
+	for i := range signature.Params {
+		fmt.Fprintf(&b, "        harness::Reader reader%d(lines[%d]);\n", i, i)
+		fmt.Fprintf(&b, "        harness::read(reader%d, arg%d);\n", i, i)
+	}
+	b.WriteString("    } catch (const std::exception &e) {\n")
+	b.WriteString("        std::cerr << \"invalid test input: \" << e.what() << std::endl;\n")
+	b.WriteString("        return 1;\n")
+	b.WriteString("    }\n")
+
+	fmt.Fprintf(&b, "    %s result = Solution().%s(%s);\n", cppType(signature.Returns), signature.Function, strings.Join(args, ", "))
+	b.WriteString("    std::cout << std::endl;\n")
+	b.WriteString("    harness::write(std::cout, result);\n")
+	b.WriteString("    std::cout << std::endl;\n")
+	b.WriteString("    return 0;\n")
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func cppStub(signature *types.Signature) string {
+	params := make([]string, len(signature.Params))
+	for i, param := range signature.Params {
+		params[i] = cppParamType(param.Type) + " " + param.Name
+	}
+	return fmt.Sprintf("class Solution {\npublic:\n    %s %s(%s) {\n\n    }\n};\n",
+		cppType(signature.Returns), signature.Function, strings.Join(params, ", "))
+}
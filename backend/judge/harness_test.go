@@ -0,0 +1,244 @@
+package judge
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"learncode/backend/types"
+)
+
+var twoSum = &types.Signature{
+	Function: "twoSum",
+	Params:   []types.Param{{Name: "nums", Type: "int[]"}, {Name: "target", Type: "int"}},
+	Returns:  "int[]",
+}
+
+var levels = &types.Signature{
+	Function: "levels",
+	Params:   []types.Param{{Name: "root", Type: "TreeNode"}, {Name: "scale", Type: "double"}},
+	Returns:  "long[][]",
+}
+
+func TestStub(t *testing.T) {
+	tests := []struct {
+		language  string
+		signature *types.Signature
+		want      string
+	}{
+		{"python", twoSum, "class Solution:\n" +
+			"    def twoSum(self, nums: List[int], target: int) -> List[int]:\n" +
+			"        pass\n"},
+		{"python", levels, "class Solution:\n" +
+			"    def levels(self, root: Optional[TreeNode], scale: float) -> List[List[int]]:\n" +
+			"        pass\n"},
+		{"nodejs", twoSum, "/**\n" +
+			" * @param {number[]} nums\n" +
+			" * @param {number} target\n" +
+			" * @return {number[]}\n" +
+			" */\n" +
+			"var twoSum = function(nums, target) {\n\n};\n"},
+		{"nodejs", levels, "/**\n" +
+			" * @param {TreeNode|null} root\n" +
+			" * @param {number} scale\n" +
+			" * @return {number[][]}\n" +
+			" */\n" +
+			"var levels = function(root, scale) {\n\n};\n"},
+		{"cpp", twoSum, "class Solution {\npublic:\n" +
+			"    vector<int> twoSum(vector<int>& nums, int target) {\n\n    }\n};\n"},
+		{"cpp", levels, "class Solution {\npublic:\n" +
+			"    vector<vector<long long>> levels(TreeNode* root, double scale) {\n\n    }\n};\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.language+"/"+tt.signature.Function, func(t *testing.T) {
+			got, ok := Stub(tt.language, tt.signature)
+			if !ok {
+				t.Fatalf("Stub(%q) not supported", tt.language)
+			}
+			if got != tt.want {
+				t.Errorf("Stub(%q) =\n%s\nwant\n%s", tt.language, got, tt.want)
+			}
+		})
+	}
+
+	if _, ok := Stub("java", twoSum); ok {
+		t.Error("Stub(java) is supported, want no stub")
+	}
+}
+
+func TestHarnessSignature(t *testing.T) {
+	tests := []struct {
+		language string
+		want     []string
+	}{
+		{"python", []string{
+			`_PARAM_TYPES = ["int[]","int"]`,
+			`_FUNCTION = "twoSum"`,
+			`_RETURNS = "int[]"`,
+		}},
+		{"nodejs", []string{
+			`lines.length !== ["int[]","int"].length`,
+			`decode(["int[]","int"][i], JSON.parse(line))`,
+			`encode("int[]", result)`,
+			`})(twoSum);`,
+		}},
+		{"cpp", []string{
+			`if (lines.size() != 2) {`,
+			`vector<int> arg0{};`,
+			`int arg1{};`,
+			`harness::read(reader1, arg1);`,
+			`vector<int> result = Solution().twoSum(arg0, arg1);`,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.language, func(t *testing.T) {
+			got, err := Harness(tt.language, twoSum, "// solution")
+			if err != nil {
+				t.Fatalf("Harness(%q): %v", tt.language, err)
+			}
+			if !strings.Contains(got, "// solution") {
+				t.Error("harness does not contain the solution")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("harness does not contain %q", want)
+				}
+			}
+		})
+	}
+
+	if _, err := Harness("java", twoSum, ""); err == nil {
+		t.Error("Harness(java) succeeded, want an error")
+	}
+}
+
+// runHarness runs a harness with the interpreter or compiler on the PATH,
+// skipping the test when the language isn't installed.
+func runHarness(t *testing.T, language string, signature *types.Signature, code string, input string) string {
+	t.Helper()
+	source, err := Harness(language, signature, code)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	var run []string
+	switch language {
+	case "python":
+		run = []string{"python3", "solution.py"}
+	case "nodejs":
+		run = []string{"node", "solution.js"}
+	case "cpp":
+		run = []string{"./solution"}
+	}
+	tool := run[0]
+	if language == "cpp" {
+		if testing.Short() {
+			t.Skip("compiling C++ is slow")
+		}
+		tool = "g++"
+	}
+	if _, err := exec.LookPath(tool); err != nil {
+		t.Skipf("%s is not installed", tool)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, Languages[language].Source), []byte(source), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if language == "cpp" {
+		cmd := exec.Command("g++", "-std=c++17", "-o", "solution", "solution.cpp")
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("compile: %v\n%s", err, out)
+		}
+	}
+
+	cmd := exec.Command(run[0], run[1:]...)
+	cmd.Dir = dir
+	cmd.Stdin = strings.NewReader(input)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("run: %v\n%s", err, out)
+	}
+	return string(out)
+}
+
+// The solutions echo their arguments back, so each case checks that a
+// value survives decoding and encoding unchanged.
+var echoSolutions = map[string]string{
+	"python": "class Solution:\n    def echo(self, a, b):\n        print('debug output')\n        return b\n",
+	"nodejs": "var echo = function(a, b) {\n    console.log('debug output');\n    return b;\n};\n",
+	"cpp": "class Solution {\npublic:\n    %s echo(int a, %s b) {\n" +
+		"        cout << \"debug output\" << endl;\n        return b;\n    }\n};\n",
+}
+
+func TestHarnessMarshalling(t *testing.T) {
+	tests := []struct {
+		name     string
+		typ      string
+		input    string
+		expected string
+	}{
+		{"ints", "int[]", "[3, -1, 2]", "[3,-1,2]"},
+		{"empty array", "int[]", "[]", "[]"},
+		{"matrix", "int[][]", "[[1,2],[3]]", "[[1,2],[3]]"},
+		{"long", "long", "9007199254740993", "9007199254740993"},
+		{"double", "double", "2.5", "2.5"},
+		{"bool", "bool", "true", "true"},
+		{"string", "string", `"a \"quoted\"\nline"`, `"a \"quoted\"\nline"`},
+		{"strings", "string[]", `["x","y"]`, `["x","y"]`},
+		{"list", "ListNode", "[1,2,3]", "[1,2,3]"},
+		{"empty list", "ListNode", "[]", "[]"},
+		{"tree", "TreeNode", "[1,null,2,3]", "[1,null,2,3]"},
+		{"empty tree", "TreeNode", "[]", "[]"},
+	}
+	for language, solution := range echoSolutions {
+		t.Run(language, func(t *testing.T) {
+			for _, tt := range tests {
+				// Python and Node.js numbers lose precision past 2^53
+				if tt.typ == "long" && language != "cpp" {
+					continue
+				}
+				t.Run(tt.name, func(t *testing.T) {
+					signature := &types.Signature{
+						Function: "echo",
+						Params:   []types.Param{{Name: "a", Type: "int"}, {Name: "b", Type: tt.typ}},
+						Returns:  tt.typ,
+					}
+					code := solution
+					if language == "cpp" {
+						code = strings.ReplaceAll(strings.Replace(solution, "%s", cppType(tt.typ), 1), "%s", cppParamType(tt.typ))
+					}
+					output := runHarness(t, language, signature, code, "7\n"+tt.input+"\n")
+					if !strings.HasPrefix(output, "debug output\n") {
+						t.Errorf("output %q does not start with the solution's own output", output)
+					}
+					if ok, feedback := compareValues(output, tt.expected); !ok {
+						t.Error(feedback)
+					}
+				})
+			}
+		})
+	}
+}
+
+// Function names the Node.js harness uses for itself must still call the
+// learner's function, not the harness's.
+func TestHarnessNamesDoNotShadowFunction(t *testing.T) {
+	for _, name := range []string{"decode", "encode", "lines", "args", "result", "solution"} {
+		t.Run(name, func(t *testing.T) {
+			signature := &types.Signature{
+				Function: name,
+				Params:   []types.Param{{Name: "n", Type: "int"}},
+				Returns:  "int",
+			}
+			code := "var " + name + " = function(n) {\n    return n * 2;\n};\n"
+			output := runHarness(t, "nodejs", signature, code, "21\n")
+			if ok, feedback := compareValues(output, "42"); !ok {
+				t.Error(feedback)
+			}
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package judge
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"learncode/backend/types"
+)
+
+// Interactors follow the testlib convention: they are called as
+// `interactor <input> <output>` with their stdin connected to the
+// solution's stdout and their stdout to the solution's stdin, and report the
+// verdict through their exit status. Anything written to <output> is passed
+// to the checker, if the problem has one.
+const (
+	interactorAccepted          = 0
+	interactorWrongAnswer       = 1
+	interactorPresentationError = 2
+)
+
+// interactorGrace is how long the interactor may keep running after the
+// solution's time limit, to read the last answer and decide on a verdict.
+const interactorGrace = 2 * time.Second
+
+type interaction struct {
+	Verdict  string
+	Feedback string
+	Output   string // Contents of the interactor's output file
+	Duration time.Duration
+}
+
+// interact runs the solution against the interactor on one test. Both
+// processes share the test's time limit, and the solution is stopped as soon
+// as it writes more than queryLimit lines (no limit when zero) or the
+// interactor rejects it.
+func interact(ctx context.Context, solution *Program, interactor *Program, test types.TestCase, timeLimit time.Duration, queryLimit int) (*interaction, error) {
+	if err := writeInput(ctx, filepath.Join(interactor.Dir, "input.txt"), test); err != nil {
+		return nil, err
+	}
+	outputPath := filepath.Join(interactor.Dir, "output.txt")
+	os.Remove(outputPath)
+
+	solutionCtx, cancelSolution := context.WithTimeout(ctx, timeLimit)
+	defer cancelSolution()
+	interactorCtx, cancelInteractor := context.WithTimeout(ctx, timeLimit+interactorGrace)
+	defer cancelInteractor()
+
+	// solution stdout -> counter -> interactor stdin, interactor stdout -> solution stdin
+	solutionOutR, solutionOutW, err := os.Pipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pipe: %v", err)
+	}
+	defer solutionOutR.Close()
+	interactorInR, interactorInW, err := os.Pipe()
+	if err != nil {
+		solutionOutW.Close()
+		return nil, fmt.Errorf("failed to create pipe: %v", err)
+	}
+	interactorOutR, interactorOutW, err := os.Pipe()
+	if err != nil {
+		solutionOutW.Close()
+		interactorInR.Close()
+		interactorInW.Close()
+		return nil, fmt.Errorf("failed to create pipe: %v", err)
+	}
+
+	var solutionStderr, interactorStderr bytes.Buffer
+	solutionCmd := exec.CommandContext(solutionCtx, solution.Run[0], solution.Run[1:]...)
+	solutionCmd.Dir = solution.Dir
+	solutionCmd.Stdin = interactorOutR
+	solutionCmd.Stdout = solutionOutW
+	solutionCmd.Stderr = &solutionStderr
+
+	args := append(append([]string{}, interactor.Run...), "input.txt", "output.txt")
+	interactorCmd := exec.CommandContext(interactorCtx, args[0], args[1:]...)
+	interactorCmd.Dir = interactor.Dir
+	interactorCmd.Stdin = interactorInR
+	interactorCmd.Stdout = interactorOutW
+	interactorCmd.Stderr = &interactorStderr
+
+	interactorErr := interactorCmd.Start()
+	var solutionErr error
+	if interactorErr == nil {
+		solutionErr = solutionCmd.Start()
+		if solutionErr != nil {
+			interactorCmd.Process.Kill()
+			interactorCmd.Wait()
+		}
+	}
+	start := time.Now()
+
+	// The children hold their own copies now
+	solutionOutW.Close()
+	interactorInR.Close()
+	interactorOutR.Close()
+	interactorOutW.Close()
+
+	if interactorErr != nil {
+		interactorInW.Close()
+		return nil, fmt.Errorf("failed to start interactor: %v", interactorErr)
+	}
+	if solutionErr != nil {
+		interactorInW.Close()
+		return nil, fmt.Errorf("failed to start program: %v", solutionErr)
+	}
+
+	// Forward the solution's output line by line, counting queries. Once the
+	// interactor stops reading, the rest is drained so the solution never
+	// blocks on a full pipe.
+	queries := 0
+	limitExceeded := false
+	copied := make(chan struct{})
+	go func() {
+		defer close(copied)
+		defer interactorInW.Close()
+		buf := make([]byte, 32*1024)
+		forward := true
+		for {
+			n, err := solutionOutR.Read(buf)
+			if n > 0 {
+				queries += bytes.Count(buf[:n], []byte("\n"))
+				if queryLimit > 0 && queries > queryLimit && !limitExceeded {
+					limitExceeded = true
+					forward = false
+					cancelSolution()
+				}
+				if forward {
+					if _, err := interactorInW.Write(buf[:n]); err != nil {
+						forward = false
+					}
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	var duration time.Duration
+	solutionDone := make(chan error, 1)
+	go func() {
+		err := solutionCmd.Wait()
+		duration = time.Since(start)
+		solutionDone <- err
+	}()
+	interactorDone := make(chan error, 1)
+	go func() {
+		interactorDone <- interactorCmd.Wait()
+	}()
+
+	select {
+	case interactorErr = <-interactorDone:
+		// A rejected solution is stopped right away rather than left to
+		// run into its time limit
+		if interactorErr != nil {
+			cancelSolution()
+		}
+		solutionErr = <-solutionDone
+	case solutionErr = <-solutionDone:
+		interactorErr = <-interactorDone
+	}
+	<-copied
+
+	result := &interaction{Duration: duration}
+	feedback := strings.TrimSpace(interactorStderr.String())
+
+	if limitExceeded {
+		result.Verdict = types.VerdictWrongAnswer
+		result.Feedback = fmt.Sprintf("query limit exceeded: more than %d queries", queryLimit)
+		return result, nil
+	}
+	if solutionCtx.Err() == context.DeadlineExceeded {
+		result.Verdict = types.VerdictTimeLimitExceeded
+		result.Feedback = "execution timed out"
+		return result, nil
+	}
+
+	exitCode := interactorAccepted
+	if interactorErr != nil {
+		var exitErr *exec.ExitError
+		switch {
+		case errors.As(interactorErr, &exitErr):
+			exitCode = exitErr.ExitCode()
+		case interactorCtx.Err() == nil:
+			return nil, fmt.Errorf("failed to run interactor: %v", interactorErr)
+		default:
+			exitCode = -1
+		}
+	}
+
+	// A solution that exits with an error status fails with a runtime error
+	// even if the interactor noticed first. One that was killed after the
+	// interactor rejected it gets the interactor's verdict.
+	if solutionErr != nil {
+		var exitErr *exec.ExitError
+		switch {
+		case errors.As(solutionErr, &exitErr) && (exitErr.ExitCode() > 0 || solutionCtx.Err() == nil):
+			result.Verdict = types.VerdictRuntimeError
+			result.Feedback = fmt.Sprintf("execution failed: %s", solutionStderr.String())
+			return result, nil
+		case solutionCtx.Err() == nil:
+			return nil, fmt.Errorf("failed to run program: %v", solutionErr)
+		}
+	}
+	if exitCode == interactorWrongAnswer || exitCode == interactorPresentationError {
+		result.Verdict = types.VerdictWrongAnswer
+		result.Feedback = feedback
+		return result, nil
+	}
+
+	if interactorCtx.Err() == context.DeadlineExceeded {
+		return nil, configErrorf("interactor timed out")
+	}
+	if exitCode != interactorAccepted {
+		return nil, configErrorf("interactor failed with status %d: %s", exitCode, feedback)
+	}
+
+	output, err := os.ReadFile(outputPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read interactor output: %v", err)
+	}
+
+	result.Verdict = types.VerdictAccepted
+	result.Feedback = feedback
+	result.Output = string(output)
+	return result, nil
+}
@@ -0,0 +1,130 @@
+package judge
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"learncode/backend/types"
+)
+
+// guessInteractor answers "? x" queries about the secret number in its input
+// file with <, > or =, and checks the final "! x" answer. A first line of
+// "exit n" makes it exit with status n instead.
+const guessInteractor = `import sys
+
+secret = open(sys.argv[1]).read().strip()
+if secret.startswith("exit "):
+    sys.stderr.write("interactor gave up")
+    sys.exit(int(secret[5:]))
+secret = int(secret)
+for line in sys.stdin:
+    kind, value = line.split()
+    value = int(value)
+    if kind == "!":
+        if value != secret:
+            sys.stderr.write("wrong guess %d" % value)
+            sys.exit(1)
+        open(sys.argv[2], "w").write("guessed %d" % value)
+        sys.exit(0)
+    if kind != "?":
+        sys.exit(2)
+    print("<" if secret < value else ">" if secret > value else "=", flush=True)
+sys.stderr.write("no answer")
+sys.exit(1)
+`
+
+const binarySearch = `lo, hi = 1, 100
+while True:
+    mid = (lo + hi) // 2
+    print("?", mid, flush=True)
+    answer = input()
+    if answer == "=":
+        break
+    if answer == "<":
+        hi = mid - 1
+    else:
+        lo = mid + 1
+print("!", mid, flush=True)
+`
+
+const linearSearch = `for x in range(1, 101):
+    print("?", x, flush=True)
+    if input() == "=":
+        break
+print("!", x, flush=True)
+`
+
+func pythonProgram(t *testing.T, code string) *Program {
+	t.Helper()
+	if _, err := exec.LookPath("python3"); err != nil {
+		t.Skip("python3 is not installed")
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "main.py"), []byte(code), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return &Program{Dir: dir, Run: []string{"python3", "main.py"}}
+}
+
+func TestInteract(t *testing.T) {
+	// Every line the solution writes counts towards the limit, its answer
+	// included: finding 42 takes 7 queries and an answer
+	tests := []struct {
+		name       string
+		solution   string
+		secret     string
+		timeLimit  time.Duration
+		queryLimit int
+		verdict    string
+		feedback   string
+		output     string
+	}{
+		{"accepted", binarySearch, "42", 5 * time.Second, 8, types.VerdictAccepted, "", "guessed 42"},
+		{"no query limit", linearSearch, "60", 5 * time.Second, 0, types.VerdictAccepted, "", "guessed 60"},
+		{"queries up to the limit", linearSearch, "9", 5 * time.Second, 10, types.VerdictAccepted, "", "guessed 9"},
+		{"query limit exceeded", linearSearch, "60", 5 * time.Second, 10, types.VerdictWrongAnswer, "query limit exceeded: more than 10 queries", ""},
+		{"wrong answer", `print("! 7", flush=True)`, "42", 5 * time.Second, 0, types.VerdictWrongAnswer, "wrong guess 7", ""},
+		{"presentation error", `print("guess 7", flush=True)`, "42", 5 * time.Second, 0, types.VerdictWrongAnswer, "", ""},
+		{"runtime error", "import sys\nsys.exit(3)", "42", 5 * time.Second, 0, types.VerdictRuntimeError, "execution failed", ""},
+		{"time limit", "import time\ntime.sleep(10)", "42", 500 * time.Millisecond, 0, types.VerdictTimeLimitExceeded, "execution timed out", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			solution := pythonProgram(t, tt.solution)
+			interactor := pythonProgram(t, guessInteractor)
+			result, err := interact(context.Background(), solution, interactor, types.TestCase{Input: tt.secret}, tt.timeLimit, tt.queryLimit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Verdict != tt.verdict {
+				t.Errorf("verdict = %s, want %s (%s)", result.Verdict, tt.verdict, result.Feedback)
+			}
+			if !strings.HasPrefix(result.Feedback, tt.feedback) {
+				t.Errorf("feedback = %q, want %q", result.Feedback, tt.feedback)
+			}
+			if result.Output != tt.output {
+				t.Errorf("output = %q, want %q", result.Output, tt.output)
+			}
+		})
+	}
+}
+
+// An interactor that fails with a status outside the testlib verdicts is the
+// problem's fault, not the solution's.
+func TestInteractInteractorFailure(t *testing.T) {
+	solution := pythonProgram(t, binarySearch)
+	interactor := pythonProgram(t, guessInteractor)
+	_, err := interact(context.Background(), solution, interactor, types.TestCase{Input: "exit 3"}, 5*time.Second, 0)
+	if !errors.Is(err, ErrProblemConfig) {
+		t.Fatalf("err = %v, want ErrProblemConfig", err)
+	}
+	if !strings.Contains(err.Error(), "status 3: interactor gave up") {
+		t.Errorf("err = %v, want the interactor's status and message", err)
+	}
+}
@@ -0,0 +1,451 @@
+package judge
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"learncode/backend/testdata"
+	"learncode/backend/types"
+)
+
+// Language describes how to build and run a program written in one of the
+// supported languages. Commands run inside the program's work directory.
+type Language struct {
+	Source   string
+	Compile  []string
+	Run      []string
+	Prologue string // Prepended to full-program submissions
+}
+
+var Languages = map[string]Language{
+	"python": {
+		Source:   "solution.py",
+		Run:      []string{"python3", "solution.py"},
+		Prologue: "import sys\n\n", // Submissions have always been able to use sys without importing it
+	},
+	"nodejs": {
+		Source: "solution.js",
+		Run:    []string{"/opt/nodejs/bin/node", "solution.js"},
+	},
+	"cpp": {
+		Source:  "solution.cpp",
+		Compile: []string{"g++", "-O2", "-std=c++17", "-static", "-o", "solution", "solution.cpp"},
+		Run:     []string{"./solution"},
+	},
+	"java": {
+		Source:  "Main.java",
+		Compile: []string{"/opt/java/bin/javac", "Main.java"},
+		Run:     []string{"/opt/java/bin/java", "-XX:+UseSerialGC", "-XX:-UsePerfData", "-cp", ".", "Main"},
+	},
+}
+
+const compileTimeout = 30 * time.Second
+
+// TestResult is stored with the submission, so it lives in package types.
+type TestResult = types.TestResult
+
+type Result struct {
+	Verdict string       `json:"verdict"`
+	Output  string       `json:"output"` // Program output, or the failure details
+	Tests   []TestResult `json:"tests"`
+}
+
+// Program is a compiled program ready to be run in its work directory.
+type Program struct {
+	Dir string
+	Run []string
+}
+
+// Prepare writes code into a fresh temp directory and compiles it if the
+// language needs it. A compile failure is returned as a *CompileError.
+func Prepare(ctx context.Context, language string, code string) (*Program, error) {
+	lang, ok := Languages[language]
+	if !ok {
+		return nil, configErrorf("unsupported language: %s", language)
+	}
+
+	dir, err := os.MkdirTemp("/tmp", language+"-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp dir: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, lang.Source), []byte(code), 0644); err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("failed to write code file: %v", err)
+	}
+
+	if len(lang.Compile) > 0 {
+		ctx, cancel := context.WithTimeout(ctx, compileTimeout)
+		defer cancel()
+
+		cmd := exec.CommandContext(ctx, lang.Compile[0], lang.Compile[1:]...)
+		cmd.Dir = dir
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			os.RemoveAll(dir)
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) {
+				return nil, &CompileError{Output: string(output)}
+			}
+			return nil, fmt.Errorf("failed to run compiler: %v", err)
+		}
+	}
+
+	return &Program{Dir: dir, Run: lang.Run}, nil
+}
+
+// Close removes the program's work directory.
+func (p *Program) Close() error {
+	return os.RemoveAll(p.Dir)
+}
+
+type CompileError struct {
+	Output string
+}
+
+func (e *CompileError) Error() string {
+	return "compilation failed:\n" + e.Output
+}
+
+// ErrProblemConfig matches failures caused by how the problem is set up,
+// such as a checker that doesn't compile, rather than by the
+// infrastructure. Judging the submission again won't get past them.
+var ErrProblemConfig = errors.New("problem configuration error")
+
+type configError struct {
+	err error
+}
+
+func (e *configError) Error() string {
+	return e.err.Error()
+}
+
+func (e *configError) Unwrap() error {
+	return e.err
+}
+
+func (e *configError) Is(target error) bool {
+	return target == ErrProblemConfig
+}
+
+// configErrorf formats an error that matches ErrProblemConfig.
+func configErrorf(format string, args ...interface{}) error {
+	return &configError{err: fmt.Errorf(format, args...)}
+}
+
+// prepareError is the error for a checker or interactor that couldn't be
+// prepared, which is the problem's fault if it didn't compile.
+func prepareError(what string, err error) error {
+	var compileErr *CompileError
+	if errors.As(err, &compileErr) {
+		return configErrorf("failed to prepare %s: %v", what, err)
+	}
+	return fmt.Errorf("failed to prepare %s: %v", what, err)
+}
+
+// Judge runs code against every test of the problem and stops at the first
+// failing test. The returned error is reserved for infrastructure failures
+// and, matching ErrProblemConfig, problems that can't be judged; anything
+// caused by the submitted code is reported through the verdict.
+// Solutions to function-signature problems are wrapped in a harness first,
+// and SQL queries run in-process against an embedded database.
+func Judge(ctx context.Context, problem *types.Problem, language string, code string) (*Result, error) {
+	return JudgeWithProgress(ctx, problem, language, code, nil)
+}
+
+// Progress is called after each test with its 1-based index and result.
+type Progress func(index int, test TestResult)
+
+// JudgeWithProgress is Judge, calling progress (if not nil) as each test
+// finishes.
+func JudgeWithProgress(ctx context.Context, problem *types.Problem, language string, code string, progress Progress) (*Result, error) {
+	if language == types.LanguageSQL {
+		if problem.SQL == nil {
+			return nil, configErrorf("problem %s is not a SQL problem", problem.ID)
+		}
+		return judgeSQL(ctx, problem, code, progress)
+	}
+
+	if problem.Signature != nil {
+		harness, err := Harness(language, problem.Signature, code)
+		if err != nil {
+			return nil, &configError{err: err}
+		}
+		code = harness
+	} else {
+		code = Languages[language].Prologue + code
+	}
+
+	program, err := Prepare(ctx, language, code)
+	if err != nil {
+		var compileErr *CompileError
+		if errors.As(err, &compileErr) {
+			return &Result{Verdict: types.VerdictCompileError, Output: compileErr.Error()}, nil
+		}
+		return nil, err
+	}
+	defer program.Close()
+
+	var checker *Program
+	if problem.Checker != nil {
+		checker, err = Prepare(ctx, problem.Checker.Language, problem.Checker.Code)
+		if err != nil {
+			return nil, prepareError("checker", err)
+		}
+		defer checker.Close()
+	}
+
+	var interactor *Program
+	if problem.Interactor != nil {
+		interactor, err = Prepare(ctx, problem.Interactor.Language, problem.Interactor.Code)
+		if err != nil {
+			return nil, prepareError("interactor", err)
+		}
+		defer interactor.Close()
+	}
+
+	timeLimit := time.Duration(problem.TimeLimit()) * time.Millisecond
+	result := &Result{Verdict: types.VerdictAccepted}
+
+	for i, test := range problem.TestCases() {
+		name := test.Name
+		if name == "" {
+			name = fmt.Sprintf("%d", i+1)
+		}
+
+		var testResult TestResult
+		if interactor != nil {
+			testResult, result.Output, err = runInteraction(ctx, problem, program, interactor, checker, test, timeLimit)
+		} else {
+			testResult, result.Output, err = runTest(ctx, problem, program, checker, test, timeLimit)
+		}
+		if err != nil {
+			return nil, err
+		}
+		testResult.Name = name
+		if progress != nil {
+			progress(i+1, testResult)
+		}
+
+		result.Tests = append(result.Tests, testResult)
+		if testResult.Verdict != types.VerdictAccepted {
+			result.Verdict = testResult.Verdict
+			break
+		}
+	}
+
+	return result, nil
+}
+
+// runTest runs the program on one test and returns its result together with
+// the program's output, or the failure details.
+func runTest(ctx context.Context, problem *types.Problem, program *Program, checker *Program, test types.TestCase, timeLimit time.Duration) (TestResult, string, error) {
+	input, err := testdata.OpenInput(ctx, test)
+	if err != nil {
+		return TestResult{}, "", fmt.Errorf("failed to open test input: %v", err)
+	}
+	run, err := program.Exec(ctx, input, timeLimit)
+	input.Close()
+	if err != nil {
+		return TestResult{}, "", err
+	}
+
+	testResult := TestResult{Verdict: run.Verdict, TimeMs: run.Duration.Milliseconds()}
+	switch run.Verdict {
+	case types.VerdictTimeLimitExceeded:
+		return testResult, "execution timed out", nil
+	case types.VerdictRuntimeError:
+		return testResult, fmt.Sprintf("execution failed: %s", run.Stderr), nil
+	}
+
+	expected, err := testdata.ReadOutput(ctx, test)
+	if err != nil {
+		return TestResult{}, "", fmt.Errorf("failed to read expected output: %v", err)
+	}
+	var ok bool
+	var feedback string
+	if problem.Signature != nil && checker == nil {
+		ok, feedback = compareValues(run.Stdout, expected)
+	} else {
+		ok, feedback, err = check(ctx, checker, test, run.Stdout, expected)
+		if err != nil {
+			return TestResult{}, "", err
+		}
+	}
+	if !ok {
+		testResult.Verdict = types.VerdictWrongAnswer
+		return testResult, feedback, nil
+	}
+	return testResult, run.Stdout, nil
+}
+
+// runInteraction runs the program against the problem's interactor on one
+// test. When the problem also has a checker, it gets the interactor's output
+// file in place of the program's output.
+func runInteraction(ctx context.Context, problem *types.Problem, program *Program, interactor *Program, checker *Program, test types.TestCase, timeLimit time.Duration) (TestResult, string, error) {
+	outcome, err := interact(ctx, program, interactor, test, timeLimit, problem.QueryLimit)
+	if err != nil {
+		return TestResult{}, "", err
+	}
+
+	testResult := TestResult{Verdict: outcome.Verdict, TimeMs: outcome.Duration.Milliseconds()}
+	if outcome.Verdict != types.VerdictAccepted || checker == nil {
+		return testResult, outcome.Feedback, nil
+	}
+
+	expected, err := testdata.ReadOutput(ctx, test)
+	if err != nil {
+		return TestResult{}, "", fmt.Errorf("failed to read expected output: %v", err)
+	}
+	ok, feedback, err := check(ctx, checker, test, outcome.Output, expected)
+	if err != nil {
+		return TestResult{}, "", err
+	}
+	if !ok {
+		testResult.Verdict = types.VerdictWrongAnswer
+	}
+	return testResult, feedback, nil
+}
+
+type Run struct {
+	Verdict  string
+	ExitCode int
+	Stdout   string
+	Stderr   string
+	Duration time.Duration
+}
+
+// Exec runs the program once with the given stdin. Timeouts and non-zero
+// exits are reported in the verdict rather than as errors.
+func (p *Program) Exec(ctx context.Context, stdin io.Reader, timeLimit time.Duration) (*Run, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeLimit)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.Run[0], p.Run[1:]...)
+	cmd.Dir = p.Dir
+	cmd.Stdin = stdin
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	start := time.Now()
+	err := cmd.Run()
+	run := &Run{
+		Verdict:  types.VerdictAccepted,
+		Stdout:   stdout.String(),
+		Stderr:   stderr.String(),
+		Duration: time.Since(start),
+	}
+
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			run.Verdict = types.VerdictTimeLimitExceeded
+			return run, nil
+		}
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return nil, fmt.Errorf("failed to start program: %v", err)
+		}
+		run.Verdict = types.VerdictRuntimeError
+		run.ExitCode = exitErr.ExitCode()
+	}
+
+	return run, nil
+}
+
+// check compares a program's output with the expected answer, either with
+// the problem's custom checker or by exact match ignoring surrounding
+// whitespace. Checkers follow the testlib convention: they are called as
+// `checker <input> <output> <answer>` and accept by exiting with status 0.
+func check(ctx context.Context, checker *Program, test types.TestCase, output string, expected string) (bool, string, error) {
+	if checker == nil {
+		actual := strings.TrimSpace(output)
+		expected := strings.TrimSpace(expected)
+		if actual != expected {
+			return false, fmt.Sprintf("output mismatch\nExpected:\n%s\nGot:\n%s", expected, actual), nil
+		}
+		return true, "", nil
+	}
+
+	if err := writeInput(ctx, filepath.Join(checker.Dir, "input.txt"), test); err != nil {
+		return false, "", err
+	}
+	files := map[string]string{
+		"output.txt": output,
+		"answer.txt": expected,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(checker.Dir, name), []byte(content), 0644); err != nil {
+			return false, "", fmt.Errorf("failed to write checker file: %v", err)
+		}
+	}
+
+	args := append(append([]string{}, checker.Run...), "input.txt", "output.txt", "answer.txt")
+	run, err := (&Program{Dir: checker.Dir, Run: args}).Exec(ctx, strings.NewReader(""), compileTimeout)
+	if err != nil {
+		return false, "", fmt.Errorf("failed to run checker: %v", err)
+	}
+	if run.Verdict == types.VerdictTimeLimitExceeded {
+		return false, "", configErrorf("checker timed out")
+	}
+
+	return run.Verdict == types.VerdictAccepted, strings.TrimSpace(run.Stdout + run.Stderr), nil
+}
+
+// writeInput copies a test's input to a file without holding it in memory.
+func writeInput(ctx context.Context, path string, test types.TestCase) error {
+	input, err := testdata.OpenInput(ctx, test)
+	if err != nil {
+		return fmt.Errorf("failed to open test input: %v", err)
+	}
+	defer input.Close()
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to write checker file: %v", err)
+	}
+	if _, err := io.Copy(f, input); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write checker file: %v", err)
+	}
+	return f.Close()
+}
+
+// ParseSubmission extracts the submission from a Momento webhook body. The
+// submit lambda publishes bytes, which arrive base64 encoded in `binary`;
+// string publishes arrive in `text`.
+func ParseSubmission(body string) (*types.Submission, error) {
+	var payload struct {
+		Text   string `json:"text"`
+		Binary string `json:"binary"`
+	}
+	if err := json.Unmarshal([]byte(body), &payload); err != nil {
+		return nil, fmt.Errorf("invalid payload: %v", err)
+	}
+
+	data := []byte(payload.Text)
+	if payload.Binary != "" {
+		decoded, err := base64.StdEncoding.DecodeString(payload.Binary)
+		if err != nil {
+			return nil, fmt.Errorf("invalid binary data: %v", err)
+		}
+		data = decoded
+	}
+
+	var submission types.Submission
+	if err := json.Unmarshal(data, &submission); err != nil {
+		return nil, fmt.Errorf("invalid submission: %v", err)
+	}
+
+	return &submission, nil
+}
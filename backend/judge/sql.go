@@ -0,0 +1,249 @@
+package judge
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"learncode/backend/testdata"
+	"learncode/backend/types"
+
+	_ "modernc.org/sqlite"
+)
+
+// judgeSQL runs a SQL query against every test of a SQL problem. Each test
+// gets a fresh in-memory SQLite database holding the problem's schema and
+// the test's seed data.
+func judgeSQL(ctx context.Context, problem *types.Problem, query string, progress Progress) (*Result, error) {
+	query = strings.TrimSpace(query)
+	query = strings.TrimSpace(strings.TrimSuffix(query, ";"))
+
+	timeLimit := time.Duration(problem.TimeLimit()) * time.Millisecond
+	result := &Result{Verdict: types.VerdictAccepted}
+
+	for i, test := range problem.TestCases() {
+		name := test.Name
+		if name == "" {
+			name = fmt.Sprintf("%d", i+1)
+		}
+
+		testResult, output, err := runSQLTest(ctx, problem, query, test, timeLimit)
+		if err != nil {
+			return nil, err
+		}
+		testResult.Name = name
+		result.Output = output
+		if progress != nil {
+			progress(i+1, testResult)
+		}
+
+		result.Tests = append(result.Tests, testResult)
+		if testResult.Verdict != types.VerdictAccepted {
+			result.Verdict = testResult.Verdict
+			break
+		}
+	}
+
+	return result, nil
+}
+
+func runSQLTest(ctx context.Context, problem *types.Problem, query string, test types.TestCase, timeLimit time.Duration) (TestResult, string, error) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		return TestResult{}, "", fmt.Errorf("failed to open database: %v", err)
+	}
+	defer db.Close()
+	// Every connection to :memory: is a separate database
+	db.SetMaxOpenConns(1)
+
+	seed, err := testdata.ReadInput(ctx, test)
+	if err != nil {
+		return TestResult{}, "", err
+	}
+	if _, err := db.ExecContext(ctx, problem.SQL.Schema); err != nil {
+		return TestResult{}, "", configErrorf("failed to create schema: %v", err)
+	}
+	if strings.TrimSpace(seed) != "" {
+		if _, err := db.ExecContext(ctx, seed); err != nil {
+			return TestResult{}, "", configErrorf("failed to load seed data: %v", err)
+		}
+	}
+
+	queryCtx, cancel := context.WithTimeout(ctx, timeLimit)
+	defer cancel()
+
+	start := time.Now()
+	stmt, err := db.PrepareContext(queryCtx, query)
+	if err != nil {
+		return TestResult{Verdict: types.VerdictCompileError}, fmt.Sprintf("invalid query: %v", err), nil
+	}
+	defer stmt.Close()
+
+	columns, rows, err := queryRows(queryCtx, stmt)
+	testResult := TestResult{Verdict: types.VerdictAccepted, TimeMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		if queryCtx.Err() == context.DeadlineExceeded {
+			testResult.Verdict = types.VerdictTimeLimitExceeded
+			return testResult, "execution timed out", nil
+		}
+		testResult.Verdict = types.VerdictRuntimeError
+		return testResult, fmt.Sprintf("execution failed: %v", err), nil
+	}
+	actual := formatResultSet(columns, rows)
+
+	expected, err := testdata.ReadOutput(ctx, test)
+	if err != nil {
+		return TestResult{}, "", fmt.Errorf("failed to read expected output: %v", err)
+	}
+	expectedColumns, expectedRows := parseResultSet(expected)
+
+	if feedback := compareResultSets(columns, rows, expectedColumns, expectedRows, problem.SQL.Ordered); feedback != "" {
+		testResult.Verdict = types.VerdictWrongAnswer
+		return testResult, fmt.Sprintf("%s\nExpected:\n%s\nGot:\n%s", feedback, strings.TrimSpace(expected), actual), nil
+	}
+	return testResult, actual, nil
+}
+
+func queryRows(ctx context.Context, stmt *sql.Stmt) ([]string, [][]string, error) {
+	rows, err := stmt.QueryContext(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var result [][]string
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, nil, err
+		}
+
+		row := make([]string, len(columns))
+		for i, value := range values {
+			row[i] = formatCell(value)
+		}
+		result = append(result, row)
+	}
+	return columns, result, rows.Err()
+}
+
+func formatCell(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "NULL"
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []byte:
+		return string(v)
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	case time.Time:
+		return v.Format("2006-01-02 15:04:05")
+	}
+	return fmt.Sprint(value)
+}
+
+func formatResultSet(columns []string, rows [][]string) string {
+	lines := []string{strings.Join(columns, "\t")}
+	for _, row := range rows {
+		lines = append(lines, strings.Join(row, "\t"))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func parseResultSet(text string) ([]string, [][]string) {
+	lines := strings.Split(strings.TrimSpace(strings.ReplaceAll(text, "\r\n", "\n")), "\n")
+	columns := strings.Split(lines[0], "\t")
+
+	var rows [][]string
+	for _, line := range lines[1:] {
+		rows = append(rows, strings.Split(line, "\t"))
+	}
+	return columns, rows
+}
+
+// compareResultSets returns what is wrong with the actual result set, or an
+// empty string if it matches. Column names are compared case-insensitively
+// and numbers with a tolerance; rows are sorted first unless ordered.
+func compareResultSets(columns []string, rows [][]string, expectedColumns []string, expectedRows [][]string, ordered bool) string {
+	if len(columns) != len(expectedColumns) {
+		return fmt.Sprintf("expected %d columns, got %d", len(expectedColumns), len(columns))
+	}
+	for i := range columns {
+		if !strings.EqualFold(strings.TrimSpace(columns[i]), strings.TrimSpace(expectedColumns[i])) {
+			return fmt.Sprintf("column %d should be named %s, got %s", i+1, expectedColumns[i], columns[i])
+		}
+	}
+	if len(rows) != len(expectedRows) {
+		return fmt.Sprintf("expected %d rows, got %d", len(expectedRows), len(rows))
+	}
+
+	if !ordered {
+		rows = sortedRows(rows)
+		expectedRows = sortedRows(expectedRows)
+	}
+	for i := range rows {
+		if len(rows[i]) != len(expectedRows[i]) {
+			return fmt.Sprintf("row %d has %d values, expected %d", i+1, len(rows[i]), len(expectedRows[i]))
+		}
+		for j := range rows[i] {
+			if !sameCell(rows[i][j], expectedRows[i][j]) {
+				return fmt.Sprintf("row %d differs in column %s", i+1, expectedColumns[j])
+			}
+		}
+	}
+	return ""
+}
+
+// sortedRows sorts rows for order-insensitive comparison. Numbers are
+// normalized first so that 2 and 2.0 sort the same way.
+func sortedRows(rows [][]string) [][]string {
+	key := func(row []string) string {
+		normalized := make([]string, len(row))
+		for i, cell := range row {
+			normalized[i] = strings.TrimSpace(cell)
+			if f, err := strconv.ParseFloat(normalized[i], 64); err == nil {
+				normalized[i] = strconv.FormatFloat(f, 'g', 10, 64)
+			}
+		}
+		return strings.Join(normalized, "\t")
+	}
+
+	sorted := append([][]string{}, rows...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return key(sorted[i]) < key(sorted[j])
+	})
+	return sorted
+}
+
+func sameCell(actual string, expected string) bool {
+	actual, expected = strings.TrimSpace(actual), strings.TrimSpace(expected)
+	if actual == expected {
+		return true
+	}
+	a, err1 := strconv.ParseFloat(actual, 64)
+	e, err2 := strconv.ParseFloat(expected, 64)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	return math.Abs(a-e) <= floatTolerance*math.Max(1, math.Abs(e))
+}
@@ -0,0 +1,109 @@
+package judge
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"learncode/backend/types"
+)
+
+func TestCompareResultSets(t *testing.T) {
+	columns := []string{"name", "total"}
+	tests := []struct {
+		name     string
+		columns  []string
+		rows     [][]string
+		expected [][]string
+		ordered  bool
+		want     string // Prefix of the feedback, empty when the sets match
+	}{
+		{"same order", columns, [][]string{{"a", "1"}, {"b", "2"}}, [][]string{{"a", "1"}, {"b", "2"}}, true, ""},
+		{"unordered in any order", columns, [][]string{{"b", "2"}, {"a", "1"}}, [][]string{{"a", "1"}, {"b", "2"}}, false, ""},
+		{"ordered out of order", columns, [][]string{{"b", "2"}, {"a", "1"}}, [][]string{{"a", "1"}, {"b", "2"}}, true, "row 1 differs in column name"},
+		{"unordered duplicates", columns, [][]string{{"a", "1"}, {"a", "1"}, {"b", "2"}}, [][]string{{"a", "1"}, {"b", "2"}, {"b", "2"}}, false, "row 2 differs"},
+		{"float tolerance", columns, [][]string{{"a", "0.30000000000000004"}}, [][]string{{"a", "0.3"}}, true, ""},
+		{"integer as float", columns, [][]string{{"a", "2.0"}}, [][]string{{"a", "2"}}, true, ""},
+		{"float out of tolerance", columns, [][]string{{"a", "2.01"}}, [][]string{{"a", "2"}}, true, "row 1 differs in column total"},
+		// 10 and 9 sort as text in the wrong order, so numbers are normalized
+		// the same way on both sides before sorting
+		{"unordered normalized numbers", columns, [][]string{{"a", "10.0"}, {"a", "9"}}, [][]string{{"a", "9.0"}, {"a", "10"}}, false, ""},
+		{"null", columns, [][]string{{"a", "NULL"}}, [][]string{{"a", "NULL"}}, true, ""},
+		{"null is not zero", columns, [][]string{{"a", "NULL"}}, [][]string{{"a", "0"}}, true, "row 1 differs in column total"},
+		{"surrounding space", columns, [][]string{{"a ", " 1"}}, [][]string{{"a", "1"}}, true, ""},
+		{"column case", []string{"NAME", "Total"}, [][]string{{"a", "1"}}, [][]string{{"a", "1"}}, true, ""},
+		{"column name", []string{"name", "sum"}, [][]string{{"a", "1"}}, [][]string{{"a", "1"}}, true, "column 2 should be named total"},
+		{"column count", []string{"name"}, [][]string{{"a"}}, [][]string{{"a", "1"}}, true, "expected 2 columns, got 1"},
+		{"row count", columns, [][]string{{"a", "1"}}, [][]string{{"a", "1"}, {"b", "2"}}, false, "expected 2 rows, got 1"},
+		{"no rows", columns, nil, nil, false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := compareResultSets(tt.columns, tt.rows, columns, tt.expected, tt.ordered)
+			if tt.want == "" && got != "" || !strings.HasPrefix(got, tt.want) {
+				t.Errorf("compareResultSets() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatCell(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  string
+	}{
+		{nil, "NULL"},
+		{int64(-3), "-3"},
+		{2.5, "2.5"},
+		{float64(2), "2"},
+		{1e21, "1000000000000000000000"},
+		{[]byte("text"), "text"},
+		{true, "1"},
+		{false, "0"},
+		{time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC), "2024-05-06 07:08:09"},
+		{"text", "text"},
+	}
+	for _, tt := range tests {
+		if got := formatCell(tt.value); got != tt.want {
+			t.Errorf("formatCell(%#v) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestJudgeSQL(t *testing.T) {
+	problem := &types.Problem{
+		SQL: &types.SQLSpec{Schema: "CREATE TABLE orders (customer TEXT, amount REAL);"},
+		Tests: []types.TestCase{{
+			Input: "INSERT INTO orders VALUES ('ann', 1.5), ('bob', NULL), ('ann', 2);",
+			Output: "customer\ttotal\n" +
+				"bob\tNULL\n" +
+				"ann\t3.5\n",
+		}},
+	}
+	query := "SELECT customer, SUM(amount) AS total FROM orders GROUP BY customer ORDER BY customer;"
+
+	tests := []struct {
+		name    string
+		ordered bool
+		query   string
+		verdict string
+	}{
+		{"unordered", false, query, types.VerdictAccepted},
+		{"ordered", true, query, types.VerdictWrongAnswer},
+		{"ordered descending", true, strings.Replace(query, "ORDER BY customer", "ORDER BY customer DESC", 1), types.VerdictAccepted},
+		{"null as zero", false, strings.Replace(query, "SUM(amount)", "TOTAL(amount)", 1), types.VerdictWrongAnswer},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problem.SQL.Ordered = tt.ordered
+			result, err := judgeSQL(context.Background(), problem, tt.query, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Verdict != tt.verdict {
+				t.Errorf("verdict = %s, want %s\n%s", result.Verdict, tt.verdict, result.Output)
+			}
+		})
+	}
+}
@@ -0,0 +1,277 @@
+package judge
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"learncode/backend/testdata"
+	"learncode/backend/types"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// ValidationRequest asks a runner to check one reference solution or input
+// validator against a problem that has not been saved yet.
+type ValidationRequest struct {
+	Problem   *types.Problem           `json:"problem"`
+	Solution  *types.ReferenceSolution `json:"solution,omitempty"`
+	Validator *types.Program           `json:"validator,omitempty"`
+}
+
+type ValidationResult struct {
+	Verdict      string   `json:"verdict,omitempty"` // Verdict of the reference solution
+	Output       string   `json:"output,omitempty"`
+	InvalidTests []string `json:"invalid_tests,omitempty"` // Tests rejected by the input validator
+}
+
+// Runner routes that accept validation requests, by language.
+var validationRoutes = map[string]string{
+	"python": "/runners/python/validate",
+	"nodejs": "/runners/nodejs/validate",
+	"cpp":    "/runners/cpp/validate",
+	"java":   "/runners/java/validate",
+	"sql":    "/runners/sql/validate",
+}
+
+// Input validators accept a test by exiting with 0, or with 42 as in the
+// ICPC problem package format.
+const icpcValidatorSuccess = 42
+
+const validationTimeout = 25 * time.Second
+
+// Verify runs every reference solution and input validator of the problem
+// on the runners. It returns one message per failed check: a test rejected
+// by a validator, an accepted solution that doesn't pass, or a wrong
+// solution that does.
+func Verify(ctx context.Context, problem *types.Problem) ([]string, error) {
+	if len(problem.ReferenceSolutions) == 0 && len(problem.InputValidators) == 0 {
+		return nil, nil
+	}
+
+	baseURL := strings.TrimSuffix(os.Getenv("RUNNERS_API_URL"), "/")
+	if baseURL == "" {
+		return nil, fmt.Errorf("RUNNERS_API_URL not set")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, validationTimeout)
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		failures []string
+		firstErr error
+	)
+	record := func(failure string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		if failure != "" {
+			failures = append(failures, failure)
+		}
+	}
+
+	for i := range problem.InputValidators {
+		validator := &problem.InputValidators[i]
+		if _, ok := validationRoutes[validator.Language]; !ok {
+			record(fmt.Sprintf("input validator %d: no runner can validate %s programs", i+1, validator.Language), nil)
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := requestValidation(ctx, baseURL, validator.Language, &ValidationRequest{Problem: problem, Validator: validator})
+			if err != nil {
+				record("", fmt.Errorf("input validator %d: %v", i+1, err))
+				return
+			}
+			for _, test := range result.InvalidTests {
+				record(fmt.Sprintf("test %s rejected by input validator %d", test, i+1), nil)
+			}
+		}()
+	}
+
+	for i := range problem.ReferenceSolutions {
+		solution := &problem.ReferenceSolutions[i]
+		if _, ok := validationRoutes[solution.Language]; !ok {
+			record(fmt.Sprintf("solution %s: no runner can validate %s programs", solution.Name, solution.Language), nil)
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := requestValidation(ctx, baseURL, solution.Language, &ValidationRequest{Problem: problem, Solution: solution})
+			if err != nil {
+				record("", fmt.Errorf("solution %s: %v", solution.Name, err))
+				return
+			}
+
+			accepted := result.Verdict == types.VerdictAccepted
+			switch {
+			case solution.ExpectedVerdict == types.VerdictAccepted && !accepted:
+				record(fmt.Sprintf("correct solution %s got %s: %s", solution.Name, result.Verdict, result.Output), nil)
+			case solution.ExpectedVerdict != types.VerdictAccepted && accepted:
+				record(fmt.Sprintf("solution %s was expected to get %s but was accepted", solution.Name, solution.ExpectedVerdict), nil)
+			}
+		}()
+	}
+
+	wg.Wait()
+	return failures, firstErr
+}
+
+func requestValidation(ctx context.Context, baseURL string, language string, req *ValidationRequest) (*ValidationResult, error) {
+	route := validationRoutes[language]
+
+	body, err := json.Marshal(map[string]interface{}{"validation": req})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal validation request: %v", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", baseURL+route, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("X-Runner-Secret", os.Getenv("RUNNER_SECRET"))
+
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("runner request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read runner response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("runner returned %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var result ValidationResult
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("invalid runner response: %v", err)
+	}
+	return &result, nil
+}
+
+// HandleValidation serves a validation request sent by Verify. It reports
+// false when the request is an ordinary submission webhook instead, which
+// the runner then handles as usual.
+func HandleValidation(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, bool) {
+	var payload struct {
+		Validation *ValidationRequest `json:"validation"`
+	}
+	if err := json.Unmarshal([]byte(event.Body), &payload); err != nil || payload.Validation == nil {
+		return events.APIGatewayProxyResponse{}, false
+	}
+
+	secret := event.Headers["X-Runner-Secret"]
+	if secret == "" {
+		secret = event.Headers["x-runner-secret"]
+	}
+	// Without a configured secret anyone could run code through the runner
+	expected := os.Getenv("RUNNER_SECRET")
+	if expected == "" {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 503,
+			Body:       `{"error": "Runner secret is not configured"}`,
+		}, true
+	}
+	if subtle.ConstantTimeCompare([]byte(secret), []byte(expected)) != 1 {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 401,
+			Body:       `{"error": "Invalid runner secret"}`,
+		}, true
+	}
+
+	req := payload.Validation
+	if req.Problem == nil || (req.Solution == nil) == (req.Validator == nil) {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 400,
+			Body:       `{"error": "Validation request needs a problem and exactly one of solution or validator"}`,
+		}, true
+	}
+
+	var result *ValidationResult
+	var err error
+	if req.Solution != nil {
+		var judged *Result
+		judged, err = Judge(ctx, req.Problem, req.Solution.Language, req.Solution.Code)
+		if err == nil {
+			result = &ValidationResult{Verdict: judged.Verdict, Output: judged.Output}
+		}
+	} else {
+		result, err = validateInputs(ctx, req.Problem, req.Validator)
+	}
+	if err != nil {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 500,
+			Body:       fmt.Sprintf(`{"error": %q}`, err.Error()),
+		}, true
+	}
+
+	body, err := json.Marshal(result)
+	if err != nil {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 500,
+			Body:       fmt.Sprintf(`{"error": "Failed to marshal response: %v"}`, err),
+		}, true
+	}
+
+	return events.APIGatewayProxyResponse{
+		StatusCode: 200,
+		Headers: map[string]string{
+			"Content-Type": "application/json",
+		},
+		Body: string(body),
+	}, true
+}
+
+// validateInputs runs an input validator on every test input.
+func validateInputs(ctx context.Context, problem *types.Problem, validator *types.Program) (*ValidationResult, error) {
+	program, err := Prepare(ctx, validator.Language, validator.Code)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare validator: %v", err)
+	}
+	defer program.Close()
+
+	result := &ValidationResult{}
+	for i, test := range problem.TestCases() {
+		name := test.Name
+		if name == "" {
+			name = fmt.Sprintf("%d", i+1)
+		}
+
+		input, err := testdata.OpenInput(ctx, test)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open test input: %v", err)
+		}
+		run, err := program.Exec(ctx, input, compileTimeout)
+		input.Close()
+		if err != nil {
+			return nil, err
+		}
+		if run.Verdict == types.VerdictTimeLimitExceeded {
+			return nil, fmt.Errorf("validator timed out on test %s", name)
+		}
+
+		if run.Verdict != types.VerdictAccepted && run.ExitCode != icpcValidatorSuccess {
+			result.InvalidTests = append(result.InvalidTests, name)
+		}
+	}
+
+	return result, nil
+}
@@ -3,7 +3,11 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"learncode/backend/db"
+	"learncode/backend/judge"
+	"learncode/backend/testdata"
 	"learncode/backend/types"
 	"learncode/backend/utils"
 	"os"
@@ -20,6 +24,9 @@ import (
 	"github.com/google/uuid"
 )
 
+// maxIDAttempts bounds how many problem IDs are drawn before giving up.
+const maxIDAttempts = 3
+
 var dynamoClient *dynamodb.Client
 
 func init() {
@@ -31,13 +38,26 @@ func init() {
 }
 
 type CreateProblemRequest struct {
-	Title         string `json:"title"`
-	Description   string `json:"description"`
-	Difficulty    string `json:"difficulty"`
-	Input         string `json:"input"`
-	Output        string `json:"output"`
-	ExampleInput  string `json:"example_input"`
-	ExampleOutput string `json:"example_output"`
+	Title              string                    `json:"title"`
+	Description        string                    `json:"description"`
+	Difficulty         string                    `json:"difficulty"`
+	Input              string                    `json:"input"`
+	Output             string                    `json:"output"`
+	ExampleInput       string                    `json:"example_input"`
+	ExampleOutput      string                    `json:"example_output"`
+	ReferenceSolutions []types.ReferenceSolution `json:"reference_solutions"`
+	InputValidators    []types.Program           `json:"input_validators"`
+	StarterCode        map[string]string         `json:"starter_code"`
+	AllowedLanguages   []string                  `json:"allowed_languages"`
+	Signature          *types.Signature          `json:"signature"`
+	Interactor         *types.Program            `json:"interactor"`
+	QueryLimit         int                       `json:"query_limit"`
+	SQL                *types.SQLSpec            `json:"sql"`
+	Hints              []string                  `json:"hints"`
+	Editorial          *types.Editorial          `json:"editorial"`
+	EditorialAttempts  int                       `json:"editorial_attempts"`
+	Rating             int                       `json:"rating"`
+	Locale             string                    `json:"locale"`
 }
 
 func handleRequest(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
@@ -112,52 +132,84 @@ func handleRequest(ctx context.Context, event events.APIGatewayProxyRequest) (ev
 		}, nil
 	}
 
-	// Validate required fields
-	if req.Title == "" || req.Description == "" || req.Difficulty == "" ||
-		req.Input == "" || req.Output == "" || req.ExampleInput == "" || req.ExampleOutput == "" {
+	// Create problem
+	now := time.Now().Unix()
+	problem := &types.Problem{
+		ID:                 newProblemID(),
+		Title:              req.Title,
+		Description:        req.Description,
+		Difficulty:         req.Difficulty,
+		Rating:             req.Rating,
+		RatingLocked:       req.Rating > 0,
+		Locale:             req.Locale,
+		Input:              req.Input,
+		Output:             req.Output,
+		ExampleInput:       req.ExampleInput,
+		ExampleOutput:      req.ExampleOutput,
+		ReferenceSolutions: req.ReferenceSolutions,
+		InputValidators:    req.InputValidators,
+		StarterCode:        req.StarterCode,
+		AllowedLanguages:   req.AllowedLanguages,
+		Signature:          req.Signature,
+		Interactor:         req.Interactor,
+		QueryLimit:         req.QueryLimit,
+		SQL:                req.SQL,
+		Hints:              req.Hints,
+		Editorial:          req.Editorial,
+		EditorialAttempts:  req.EditorialAttempts,
+		Status:             types.ProblemDraft,
+		CreatedAt:          now,
+		UpdatedAt:          now,
+	}
+
+	// Validate required fields and difficulty
+	if err := problem.Validate(); err != nil {
 		return events.APIGatewayProxyResponse{
 			StatusCode: 400,
-			Body:       `{"error": "All fields are required"}`,
+			Body:       fmt.Sprintf(`{"error": %q}`, err.Error()),
 		}, nil
 	}
 
-	// Validate difficulty
-	if req.Difficulty != "Easy" && req.Difficulty != "Medium" && req.Difficulty != "Hard" {
+	// Move large test data out of the item
+	if err := testdata.OffloadDefault(ctx, problem); err != nil {
 		return events.APIGatewayProxyResponse{
-			StatusCode: 400,
-			Body:       `{"error": "Difficulty must be Easy, Medium, or Hard"}`,
+			StatusCode: 500,
+			Body:       fmt.Sprintf(`{"error": "Failed to store test data: %v"}`, err),
 		}, nil
 	}
 
-	// Create problem
-	now := time.Now().Unix()
-	problem := &types.Problem{
-		ID:            fmt.Sprintf("prob-%s", uuid.New().String()[:8]),
-		Title:         req.Title,
-		Description:   req.Description,
-		Difficulty:    req.Difficulty,
-		Input:         req.Input,
-		Output:        req.Output,
-		ExampleInput:  req.ExampleInput,
-		ExampleOutput: req.ExampleOutput,
-		CreatedAt:     now,
-		UpdatedAt:     now,
-	}
-
-	// Save to DynamoDB
-	item, err := attributevalue.MarshalMap(problem)
+	// Run reference solutions and input validators before publishing
+	failures, err := judge.Verify(ctx, problem)
 	if err != nil {
 		return events.APIGatewayProxyResponse{
 			StatusCode: 500,
-			Body:       fmt.Sprintf(`{"error": "Failed to marshal problem: %v"}`, err),
+			Body:       fmt.Sprintf(`{"error": "Failed to validate problem: %v"}`, err),
+		}, nil
+	}
+	if len(failures) > 0 {
+		body, _ := json.Marshal(map[string]interface{}{
+			"error":    "Problem failed validation",
+			"failures": failures,
+		})
+		return events.APIGatewayProxyResponse{
+			StatusCode: 422,
+			Headers: map[string]string{
+				"Content-Type": "application/json",
+			},
+			Body: string(body),
 		}, nil
 	}
 
-	_, err = dynamoClient.PutItem(ctx, &dynamodb.PutItemInput{
-		TableName: aws.String(os.Getenv("PROBLEMS_TABLE")),
-		Item:      item,
-	})
-
+	// Save to DynamoDB with its slug. IDs are short, so on the rare
+	// collision a new one is drawn instead of overwriting the existing
+	// problem.
+	for attempt := 1; ; attempt++ {
+		err = db.CreateProblem(ctx, problem)
+		if !errors.Is(err, db.ErrProblemExists) || attempt == maxIDAttempts {
+			break
+		}
+		problem.ID = newProblemID()
+	}
 	if err != nil {
 		return events.APIGatewayProxyResponse{
 			StatusCode: 500,
@@ -188,6 +240,10 @@ func handleRequest(ctx context.Context, event events.APIGatewayProxyRequest) (ev
 	}, nil
 }
 
+func newProblemID() string {
+	return fmt.Sprintf("prob-%s", uuid.New().String()[:8])
+}
+
 func main() {
 	lambda.Start(handleRequest)
 }
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"learncode/backend/db"
+	"learncode/backend/types"
+	"learncode/backend/utils"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/google/uuid"
+)
+
+type CreateCollectionRequest struct {
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	ProblemIDs  []string `json:"problem_ids"`
+	Published   bool     `json:"published"`
+}
+
+func handleRequest(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	user, errResponse := utils.AuthenticateInstructor(ctx, event.Headers)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	// Parse request body
+	var req CreateCollectionRequest
+	if err := json.Unmarshal([]byte(event.Body), &req); err != nil {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 400,
+			Body:       fmt.Sprintf(`{"error": "Invalid request body: %v"}`, err),
+		}, nil
+	}
+
+	now := time.Now().Unix()
+	collection := &types.Collection{
+		ID:          fmt.Sprintf("coll-%s", uuid.New().String()[:8]),
+		Title:       req.Title,
+		Description: req.Description,
+		ProblemIDs:  req.ProblemIDs,
+		OwnerID:     user.ID,
+		Published:   req.Published,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	if err := collection.Validate(); err != nil {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 400,
+			Body:       fmt.Sprintf(`{"error": %q}`, err.Error()),
+		}, nil
+	}
+
+	// Every problem in the collection has to exist
+	problems, err := db.GetProblemsByID(ctx, collection.ProblemIDs)
+	if err != nil {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 500,
+			Body:       fmt.Sprintf(`{"error": "Failed to get problems: %v"}`, err),
+		}, nil
+	}
+	for _, id := range collection.ProblemIDs {
+		if problems[id] == nil {
+			return events.APIGatewayProxyResponse{
+				StatusCode: 400,
+				Body:       fmt.Sprintf(`{"error": "Problem not found: %s"}`, id),
+			}, nil
+		}
+	}
+
+	if err := db.CreateCollection(ctx, collection); err != nil {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 500,
+			Body:       fmt.Sprintf(`{"error": "Failed to save collection: %v"}`, err),
+		}, nil
+	}
+
+	responseBody, err := json.Marshal(map[string]interface{}{
+		"message":    "Collection created successfully",
+		"collection": collection,
+	})
+	if err != nil {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 500,
+			Body:       fmt.Sprintf(`{"error": "Failed to marshal response: %v"}`, err),
+		}, nil
+	}
+
+	return events.APIGatewayProxyResponse{
+		StatusCode: 201,
+		Headers: map[string]string{
+			"Content-Type": "application/json",
+		},
+		Body: string(responseBody),
+	}, nil
+}
+
+func main() {
+	lambda.Start(handleRequest)
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"learncode/backend/db"
+	"learncode/backend/utils"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+func handleRequest(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	user, errResponse := utils.AuthenticateInstructor(ctx, event.Headers)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	// Get collection ID from path parameters
+	collectionID := event.PathParameters["id"]
+	if collectionID == "" {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 400,
+			Body:       `{"error": "Collection ID is required"}`,
+		}, nil
+	}
+
+	collection, err := db.GetCollection(ctx, collectionID)
+	if err != nil {
+		if errors.Is(err, db.ErrCollectionNotFound) {
+			return events.APIGatewayProxyResponse{
+				StatusCode: 404,
+				Body:       fmt.Sprintf(`{"error": "Collection not found: %s"}`, collectionID),
+			}, nil
+		}
+		return events.APIGatewayProxyResponse{
+			StatusCode: 500,
+			Body:       fmt.Sprintf(`{"error": "Failed to get collection: %v"}`, err),
+		}, nil
+	}
+
+	if !collection.CanEdit(user) {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 403,
+			Body:       `{"error": "Only the collection's owner or an admin can delete it"}`,
+		}, nil
+	}
+
+	if err := db.DeleteCollection(ctx, collectionID); err != nil {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 500,
+			Body:       fmt.Sprintf(`{"error": "Failed to delete collection: %v"}`, err),
+		}, nil
+	}
+
+	return events.APIGatewayProxyResponse{
+		StatusCode: 200,
+		Headers: map[string]string{
+			"Content-Type": "application/json",
+		},
+		Body: `{"message": "Collection deleted successfully"}`,
+	}, nil
+}
+
+func main() {
+	lambda.Start(handleRequest)
+}
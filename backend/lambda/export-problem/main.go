@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"learncode/backend/db"
+	"learncode/backend/problempkg"
+	"learncode/backend/testdata"
+	"learncode/backend/utils"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+func handleRequest(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	if _, errResponse := utils.AuthenticateAdmin(ctx, event.Headers); errResponse != nil {
+		return *errResponse, nil
+	}
+
+	// Get problem ID from path parameters
+	problemID := event.PathParameters["id"]
+	if problemID == "" {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 400,
+			Body:       `{"error": "Problem ID is required"}`,
+		}, nil
+	}
+
+	problem, err := db.GetProblem(ctx, problemID)
+	if err != nil {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 404,
+			Body:       fmt.Sprintf(`{"error": "Problem not found: %v"}`, err),
+		}, nil
+	}
+
+	if err := testdata.Inline(ctx, problem); err != nil {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 500,
+			Body:       fmt.Sprintf(`{"error": "Failed to load test data: %v"}`, err),
+		}, nil
+	}
+
+	var archive bytes.Buffer
+	if err := problempkg.WriteZip(&archive, problem); err != nil {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 500,
+			Body:       fmt.Sprintf(`{"error": "Failed to export problem: %v"}`, err),
+		}, nil
+	}
+
+	return events.APIGatewayProxyResponse{
+		StatusCode: 200,
+		Headers: map[string]string{
+			"Content-Type":        "application/zip",
+			"Content-Disposition": fmt.Sprintf(`attachment; filename="%s.zip"`, problem.ID),
+		},
+		Body:            base64.StdEncoding.EncodeToString(archive.Bytes()),
+		IsBase64Encoded: true,
+	}, nil
+}
+
+func main() {
+	lambda.Start(handleRequest)
+}
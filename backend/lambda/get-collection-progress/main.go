@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"learncode/backend/db"
+	"learncode/backend/types"
+	"learncode/backend/utils"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// ProblemProgress is the caller's progress on one problem of a study plan.
+type ProblemProgress struct {
+	Problem  *types.Problem `json:"problem"`
+	Solved   bool           `json:"solved"`
+	Attempts int            `json:"attempts"` // Judged SUBMIT submissions
+}
+
+func handleRequest(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	user, errResponse := utils.AuthenticateUser(ctx, event.Headers)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	// Get collection ID from path parameters
+	collectionID := event.PathParameters["id"]
+	if collectionID == "" {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 400,
+			Body:       `{"error": "Collection ID is required"}`,
+		}, nil
+	}
+
+	collection, err := db.GetCollection(ctx, collectionID)
+	if err != nil && !errors.Is(err, db.ErrCollectionNotFound) {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 500,
+			Body:       fmt.Sprintf(`{"error": "Failed to get collection: %v"}`, err),
+		}, nil
+	}
+	if err != nil || !collection.CanView(user) {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 404,
+			Body:       fmt.Sprintf(`{"error": "Collection not found: %s"}`, collectionID),
+		}, nil
+	}
+
+	problems, err := db.GetProblemsByID(ctx, collection.ProblemIDs)
+	if err != nil {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 500,
+			Body:       fmt.Sprintf(`{"error": "Failed to get problems: %v"}`, err),
+		}, nil
+	}
+
+	// Progress comes from the caller's SUBMIT submissions to each problem
+	// still in the plan that they can open
+	visible := []string{}
+	progress := []ProblemProgress{}
+	solved := 0
+	for _, id := range collection.ProblemIDs {
+		problem := problems[id]
+		if problem == nil {
+			continue
+		}
+		canView, err := utils.CanViewProblem(ctx, user.ID, problem)
+		if err != nil {
+			return events.APIGatewayProxyResponse{
+				StatusCode: 500,
+				Body:       fmt.Sprintf(`{"error": "Failed to get user: %v"}`, err),
+			}, nil
+		}
+		if !canView {
+			continue
+		}
+		visible = append(visible, id)
+
+		submissions, err := db.GetSubmissionsByProblemAndType(ctx, "SUBMISSION#", id, "SUBMIT", user.ID)
+		if err != nil {
+			return events.APIGatewayProxyResponse{
+				StatusCode: 500,
+				Body:       fmt.Sprintf(`{"error": "Failed to get submissions: %v"}`, err),
+			}, nil
+		}
+
+		entry := ProblemProgress{Problem: problem.Public()}
+		for _, submission := range submissions {
+			if submission.Finished() {
+				entry.Attempts++
+			}
+			if submission.Accepted() {
+				entry.Solved = true
+			}
+		}
+		if entry.Solved {
+			solved++
+		}
+		progress = append(progress, entry)
+	}
+
+	// Learners don't get the IDs of problems they can't open
+	collection.ProblemIDs = visible
+
+	responseBody, err := json.Marshal(map[string]interface{}{
+		"collection": collection,
+		"problems":   progress,
+		"solved":     solved,
+		"total":      len(progress),
+	})
+	if err != nil {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 500,
+			Body:       fmt.Sprintf(`{"error": "Failed to marshal response: %v"}`, err),
+		}, nil
+	}
+
+	return events.APIGatewayProxyResponse{
+		StatusCode: 200,
+		Headers: map[string]string{
+			"Content-Type": "application/json",
+		},
+		Body: string(responseBody),
+	}, nil
+}
+
+func main() {
+	lambda.Start(handleRequest)
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"learncode/backend/db"
+	"learncode/backend/types"
+	"learncode/backend/utils"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+func handleRequest(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	user, errResponse := utils.AuthenticateUser(ctx, event.Headers)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	// Get collection ID from path parameters
+	collectionID := event.PathParameters["id"]
+	if collectionID == "" {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 400,
+			Body:       `{"error": "Collection ID is required"}`,
+		}, nil
+	}
+
+	collection, err := db.GetCollection(ctx, collectionID)
+	if err != nil && !errors.Is(err, db.ErrCollectionNotFound) {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 500,
+			Body:       fmt.Sprintf(`{"error": "Failed to get collection: %v"}`, err),
+		}, nil
+	}
+	if err != nil || !collection.CanView(user) {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 404,
+			Body:       fmt.Sprintf(`{"error": "Collection not found: %s"}`, collectionID),
+		}, nil
+	}
+
+	problems, err := db.GetProblemsByID(ctx, collection.ProblemIDs)
+	if err != nil {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 500,
+			Body:       fmt.Sprintf(`{"error": "Failed to get problems: %v"}`, err),
+		}, nil
+	}
+
+	// Problems in study order, leaving out deleted ones and, for learners,
+	// unpublished ones
+	visible := []string{}
+	listed := []*types.Problem{}
+	for _, id := range collection.ProblemIDs {
+		problem := problems[id]
+		if problem == nil {
+			continue
+		}
+		canView, err := utils.CanViewProblem(ctx, user.ID, problem)
+		if err != nil {
+			return events.APIGatewayProxyResponse{
+				StatusCode: 500,
+				Body:       fmt.Sprintf(`{"error": "Failed to get user: %v"}`, err),
+			}, nil
+		}
+		if !canView {
+			continue
+		}
+		visible = append(visible, id)
+		listed = append(listed, problem.Public())
+	}
+
+	// Nor are their IDs listed
+	collection.ProblemIDs = visible
+
+	responseBody, err := json.Marshal(map[string]interface{}{
+		"collection": collection,
+		"problems":   listed,
+	})
+	if err != nil {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 500,
+			Body:       fmt.Sprintf(`{"error": "Failed to marshal response: %v"}`, err),
+		}, nil
+	}
+
+	return events.APIGatewayProxyResponse{
+		StatusCode: 200,
+		Headers: map[string]string{
+			"Content-Type": "application/json",
+		},
+		Body: string(responseBody),
+	}, nil
+}
+
+func main() {
+	lambda.Start(handleRequest)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"learncode/backend/db"
+	"learncode/backend/types"
+	"learncode/backend/utils"
+	"sort"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+func handleRequest(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	user, errResponse := utils.AuthenticateUser(ctx, event.Headers)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	collections, err := db.GetCollections(ctx)
+	if err != nil {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 500,
+			Body:       fmt.Sprintf(`{"error": "Failed to fetch collections: %v"}`, err),
+		}, nil
+	}
+
+	// Learners see published study plans; owners and admins also drafts
+	listed := []types.Collection{}
+	for _, collection := range collections {
+		if collection.CanView(user) {
+			listed = append(listed, collection)
+		}
+	}
+	sort.SliceStable(listed, func(i, j int) bool {
+		return listed[i].CreatedAt < listed[j].CreatedAt
+	})
+
+	responseBody, err := json.Marshal(map[string]interface{}{
+		"collections": listed,
+	})
+	if err != nil {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 500,
+			Body:       fmt.Sprintf(`{"error": "Failed to marshal response: %v"}`, err),
+		}, nil
+	}
+
+	return events.APIGatewayProxyResponse{
+		StatusCode: 200,
+		Headers: map[string]string{
+			"Content-Type": "application/json",
+		},
+		Body: string(responseBody),
+	}, nil
+}
+
+func main() {
+	lambda.Start(handleRequest)
+}
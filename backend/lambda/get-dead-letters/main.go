@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"learncode/backend/db"
+	"learncode/backend/types"
+	"learncode/backend/utils"
+	"sort"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+func handleRequest(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	if _, errResponse := utils.AuthenticateAdmin(ctx, event.Headers); errResponse != nil {
+		return *errResponse, nil
+	}
+
+	deadLetters, err := db.GetDeadLetters(ctx)
+	if err != nil {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 500,
+			Body:       fmt.Sprintf(`{"error": "Failed to get dead letters: %v"}`, err),
+		}, nil
+	}
+
+	// Optional filters
+	query := event.QueryStringParameters
+	filtered := []types.DeadLetter{}
+	for _, deadLetter := range deadLetters {
+		if query["problem_id"] != "" && deadLetter.ProblemID != query["problem_id"] {
+			continue
+		}
+		if query["language"] != "" && deadLetter.Language != query["language"] {
+			continue
+		}
+		if query["replayed"] == "false" && deadLetter.ReplayedAt != 0 {
+			continue
+		}
+		filtered = append(filtered, deadLetter)
+	}
+
+	// Most recent failures first
+	sort.Slice(filtered, func(i, j int) bool {
+		return filtered[i].FailedAt > filtered[j].FailedAt
+	})
+
+	responseBody, err := json.Marshal(map[string]interface{}{
+		"dead_letters": filtered,
+	})
+	if err != nil {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 500,
+			Body:       fmt.Sprintf(`{"error": "Failed to marshal response: %v"}`, err),
+		}, nil
+	}
+
+	return events.APIGatewayProxyResponse{
+		StatusCode: 200,
+		Headers: map[string]string{
+			"Content-Type": "application/json",
+		},
+		Body: string(responseBody),
+	}, nil
+}
+
+func main() {
+	lambda.Start(handleRequest)
+}
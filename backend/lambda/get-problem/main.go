@@ -5,15 +5,21 @@ import (
 	"encoding/json"
 	"fmt"
 	"learncode/backend/utils"
+	"net/url"
+	"path"
 	"strings"
 
 	"learncode/backend/db"
+	"learncode/backend/judge"
+	"learncode/backend/types"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 )
 
-func handleRequest(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+// handleRequest takes the HTTP API's version 2.0 payload, whose raw path the
+// slug redirect is built from.
+func handleRequest(ctx context.Context, event events.APIGatewayV2HTTPRequest) (events.APIGatewayProxyResponse, error) {
 	// Check authorization
 	authToken := event.Headers["Authorization"]
 	if authToken == "" {
@@ -38,7 +44,7 @@ func handleRequest(ctx context.Context, event events.APIGatewayProxyRequest) (ev
 	token := parts[1]
 
 	// Verify token with GitHub
-	_, err := utils.GetGithubUser(token)
+	githubUser, err := utils.GetGithubUser(token)
 	if err != nil {
 		return events.APIGatewayProxyResponse{
 			StatusCode: 401,
@@ -55,8 +61,8 @@ func handleRequest(ctx context.Context, event events.APIGatewayProxyRequest) (ev
 		}, nil
 	}
 
-	// Get problem from database
-	problem, err := db.GetProblem(ctx, problemID)
+	// Get problem from database; the path holds either its ID or a slug
+	problem, err := db.GetProblemByIDOrSlug(ctx, problemID)
 	if err != nil {
 		return events.APIGatewayProxyResponse{
 			StatusCode: 404,
@@ -64,9 +70,101 @@ func handleRequest(ctx context.Context, event events.APIGatewayProxyRequest) (ev
 		}, nil
 	}
 
+	// Unpublished problems are only shown to admins
+	visible, err := utils.CanViewProblem(ctx, githubUser.ID, problem)
+	if err != nil {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 500,
+			Body:       fmt.Sprintf(`{"error": "Failed to get user: %v"}`, err),
+		}, nil
+	}
+	if !visible {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 404,
+			Body:       fmt.Sprintf(`{"error": "Problem not found: %s"}`, problemID),
+		}, nil
+	}
+
+	// Old slugs of renamed problems redirect to the current one
+	if problemID != problem.ID && problemID != problem.Slug {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 301,
+			Headers: map[string]string{
+				"Content-Type": "application/json",
+				"Location":     path.Dir(event.RawPath) + "/" + url.PathEscape(problem.Slug),
+			},
+			Body: fmt.Sprintf(`{"slug": %q, "problem_id": %q}`, problem.Slug, problem.ID),
+		}, nil
+	}
+	problemID = problem.ID
+
+	// Show the statement in the caller's language if it has been translated
+	locale := problem.MatchLocale(utils.RequestedLocales(event.QueryStringParameters, event.Headers)...)
+	public := problem.Localized(locale).Public()
+
+	// Function-signature problems get generated starter code for every
+	// language the setter didn't write it for
+	judge.AddStubs(public)
+
+	stats, err := db.GetProblemStats(ctx, problemID)
+	if err != nil {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 500,
+			Body:       fmt.Sprintf(`{"error": "Failed to get problem stats: %v"}`, err),
+		}, nil
+	}
+
 	// Create response with both problem and user
 	response := map[string]interface{}{
-		"problem": problem,
+		"problem": public,
+		"stats":   stats,
+		"locales": problem.Locales(),
+	}
+
+	if len(problem.Hints) > 0 {
+		user, err := db.GetUser(ctx, githubUser.ID)
+		if err != nil {
+			return events.APIGatewayProxyResponse{
+				StatusCode: 500,
+				Body:       fmt.Sprintf(`{"error": "Failed to get user: %v"}`, err),
+			}, nil
+		}
+		revealed := 0
+		if user != nil {
+			revealed = user.RevealedHints[problemID]
+		}
+		if revealed > len(problem.Hints) {
+			revealed = len(problem.Hints)
+		}
+
+		// Hints are revealed one at a time, in order
+		reveal := event.QueryStringParameters["reveal_hint"]
+		if (reveal == "true" || reveal == "1") && revealed < len(problem.Hints) {
+			revealed++
+			if err := db.SetRevealedHints(ctx, githubUser.ID, problemID, revealed); err != nil {
+				return events.APIGatewayProxyResponse{
+					StatusCode: 500,
+					Body:       fmt.Sprintf(`{"error": "Failed to reveal hint: %v"}`, err),
+				}, nil
+			}
+		}
+
+		response["hints"] = problem.Hints[:revealed]
+		response["hint_count"] = len(problem.Hints)
+	}
+
+	if problem.Editorial != nil {
+		unlocked, err := editorialUnlocked(ctx, problem, githubUser.ID)
+		if err != nil {
+			return events.APIGatewayProxyResponse{
+				StatusCode: 500,
+				Body:       fmt.Sprintf(`{"error": "Failed to get submissions: %v"}`, err),
+			}, nil
+		}
+		response["editorial_unlocked"] = unlocked
+		if unlocked {
+			response["editorial"] = problem.Editorial
+		}
 	}
 
 	responseBody, err := json.Marshal(response)
@@ -86,6 +184,26 @@ func handleRequest(ctx context.Context, event events.APIGatewayProxyRequest) (ev
 	}, nil
 }
 
+// editorialUnlocked reports whether the user has an accepted submission for
+// the problem, or at least the problem's number of failed ones.
+func editorialUnlocked(ctx context.Context, problem *types.Problem, userID string) (bool, error) {
+	submissions, err := db.GetSubmissionsByProblemAndType(ctx, "SUBMISSION#", problem.ID, "SUBMIT", userID)
+	if err != nil {
+		return false, err
+	}
+
+	failed := 0
+	for _, submission := range submissions {
+		if submission.Accepted() {
+			return true, nil
+		}
+		if submission.Finished() {
+			failed++
+		}
+	}
+	return problem.EditorialAttempts > 0 && failed >= problem.EditorialAttempts, nil
+}
+
 func main() {
 	lambda.Start(handleRequest)
 }
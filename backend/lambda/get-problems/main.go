@@ -5,9 +5,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"learncode/backend/utils"
+	"sort"
 	"strings"
+	"time"
 
 	"learncode/backend/db"
+	"learncode/backend/types"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
@@ -38,7 +41,7 @@ func handleRequest(ctx context.Context, event events.APIGatewayProxyRequest) (ev
 	token := parts[1]
 
 	// Verify token with GitHub
-	_, err := utils.GetGithubUser(token)
+	githubUser, err := utils.GetGithubUser(token)
 	if err != nil {
 		return events.APIGatewayProxyResponse{
 			StatusCode: 401,
@@ -54,11 +57,95 @@ func handleRequest(ctx context.Context, event events.APIGatewayProxyRequest) (ev
 		}, nil
 	}
 
-	// Create response with both problems and user
-	response := map[string]interface{}{
-		"problems": problems,
+	// Admins can list problems in any status for review; learners only see
+	// published ones, stripped of hidden tests and solutions
+	status := event.QueryStringParameters["status"]
+	if status != "" {
+		user, err := db.GetUser(ctx, githubUser.ID)
+		if err != nil {
+			return events.APIGatewayProxyResponse{
+				StatusCode: 500,
+				Body:       fmt.Sprintf(`{"error": "Failed to get user: %v"}`, err),
+			}, nil
+		}
+		if user == nil || !user.IsAdmin {
+			return events.APIGatewayProxyResponse{
+				StatusCode: 403,
+				Body:       `{"error": "Unauthorized: Admin access required"}`,
+			}, nil
+		}
+	}
+
+	// Statements are shown in the caller's language where translated
+	locales := utils.RequestedLocales(event.QueryStringParameters, event.Headers)
+	now := time.Now().Unix()
+	listed := problems[:0]
+	for _, problem := range problems {
+		if status != "" {
+			if problem.LifecycleStatus() == status {
+				listed = append(listed, problem)
+			}
+		} else if problem.IsVisible(now) {
+			listed = append(listed, *problem.Localized(problem.MatchLocale(locales...)).Public())
+		}
+	}
+	problems = listed
+
+	// Unrated problems are listed with the rating their difficulty implies
+	for i := range problems {
+		problems[i].Rating = problems[i].EffectiveRating()
 	}
 
+	// Sort by difficulty rating, or with sort=match by how close problems
+	// are to the learner's own rating so the best matched come first
+	response := map[string]interface{}{}
+	sort.SliceStable(problems, func(i, j int) bool {
+		return problems[i].Rating < problems[j].Rating
+	})
+	if event.QueryStringParameters["sort"] == "match" {
+		user, err := db.GetUser(ctx, githubUser.ID)
+		if err != nil {
+			return events.APIGatewayProxyResponse{
+				StatusCode: 500,
+				Body:       fmt.Sprintf(`{"error": "Failed to get user: %v"}`, err),
+			}, nil
+		}
+		userRating := types.DefaultRating
+		if user != nil {
+			userRating = user.EffectiveRating()
+		}
+		distance := func(p types.Problem) int {
+			if p.Rating > userRating {
+				return p.Rating - userRating
+			}
+			return userRating - p.Rating
+		}
+		sort.SliceStable(problems, func(i, j int) bool {
+			return distance(problems[i]) < distance(problems[j])
+		})
+		response["user_rating"] = userRating
+	}
+
+	stats, err := db.GetAllProblemStats(ctx)
+	if err != nil {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 500,
+			Body:       fmt.Sprintf(`{"error": "Failed to fetch problem stats: %v"}`, err),
+		}, nil
+	}
+
+	// Only report stats for the listed problems
+	listedStats := map[string]*types.ProblemStats{}
+	for _, problem := range problems {
+		if s, ok := stats[problem.ID]; ok {
+			listedStats[problem.ID] = s
+		}
+	}
+
+	// Create response with both problems and user
+	response["problems"] = problems
+	response["stats"] = listedStats
+
 	responseBody, err := json.Marshal(response)
 	if err != nil {
 		return events.APIGatewayProxyResponse{
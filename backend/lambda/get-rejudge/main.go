@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"learncode/backend/db"
+	"learncode/backend/types"
+	"learncode/backend/utils"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+func handleRequest(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	if _, errResponse := utils.AuthenticateAdmin(ctx, event.Headers); errResponse != nil {
+		return *errResponse, nil
+	}
+
+	// Get rejudge ID from path parameters; URLs may leave out the prefix
+	rejudgeID := event.PathParameters["id"]
+	if rejudgeID == "" {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 400,
+			Body:       `{"error": "Rejudge ID is required"}`,
+		}, nil
+	}
+	if !strings.HasPrefix(rejudgeID, types.RejudgeIDPrefix) {
+		rejudgeID = types.RejudgeIDPrefix + rejudgeID
+	}
+
+	rejudge, err := db.GetRejudge(ctx, rejudgeID)
+	if err != nil {
+		if errors.Is(err, db.ErrRejudgeNotFound) {
+			return events.APIGatewayProxyResponse{
+				StatusCode: 404,
+				Body:       fmt.Sprintf(`{"error": "Rejudge not found: %s"}`, rejudgeID),
+			}, nil
+		}
+		return events.APIGatewayProxyResponse{
+			StatusCode: 500,
+			Body:       fmt.Sprintf(`{"error": "Failed to get rejudge: %v"}`, err),
+		}, nil
+	}
+
+	submissions, err := db.GetRejudgeSubmissions(ctx, rejudge, false, 0)
+	if err != nil {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 500,
+			Body:       fmt.Sprintf(`{"error": "Failed to get submissions: %v"}`, err),
+		}, nil
+	}
+
+	responseBody, err := json.Marshal(rejudge.Summarize(submissions))
+	if err != nil {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 500,
+			Body:       fmt.Sprintf(`{"error": "Failed to marshal response: %v"}`, err),
+		}, nil
+	}
+
+	return events.APIGatewayProxyResponse{
+		StatusCode: 200,
+		Headers: map[string]string{
+			"Content-Type": "application/json",
+		},
+		Body: string(responseBody),
+	}, nil
+}
+
+func main() {
+	lambda.Start(handleRequest)
+}
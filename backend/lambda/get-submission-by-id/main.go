@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"learncode/backend/db"
+	"learncode/backend/types"
+	"learncode/backend/utils"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+func handleRequest(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	user, errResponse := utils.AuthenticateUser(ctx, event.Headers)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	// Get submission ID from path parameters
+	submissionID := event.PathParameters["id"]
+	if submissionID == "" {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 400,
+			Body:       `{"error": "Submission ID is required"}`,
+		}, nil
+	}
+
+	submission, err := db.GetSubmission(ctx, types.FullSubmissionID(submissionID))
+	if err != nil {
+		if errors.Is(err, db.ErrSubmissionNotFound) {
+			return events.APIGatewayProxyResponse{
+				StatusCode: 404,
+				Body:       fmt.Sprintf(`{"error": "Submission not found: %s"}`, submissionID),
+			}, nil
+		}
+		return events.APIGatewayProxyResponse{
+			StatusCode: 500,
+			Body:       fmt.Sprintf(`{"error": "Failed to get submission: %v"}`, err),
+		}, nil
+	}
+
+	// Other users only see public submissions, and only the redacted view;
+	// private ones look the same as missing ones
+	redacted := !submission.CanView(user)
+	if redacted {
+		if !submission.Public {
+			return events.APIGatewayProxyResponse{
+				StatusCode: 404,
+				Body:       fmt.Sprintf(`{"error": "Submission not found: %s"}`, submissionID),
+			}, nil
+		}
+		submission = submission.Redacted()
+	}
+
+	responseBody, err := json.Marshal(map[string]interface{}{
+		"submission": submission,
+		"redacted":   redacted,
+	})
+	if err != nil {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 500,
+			Body:       fmt.Sprintf(`{"error": "Failed to marshal response: %v"}`, err),
+		}, nil
+	}
+
+	return events.APIGatewayProxyResponse{
+		StatusCode: 200,
+		Headers: map[string]string{
+			"Content-Type": "application/json",
+		},
+		Body: string(responseBody),
+	}, nil
+}
+
+func main() {
+	lambda.Start(handleRequest)
+}
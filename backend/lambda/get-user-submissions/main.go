@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"learncode/backend/db"
+	"learncode/backend/utils"
+	"strconv"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+const (
+	defaultLimit = 20
+	maxLimit     = 100
+)
+
+func handleRequest(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	user, errResponse := utils.AuthenticateUser(ctx, event.Headers)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	query := event.QueryStringParameters
+	filter := db.SubmissionFilter{
+		ProblemID: query["problem_id"],
+		Language:  query["language"],
+		Verdict:   query["verdict"],
+		Type:      query["type"],
+	}
+	if filter.Type != "" && filter.Type != "RUN" && filter.Type != "SUBMIT" {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 400,
+			Body:       `{"error": "type must be RUN or SUBMIT"}`,
+		}, nil
+	}
+
+	// Date range as Unix timestamps
+	var err error
+	if filter.From, err = timestampParam(query, "from"); err != nil {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 400,
+			Body:       fmt.Sprintf(`{"error": %q}`, err.Error()),
+		}, nil
+	}
+	if filter.To, err = timestampParam(query, "to"); err != nil {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 400,
+			Body:       fmt.Sprintf(`{"error": %q}`, err.Error()),
+		}, nil
+	}
+	if filter.From > 0 && filter.To > 0 && filter.From > filter.To {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 400,
+			Body:       `{"error": "from must not be after to"}`,
+		}, nil
+	}
+
+	limit := defaultLimit
+	if value := query["limit"]; value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxLimit {
+			return events.APIGatewayProxyResponse{
+				StatusCode: 400,
+				Body:       fmt.Sprintf(`{"error": "limit must be between 1 and %d"}`, maxLimit),
+			}, nil
+		}
+	}
+
+	submissions, next, err := db.GetUserSubmissions(ctx, user.ID, filter, limit, query["cursor"])
+	if err != nil {
+		if errors.Is(err, db.ErrInvalidCursor) {
+			return events.APIGatewayProxyResponse{
+				StatusCode: 400,
+				Body:       `{"error": "Invalid cursor"}`,
+			}, nil
+		}
+		return events.APIGatewayProxyResponse{
+			StatusCode: 500,
+			Body:       fmt.Sprintf(`{"error": "Failed to get submissions: %v"}`, err),
+		}, nil
+	}
+
+	response := map[string]interface{}{
+		"submissions": submissions,
+	}
+	if next != "" {
+		response["next_cursor"] = next
+	}
+
+	responseBody, err := json.Marshal(response)
+	if err != nil {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 500,
+			Body:       fmt.Sprintf(`{"error": "Failed to marshal response: %v"}`, err),
+		}, nil
+	}
+
+	return events.APIGatewayProxyResponse{
+		StatusCode: 200,
+		Headers: map[string]string{
+			"Content-Type": "application/json",
+		},
+		Body: string(responseBody),
+	}, nil
+}
+
+// timestampParam parses an optional Unix timestamp query parameter.
+func timestampParam(query map[string]string, name string) (int64, error) {
+	value := query[name]
+	if value == "" {
+		return 0, nil
+	}
+	timestamp, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || timestamp <= 0 {
+		return 0, fmt.Errorf("%s must be a Unix timestamp", name)
+	}
+	return timestamp, nil
+}
+
+func main() {
+	lambda.Start(handleRequest)
+}
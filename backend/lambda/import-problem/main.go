@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"learncode/backend/db"
+	"learncode/backend/judge"
+	"learncode/backend/problempkg"
+	"learncode/backend/testdata"
+	"learncode/backend/types"
+	"learncode/backend/utils"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/google/uuid"
+)
+
+func handleRequest(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	if _, errResponse := utils.AuthenticateAdmin(ctx, event.Headers); errResponse != nil {
+		return *errResponse, nil
+	}
+
+	// API Gateway base64 encodes binary request bodies
+	body := []byte(event.Body)
+	if event.IsBase64Encoded {
+		decoded, err := base64.StdEncoding.DecodeString(event.Body)
+		if err != nil {
+			return events.APIGatewayProxyResponse{
+				StatusCode: 400,
+				Body:       fmt.Sprintf(`{"error": "Invalid request body: %v"}`, err),
+			}, nil
+		}
+		body = decoded
+	}
+
+	problem, err := problempkg.ReadZip(body)
+	if err != nil {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 400,
+			Body:       fmt.Sprintf(`{"error": "Invalid problem package: %v"}`, err),
+		}, nil
+	}
+
+	if err := problem.Validate(); err != nil {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 400,
+			Body:       fmt.Sprintf(`{"error": %q}`, err.Error()),
+		}, nil
+	}
+
+	now := time.Now().Unix()
+	problem.CreatedAt = now
+	problem.UpdatedAt = now
+	problem.Status = types.ProblemDraft
+
+	var existing *types.Problem
+	if problem.ID == "" {
+		problem.ID = fmt.Sprintf("prob-%s", uuid.New().String()[:8])
+	} else if existing, err = db.GetProblem(ctx, problem.ID); err == nil {
+		// Re-importing a package replaces the problem but keeps its history
+		problem.CreatedAt = existing.CreatedAt
+		problem.Status = existing.Status
+		problem.PublishAt = existing.PublishAt
+		problem.Reviews = existing.Reviews
+		problem.Slug = existing.Slug
+		// Translations added through the API survive a package without any
+		if problem.Translations == nil {
+			problem.Translations = existing.Translations
+		}
+		if problem.Rating == 0 {
+			problem.Rating = existing.Rating
+			problem.RatingLocked = existing.RatingLocked
+		}
+	}
+
+	// Move large test data out of the item
+	if err := testdata.OffloadDefault(ctx, problem); err != nil {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 500,
+			Body:       fmt.Sprintf(`{"error": "Failed to store test data: %v"}`, err),
+		}, nil
+	}
+
+	// Run reference solutions and input validators before publishing
+	failures, err := judge.Verify(ctx, problem)
+	if err != nil {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 500,
+			Body:       fmt.Sprintf(`{"error": "Failed to validate problem: %v"}`, err),
+		}, nil
+	}
+	if len(failures) > 0 {
+		body, _ := json.Marshal(map[string]interface{}{
+			"error":    "Problem failed validation",
+			"failures": failures,
+		})
+		return events.APIGatewayProxyResponse{
+			StatusCode: 422,
+			Headers: map[string]string{
+				"Content-Type": "application/json",
+			},
+			Body: string(body),
+		}, nil
+	}
+
+	// A renamed problem gets a new slug; the old one redirects to it
+	if existing == nil {
+		err = db.CreateProblem(ctx, problem)
+	} else {
+		err = db.UpdateProblem(ctx, problem, existing.UpdatedAt, existing.Rating)
+	}
+	if errors.Is(err, db.ErrProblemExists) || errors.Is(err, db.ErrProblemChanged) {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 409,
+			Body:       `{"error": "Problem changed while importing, try again"}`,
+		}, nil
+	}
+	if err != nil {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 500,
+			Body:       fmt.Sprintf(`{"error": "Failed to save problem: %v"}`, err),
+		}, nil
+	}
+
+	responseBody, err := json.Marshal(map[string]interface{}{
+		"message": "Problem imported successfully",
+		"problem": problem,
+	})
+	if err != nil {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 500,
+			Body:       fmt.Sprintf(`{"error": "Failed to marshal response: %v"}`, err),
+		}, nil
+	}
+
+	return events.APIGatewayProxyResponse{
+		StatusCode: 201,
+		Headers: map[string]string{
+			"Content-Type": "application/json",
+		},
+		Body: string(responseBody),
+	}, nil
+}
+
+func main() {
+	lambda.Start(handleRequest)
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"learncode/backend/db"
+	"learncode/backend/notify"
+	"learncode/backend/types"
+	"learncode/backend/utils"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// Defaults for STUCK_SUBMISSION_TIMEOUT and MAX_SUBMISSION_RETRIES. The
+// runners time out after 30 seconds, so a submission untouched for five
+// minutes isn't going to be judged.
+const (
+	defaultStuckTimeout = 5 * time.Minute
+	defaultMaxRetries   = 2
+)
+
+// handleRequest runs on a schedule. It republishes submissions stuck in
+// pending or running, and marks them system_error once they have been
+// retried MAX_SUBMISSION_RETRIES times.
+func handleRequest(ctx context.Context) error {
+	timeout := defaultStuckTimeout
+	if value := os.Getenv("STUCK_SUBMISSION_TIMEOUT"); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d < time.Minute {
+			return fmt.Errorf("invalid STUCK_SUBMISSION_TIMEOUT: %q", value)
+		}
+		timeout = d
+	}
+	maxRetries := defaultMaxRetries
+	if value := os.Getenv("MAX_SUBMISSION_RETRIES"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid MAX_SUBMISSION_RETRIES: %q", value)
+		}
+		maxRetries = n
+	}
+
+	now := time.Now()
+	submissions, err := db.GetStuckSubmissions(ctx, now.Add(-timeout).Unix())
+	if err != nil {
+		return err
+	}
+
+	retried, failed := 0, 0
+	for i := range submissions {
+		submission := &submissions[i]
+
+		if submission.Retries >= maxRetries {
+			reason := fmt.Sprintf("Not judged after %d attempts: the %s runner crashed, timed out or never received the submission", submission.Retries+1, submission.Language)
+			if err := db.FailSubmission(ctx, submission, reason, now.Unix()); err != nil {
+				if errors.Is(err, db.ErrSubmissionChanged) {
+					continue
+				}
+				return err
+			}
+			notify.Verdict(ctx, submission, types.StatusSystemError, "")
+			failed++
+			continue
+		}
+
+		if err := db.RetrySubmission(ctx, submission, now.Unix()); err != nil {
+			if errors.Is(err, db.ErrSubmissionChanged) {
+				continue
+			}
+			return err
+		}
+		// The runner needs the code, which the scan leaves out. The next
+		// run retries it again if publishing failed.
+		full, err := db.GetSubmissionByKey(ctx, submission.ProblemID, submission.SubmissionID)
+		if err != nil {
+			fmt.Printf("Failed to get %s to republish: %v\n", submission.SubmissionID, err)
+			continue
+		}
+		if err := utils.PublishToMomento(ctx, *full); err != nil {
+			fmt.Printf("Failed to republish %s: %v\n", submission.SubmissionID, err)
+			continue
+		}
+		notify.Status(ctx, submission, types.StatusPending)
+		retried++
+	}
+
+	fmt.Printf("Retried %d stuck submissions, gave up on %d\n", retried, failed)
+	return nil
+}
+
+func main() {
+	lambda.Start(handleRequest)
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"learncode/backend/db"
+	"learncode/backend/types"
+	"learncode/backend/utils"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/google/uuid"
+)
+
+// RejudgeRequest picks the submissions to rejudge. Empty fields match every
+// submission.
+type RejudgeRequest struct {
+	Verdict  string `json:"verdict"`
+	Language string `json:"language"`
+	From     int64  `json:"from"` // Unix timestamp, inclusive
+	To       int64  `json:"to"`   // Unix timestamp, inclusive
+}
+
+func handleRequest(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	admin, errResponse := utils.AuthenticateAdmin(ctx, event.Headers)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	// Get problem ID from path parameters
+	problemID := event.PathParameters["id"]
+	if problemID == "" {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 400,
+			Body:       `{"error": "Problem ID is required"}`,
+		}, nil
+	}
+
+	// Parse request body; an empty body rejudges everything
+	var req RejudgeRequest
+	if event.Body != "" {
+		if err := json.Unmarshal([]byte(event.Body), &req); err != nil {
+			return events.APIGatewayProxyResponse{
+				StatusCode: 400,
+				Body:       fmt.Sprintf(`{"error": "Invalid request body: %v"}`, err),
+			}, nil
+		}
+	}
+	if req.From < 0 || req.To < 0 || (req.From > 0 && req.To > 0 && req.From > req.To) {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 400,
+			Body:       `{"error": "from and to must be Unix timestamps with from not after to"}`,
+		}, nil
+	}
+
+	if _, err := db.GetProblem(ctx, problemID); err != nil {
+		if errors.Is(err, db.ErrProblemNotFound) {
+			return events.APIGatewayProxyResponse{
+				StatusCode: 404,
+				Body:       fmt.Sprintf(`{"error": "Problem not found: %s"}`, problemID),
+			}, nil
+		}
+		return events.APIGatewayProxyResponse{
+			StatusCode: 500,
+			Body:       fmt.Sprintf(`{"error": "Failed to get problem: %v"}`, err),
+		}, nil
+	}
+
+	// Only submissions count towards verdicts; test runs aren't rejudged
+	submissions, err := db.GetProblemSubmissions(ctx, problemID, db.SubmissionFilter{
+		Language: req.Language,
+		Verdict:  req.Verdict,
+		Type:     types.SubmissionSubmit,
+		From:     req.From,
+		To:       req.To,
+	})
+	if err != nil {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 500,
+			Body:       fmt.Sprintf(`{"error": "Failed to get submissions: %v"}`, err),
+		}, nil
+	}
+
+	rejudge := &types.Rejudge{
+		ID:          types.RejudgeIDPrefix + uuid.New().String(),
+		ProblemID:   problemID,
+		Verdict:     req.Verdict,
+		Language:    req.Language,
+		From:        req.From,
+		To:          req.To,
+		RequestedBy: admin.ID,
+		CreatedAt:   time.Now().Unix(),
+	}
+
+	// Saved before anything is queued, so every queued submission belongs
+	// to a rejudge the worker knows about even if queueing fails partway
+	if err := db.SaveRejudge(ctx, rejudge); err != nil {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 500,
+			Body:       fmt.Sprintf(`{"error": "Failed to save rejudge: %v"}`, err),
+		}, nil
+	}
+
+	// Submissions still being judged, queued by another rejudge or ended in
+	// a system error are skipped
+	skipped := 0
+	for i := range submissions {
+		submission := &submissions[i]
+		if !submission.Rejudgeable() {
+			skipped++
+			continue
+		}
+		if err := db.QueueRejudge(ctx, submission, rejudge, submission.JudgedVerdict()); err != nil {
+			if errors.Is(err, db.ErrSubmissionChanged) {
+				skipped++
+				continue
+			}
+			return events.APIGatewayProxyResponse{
+				StatusCode: 500,
+				Body:       fmt.Sprintf(`{"error": "Failed to queue submission: %v"}`, err),
+			}, nil
+		}
+	}
+
+	// The rejudge worker republishes the queued submissions
+	if err := db.MarkRejudgeQueued(ctx, rejudge, time.Now().Unix()); err != nil {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 500,
+			Body:       fmt.Sprintf(`{"error": "Failed to save rejudge: %v"}`, err),
+		}, nil
+	}
+
+	responseBody, err := json.Marshal(map[string]interface{}{
+		"message": "Rejudge queued successfully",
+		"rejudge": rejudge,
+		"skipped": skipped,
+	})
+	if err != nil {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 500,
+			Body:       fmt.Sprintf(`{"error": "Failed to marshal response: %v"}`, err),
+		}, nil
+	}
+
+	return events.APIGatewayProxyResponse{
+		StatusCode: 202,
+		Headers: map[string]string{
+			"Content-Type": "application/json",
+		},
+		Body: string(responseBody),
+	}, nil
+}
+
+func main() {
+	lambda.Start(handleRequest)
+}
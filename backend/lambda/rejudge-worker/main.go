@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"learncode/backend/db"
+	"learncode/backend/notify"
+	"learncode/backend/types"
+	"learncode/backend/utils"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// Submissions republished per run unless REJUDGE_BATCH_SIZE says otherwise.
+// Keeping batches small leaves the runners free for new submissions.
+const defaultBatchSize = 20
+
+// handleRequest runs on a schedule. It republishes the next batch of
+// queued rejudge submissions to the runners, oldest rejudge first.
+func handleRequest(ctx context.Context) error {
+	batchSize := defaultBatchSize
+	if value := os.Getenv("REJUDGE_BATCH_SIZE"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return fmt.Errorf("invalid REJUDGE_BATCH_SIZE: %q", value)
+		}
+		batchSize = n
+	}
+
+	rejudges, err := db.GetUnpublishedRejudges(ctx)
+	if err != nil {
+		return err
+	}
+	sort.Slice(rejudges, func(i, j int) bool {
+		return rejudges[i].CreatedAt < rejudges[j].CreatedAt
+	})
+
+	published := 0
+	for i := range rejudges {
+		rejudge := &rejudges[i]
+		if published == batchSize {
+			break
+		}
+
+		// Ask for one more than fits so that a rejudge whose last
+		// submissions go out in this batch is marked published now,
+		// unless more may still be queued
+		submissions, err := db.GetRejudgeSubmissions(ctx, rejudge, true, batchSize-published+1)
+		if err != nil {
+			return err
+		}
+		fits := len(submissions) <= batchSize-published
+		if !fits {
+			submissions = submissions[:batchSize-published]
+		}
+		done := fits && rejudge.DoneQueueing(time.Now().Unix())
+
+		for j := range submissions {
+			submission := &submissions[j]
+			if err := db.StartRejudge(ctx, submission, time.Now().Unix()); err != nil {
+				if errors.Is(err, db.ErrSubmissionChanged) {
+					continue
+				}
+				return err
+			}
+			if err := utils.PublishToMomento(ctx, *submission); err != nil {
+				if requeueErr := db.RequeueRejudge(ctx, submission); requeueErr != nil {
+					fmt.Printf("Failed to requeue %s: %v\n", submission.SubmissionID, requeueErr)
+				}
+				return err
+			}
+			notify.Status(ctx, submission, types.StatusPending)
+			published++
+		}
+
+		if done {
+			if err := db.MarkRejudgePublished(ctx, rejudge, time.Now().Unix()); err != nil {
+				return err
+			}
+			fmt.Printf("Rejudge %s fully republished\n", rejudge.ID)
+		}
+	}
+
+	fmt.Printf("Republished %d submissions\n", published)
+	return nil
+}
+
+func main() {
+	lambda.Start(handleRequest)
+}
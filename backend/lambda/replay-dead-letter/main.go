@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"learncode/backend/db"
+	"learncode/backend/notify"
+	"learncode/backend/types"
+	"learncode/backend/utils"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+func handleRequest(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	if _, errResponse := utils.AuthenticateAdmin(ctx, event.Headers); errResponse != nil {
+		return *errResponse, nil
+	}
+
+	// Dead letters are keyed by submission ID
+	id := event.PathParameters["id"]
+	if id == "" {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 400,
+			Body:       `{"error": "Dead letter ID is required"}`,
+		}, nil
+	}
+
+	deadLetter, err := db.GetDeadLetter(ctx, types.FullSubmissionID(id))
+	if err != nil {
+		if errors.Is(err, db.ErrDeadLetterNotFound) {
+			return events.APIGatewayProxyResponse{
+				StatusCode: 404,
+				Body:       fmt.Sprintf(`{"error": "Dead letter not found: %s"}`, id),
+			}, nil
+		}
+		return events.APIGatewayProxyResponse{
+			StatusCode: 500,
+			Body:       fmt.Sprintf(`{"error": "Failed to get dead letter: %v"}`, err),
+		}, nil
+	}
+
+	// Judge the submission from scratch, as the runner received it. Only a
+	// submission still in system_error is replayed, and only once per dead
+	// letter.
+	submission := deadLetter.Submission
+	if err := db.ReplayDeadLetter(ctx, deadLetter, time.Now().Unix()); err != nil {
+		if errors.Is(err, db.ErrSubmissionChanged) || errors.Is(err, db.ErrDeadLetterReplayed) {
+			return events.APIGatewayProxyResponse{
+				StatusCode: 409,
+				Body:       fmt.Sprintf(`{"error": "Dead letter can't be replayed: %v"}`, err),
+			}, nil
+		}
+		return events.APIGatewayProxyResponse{
+			StatusCode: 500,
+			Body:       fmt.Sprintf(`{"error": "Failed to replay dead letter: %v"}`, err),
+		}, nil
+	}
+	if err := utils.PublishToMomento(ctx, *submission); err != nil {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 500,
+			Body:       fmt.Sprintf(`{"error": "Failed to publish submission: %v"}`, err),
+		}, nil
+	}
+	notify.Status(ctx, submission, types.StatusPending)
+
+	responseBody, err := json.Marshal(map[string]interface{}{
+		"message":     "Submission republished successfully",
+		"dead_letter": deadLetter,
+	})
+	if err != nil {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 500,
+			Body:       fmt.Sprintf(`{"error": "Failed to marshal response: %v"}`, err),
+		}, nil
+	}
+
+	return events.APIGatewayProxyResponse{
+		StatusCode: 202,
+		Headers: map[string]string{
+			"Content-Type": "application/json",
+		},
+		Body: string(responseBody),
+	}, nil
+}
+
+func main() {
+	lambda.Start(handleRequest)
+}
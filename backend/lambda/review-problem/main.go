@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"learncode/backend/db"
+	"learncode/backend/types"
+	"learncode/backend/utils"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+type ReviewRequest struct {
+	Comment string `json:"comment"`
+	Approve bool   `json:"approve"`
+}
+
+func handleRequest(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	reviewer, errResponse := utils.AuthenticateAdmin(ctx, event.Headers)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	// Get problem ID from path parameters
+	problemID := event.PathParameters["id"]
+	if problemID == "" {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 400,
+			Body:       `{"error": "Problem ID is required"}`,
+		}, nil
+	}
+
+	// Parse request body
+	var req ReviewRequest
+	if err := json.Unmarshal([]byte(event.Body), &req); err != nil {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 400,
+			Body:       fmt.Sprintf(`{"error": "Invalid request body: %v"}`, err),
+		}, nil
+	}
+	req.Comment = strings.TrimSpace(req.Comment)
+	if req.Comment == "" && !req.Approve {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 400,
+			Body:       `{"error": "A review needs a comment or an approval"}`,
+		}, nil
+	}
+
+	problem, err := db.GetProblem(ctx, problemID)
+	if err != nil {
+		if errors.Is(err, db.ErrProblemNotFound) {
+			return events.APIGatewayProxyResponse{
+				StatusCode: 404,
+				Body:       fmt.Sprintf(`{"error": "Problem not found: %s"}`, problemID),
+			}, nil
+		}
+		return events.APIGatewayProxyResponse{
+			StatusCode: 500,
+			Body:       fmt.Sprintf(`{"error": "Failed to get problem: %v"}`, err),
+		}, nil
+	}
+
+	if problem.LifecycleStatus() != types.ProblemInReview {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 409,
+			Body:       fmt.Sprintf(`{"error": "Only problems in review can be reviewed, this one is %s"}`, problem.LifecycleStatus()),
+		}, nil
+	}
+
+	review := types.Review{
+		Reviewer:  reviewer.Login,
+		Comment:   req.Comment,
+		Approved:  req.Approve,
+		CreatedAt: time.Now().Unix(),
+	}
+	problem.Reviews = append(problem.Reviews, review)
+
+	if err := db.SaveProblem(ctx, problem); err != nil {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 500,
+			Body:       fmt.Sprintf(`{"error": "Failed to save problem: %v"}`, err),
+		}, nil
+	}
+
+	responseBody, err := json.Marshal(map[string]interface{}{
+		"message":  "Review added successfully",
+		"review":   review,
+		"approved": problem.Approved(),
+	})
+	if err != nil {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 500,
+			Body:       fmt.Sprintf(`{"error": "Failed to marshal response: %v"}`, err),
+		}, nil
+	}
+
+	return events.APIGatewayProxyResponse{
+		StatusCode: 201,
+		Headers: map[string]string{
+			"Content-Type": "application/json",
+		},
+		Body: string(responseBody),
+	}, nil
+}
+
+func main() {
+	lambda.Start(handleRequest)
+}
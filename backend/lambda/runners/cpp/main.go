@@ -0,0 +1,11 @@
+package main
+
+import (
+	"learncode/backend/runner"
+
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+func main() {
+	lambda.Start(runner.Handler("cpp"))
+}
@@ -0,0 +1,12 @@
+package main
+
+import (
+	"learncode/backend/runner"
+	"learncode/backend/types"
+
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+func main() {
+	lambda.Start(runner.Handler(types.LanguageSQL))
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"learncode/backend/db"
+	"learncode/backend/types"
+	"learncode/backend/utils"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+type SetStatusRequest struct {
+	Status    string `json:"status"`
+	PublishAt int64  `json:"publish_at"` // Optional Unix timestamp when publishing; zero publishes right away
+}
+
+func handleRequest(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	if _, errResponse := utils.AuthenticateAdmin(ctx, event.Headers); errResponse != nil {
+		return *errResponse, nil
+	}
+
+	// Get problem ID from path parameters
+	problemID := event.PathParameters["id"]
+	if problemID == "" {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 400,
+			Body:       `{"error": "Problem ID is required"}`,
+		}, nil
+	}
+
+	// Parse request body
+	var req SetStatusRequest
+	if err := json.Unmarshal([]byte(event.Body), &req); err != nil {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 400,
+			Body:       fmt.Sprintf(`{"error": "Invalid request body: %v"}`, err),
+		}, nil
+	}
+	if req.PublishAt < 0 || (req.PublishAt > 0 && req.Status != types.ProblemPublished) {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 400,
+			Body:       `{"error": "publish_at can only be set when publishing"}`,
+		}, nil
+	}
+
+	problem, err := db.GetProblem(ctx, problemID)
+	if err != nil {
+		if errors.Is(err, db.ErrProblemNotFound) {
+			return events.APIGatewayProxyResponse{
+				StatusCode: 404,
+				Body:       fmt.Sprintf(`{"error": "Problem not found: %s"}`, problemID),
+			}, nil
+		}
+		return events.APIGatewayProxyResponse{
+			StatusCode: 500,
+			Body:       fmt.Sprintf(`{"error": "Failed to get problem: %v"}`, err),
+		}, nil
+	}
+
+	if !problem.CanTransition(req.Status) {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 409,
+			Body:       fmt.Sprintf(`{"error": "Cannot move a %s problem to %q"}`, problem.LifecycleStatus(), req.Status),
+		}, nil
+	}
+	// Rescheduling an already published problem needs no new approval
+	if req.Status == types.ProblemPublished && problem.LifecycleStatus() != types.ProblemPublished && !problem.Approved() {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 409,
+			Body:       `{"error": "Problem needs a reviewer's approval since its last edit"}`,
+		}, nil
+	}
+
+	// Status changes leave UpdatedAt alone so they don't invalidate approvals
+	problem.Status = req.Status
+	problem.PublishAt = req.PublishAt
+
+	if err := db.SaveProblem(ctx, problem); err != nil {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 500,
+			Body:       fmt.Sprintf(`{"error": "Failed to save problem: %v"}`, err),
+		}, nil
+	}
+
+	responseBody, err := json.Marshal(map[string]interface{}{
+		"message": "Problem status updated successfully",
+		"problem": problem,
+	})
+	if err != nil {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 500,
+			Body:       fmt.Sprintf(`{"error": "Failed to marshal response: %v"}`, err),
+		}, nil
+	}
+
+	return events.APIGatewayProxyResponse{
+		StatusCode: 200,
+		Headers: map[string]string{
+			"Content-Type": "application/json",
+		},
+		Body: string(responseBody),
+	}, nil
+}
+
+func main() {
+	lambda.Start(handleRequest)
+}
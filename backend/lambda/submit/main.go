@@ -2,11 +2,17 @@ package main
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"learncode/backend/db"
+	"learncode/backend/notify"
+	"learncode/backend/ratelimit"
 	"learncode/backend/types"
 	"learncode/backend/utils"
+	"strconv"
 	"strings"
 	"time"
 
@@ -15,6 +21,11 @@ import (
 	"github.com/google/uuid"
 )
 
+// Keys are kept long enough to cover client retries, not forever
+const idempotencyTTL = 24 * time.Hour
+
+const maxIdempotencyKeyLength = 255
+
 type SubmitRequest struct {
 	ProblemID string `json:"problem_id"`
 	Language  string `json:"language"`
@@ -22,7 +33,29 @@ type SubmitRequest struct {
 	Type      string `json:"type"`
 }
 
-func handleRequest(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+// Validate checks the fields that don't depend on the problem.
+func (r *SubmitRequest) Validate() types.FieldErrors {
+	fields := types.FieldErrors{}
+	if r.ProblemID == "" {
+		fields["problem_id"] = "Problem ID is required"
+	}
+	if !types.IsSupportedLanguage(r.Language) {
+		fields["language"] = fmt.Sprintf("Language must be one of: %s", strings.Join(types.Languages, ", "))
+	}
+	if r.Type != types.SubmissionRun && r.Type != types.SubmissionSubmit {
+		fields["type"] = fmt.Sprintf("Type must be %s or %s", types.SubmissionRun, types.SubmissionSubmit)
+	}
+	if strings.TrimSpace(r.Code) == "" {
+		fields["code"] = "Code is required"
+	} else if len(r.Code) > types.MaxCodeBytes {
+		fields["code"] = fmt.Sprintf("Code must be at most %d KiB", types.MaxCodeBytes>>10)
+	}
+	return fields
+}
+
+// handleRequest takes the HTTP API's version 2.0 payload for the caller's
+// source IP, which the rate limits are keyed on.
+func handleRequest(ctx context.Context, event events.APIGatewayV2HTTPRequest) (events.APIGatewayProxyResponse, error) {
 	// Parse request body
 	var req SubmitRequest
 	if err := json.Unmarshal([]byte(event.Body), &req); err != nil {
@@ -32,54 +65,144 @@ func handleRequest(ctx context.Context, event events.APIGatewayProxyRequest) (ev
 		}, nil
 	}
 
-	// Validate language
-	if !isValidLanguage(req.Language) {
-		return events.APIGatewayProxyResponse{
-			StatusCode: 400,
-			Body:       `{"error": "Invalid language. Supported languages: nodejs, cpp, java, python"}`,
-		}, nil
+	// Reject malformed submissions before they reach the table or a runner
+	if fields := req.Validate(); len(fields) > 0 {
+		return invalidSubmission(fields), nil
 	}
 
-	// Validate token
-	authToken := event.Headers["Authorization"]
-	if authToken == "" {
-		authToken = event.Headers["authorization"]
+	// Verify token; the stored user's role sets their daily quota
+	user, errResponse := utils.AuthenticateUser(ctx, event.Headers)
+	if errResponse != nil {
+		return *errResponse, nil
 	}
 
-	if authToken == "" {
+	// The problem must exist and be published. Clients may name it by slug,
+	// but the submission is stored under its ID.
+	problem, err := db.GetProblemByIDOrSlug(ctx, req.ProblemID)
+	if errors.Is(err, db.ErrProblemNotFound) {
+		return invalidSubmission(types.FieldErrors{"problem_id": "Problem not found"}), nil
+	}
+	if err != nil {
 		return events.APIGatewayProxyResponse{
-			StatusCode: 401,
-			Body:       `{"error": "No authorization token provided"}`,
+			StatusCode: 500,
+			Body:       fmt.Sprintf(`{"error": "Failed to get problem: %v"}`, err),
 		}, nil
 	}
 
-	// Clean token before verification
-	authToken = strings.TrimPrefix(authToken, "Bearer ")
-
-	// Verify token with GitHub
-	githubUser, err := utils.GetGithubUser(authToken)
+	// Unpublished problems are only shown to admins
+	visible, err := utils.CanViewProblem(ctx, user.ID, problem)
 	if err != nil {
 		return events.APIGatewayProxyResponse{
-			StatusCode: 401,
-			Body:       fmt.Sprintf(`{"error": "Failed to verify token: %v"}`, err),
+			StatusCode: 500,
+			Body:       fmt.Sprintf(`{"error": "Failed to get user: %v"}`, err),
 		}, nil
 	}
+	if !visible {
+		return invalidSubmission(types.FieldErrors{"problem_id": "Problem not found"}), nil
+	}
+
+	// Check the language against the problem's allowed languages
+	if !problem.AllowsLanguage(req.Language) {
+		return invalidSubmission(types.FieldErrors{
+			"language": fmt.Sprintf("Language %s is not allowed for this problem", req.Language),
+		}), nil
+	}
 
-	// Create submission record
+	now := time.Now()
 	submissionId := uuid.New().String()
 	submission := types.Submission{
-		SubmissionID: fmt.Sprintf("SUBMISSION#%s", submissionId),
-		UserID:    githubUser.ID,
-		ProblemID: req.ProblemID,
-		Language:  req.Language,
-		Code:      req.Code,
-		Status:    "pending",
+		SubmissionID: types.SubmissionIDPrefix + submissionId,
+		UserID:       user.ID,
+		ProblemID:    problem.ID,
+		Language:     req.Language,
+		Code:         req.Code,
+		Status:       types.StatusPending,
 
-		CreatedAt: time.Now().Unix(),
-		UpdatedAt: time.Now().Unix(),
-		Type:      req.Type,
+		CreatedAt:     now.Unix(),
+		UpdatedAt:     now.Unix(),
+		Type:          req.Type,
+		StatusHistory: []types.StatusChange{{Status: types.StatusPending, At: now.Unix()}},
 	}
 
+	// A retry carrying the same Idempotency-Key gets the submission of the
+	// first attempt instead of creating another one
+	saved := false
+	recordKey := ""
+	if key := idempotencyKey(event.Headers); key != "" {
+		if len(key) > maxIdempotencyKeyLength {
+			return events.APIGatewayProxyResponse{
+				StatusCode: 400,
+				Body:       fmt.Sprintf(`{"error": "Idempotency-Key must be at most %d characters"}`, maxIdempotencyKeyLength),
+			}, nil
+		}
+
+		record := &db.IdempotencyRecord{
+			Key:          user.ID + "#" + key,
+			RequestHash:  req.hash(),
+			ProblemID:    submission.ProblemID,
+			SubmissionID: submission.SubmissionID,
+			CreatedAt:    now.Unix(),
+			ExpiresAt:    now.Add(idempotencyTTL).Unix(),
+		}
+		existing, err := db.ClaimIdempotencyKey(ctx, record)
+		if err != nil {
+			return events.APIGatewayProxyResponse{
+				StatusCode: 500,
+				Body:       fmt.Sprintf(`{"error": "Failed to check idempotency key: %v"}`, err),
+			}, nil
+		}
+		if existing != nil {
+			return replay(ctx, existing, record.RequestHash), nil
+		}
+
+		// Free the key if this attempt fails before saving the submission so
+		// that a retry can succeed. Once it is saved the key stays, and a
+		// retry publishes the saved submission if this attempt couldn't.
+		recordKey = record.Key
+		defer func() {
+			if !saved {
+				if err := db.ReleaseIdempotencyKey(ctx, record.Key); err != nil {
+					fmt.Printf("Failed to release idempotency key: %v\n", err)
+				}
+			}
+		}()
+	}
+
+	// Every submission invokes a runner, so limit how often users can submit
+	limits, err := ratelimit.LoadConfig()
+	if err != nil {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 500,
+			Body:       fmt.Sprintf(`{"error": "Failed to load rate limits: %v"}`, err),
+		}, nil
+	}
+	ip := utils.ClientIP(event)
+	denied, err := limits.Allow(ctx, user, ip, req.Type, now)
+	if err != nil {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 500,
+			Body:       fmt.Sprintf(`{"error": "Failed to check rate limits: %v"}`, err),
+		}, nil
+	}
+	if denied != nil {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 429,
+			Headers: map[string]string{
+				"Content-Type": "application/json",
+				"Retry-After":  strconv.Itoa(denied.RetryAfterSeconds()),
+			},
+			Body: fmt.Sprintf(`{"error": %q, "retry_after": %d}`, denied.Reason, denied.RetryAfterSeconds()),
+		}, nil
+	}
+	// A submission that isn't saved never runs, so it doesn't count
+	defer func() {
+		if !saved {
+			if err := limits.Release(ctx, user, ip, req.Type, now); err != nil {
+				fmt.Printf("Failed to release rate limits: %v\n", err)
+			}
+		}
+	}()
+
 	// Save to DynamoDB
 	if err := db.SaveSubmission(ctx, &submission); err != nil {
 		return events.APIGatewayProxyResponse{
@@ -87,9 +210,10 @@ func handleRequest(ctx context.Context, event events.APIGatewayProxyRequest) (ev
 			Body:       fmt.Sprintf(`{"error": "Failed to save submission: %v"}`, err),
 		}, nil
 	}
+	saved = true
 
 	// Publish to Momento topic for processing
-	if err := utils.PublishToMomento(ctx, submission); err != nil {
+	if err := publish(ctx, &submission, recordKey); err != nil {
 		return events.APIGatewayProxyResponse{
 			StatusCode: 500,
 			Body:       fmt.Sprintf(`{"error": "Failed to publish submission: %v"}`, err),
@@ -116,14 +240,121 @@ func handleRequest(ctx context.Context, event events.APIGatewayProxyRequest) (ev
 	}, nil
 }
 
-func isValidLanguage(lang string) bool {
-	validLanguages := map[string]bool{
-		"nodejs": true,
-		"cpp":    true,
-		"java":   true,
-		"python": true,
+// idempotencyKey returns the request's Idempotency-Key header.
+func idempotencyKey(headers map[string]string) string {
+	// Check both cases since API Gateway might normalize header names
+	if key := headers["Idempotency-Key"]; key != "" {
+		return key
+	}
+	return headers["idempotency-key"]
+}
+
+// hash identifies the request's content, so that reusing a key for a
+// different request can be told apart from a retry.
+func (r *SubmitRequest) hash() string {
+	data, _ := json.Marshal(r)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// replay answers a retried request with the submission its key created.
+func replay(ctx context.Context, existing *db.IdempotencyRecord, requestHash string) events.APIGatewayProxyResponse {
+	if existing.RequestHash != requestHash {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 422,
+			Body:       `{"error": "Idempotency-Key was already used for a different request"}`,
+		}
+	}
+
+	submission, err := db.GetSubmissionByKey(ctx, existing.ProblemID, existing.SubmissionID)
+	if errors.Is(err, db.ErrSubmissionNotFound) {
+		// The first attempt hasn't saved its submission yet
+		return events.APIGatewayProxyResponse{
+			StatusCode: 409,
+			Headers: map[string]string{
+				"Retry-After": "1",
+			},
+			Body: `{"error": "A request with this Idempotency-Key is still in progress"}`,
+		}
+	}
+	if err != nil {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 500,
+			Body:       fmt.Sprintf(`{"error": "Failed to get submission: %v"}`, err),
+		}
+	}
+
+	// The first attempt saved the submission but may have failed to
+	// publish it
+	if submission.Status == types.StatusPending && !existing.Publishing {
+		if err := publish(ctx, submission, existing.Key); err != nil {
+			return events.APIGatewayProxyResponse{
+				StatusCode: 500,
+				Body:       fmt.Sprintf(`{"error": "Failed to publish submission: %v"}`, err),
+			}
+		}
+	}
+
+	responseBody, err := json.Marshal(map[string]interface{}{
+		"submission": submission,
+	})
+	if err != nil {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 500,
+			Body:       fmt.Sprintf(`{"error": "Failed to create response: %v"}`, err),
+		}
+	}
+
+	return events.APIGatewayProxyResponse{
+		StatusCode: 200,
+		Headers: map[string]string{
+			"Content-Type":        "application/json",
+			"Idempotent-Replayed": "true",
+		},
+		Body: string(responseBody),
+	}
+}
+
+// publish sends the submission to its runner. With an idempotency key only
+// the request that claims publishing for the key does, so that a retry
+// doesn't publish a submission twice, and a failure gives the claim back.
+func publish(ctx context.Context, submission *types.Submission, recordKey string) error {
+	if recordKey != "" {
+		claimed, err := db.ClaimPublish(ctx, recordKey)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			return nil
+		}
+	}
+
+	if err := utils.PublishToMomento(ctx, *submission); err != nil {
+		if recordKey != "" {
+			if releaseErr := db.ReleasePublish(ctx, recordKey); releaseErr != nil {
+				fmt.Printf("Failed to release publishing: %v\n", releaseErr)
+			}
+		}
+		return err
+	}
+	notify.Status(ctx, submission, types.StatusPending)
+	return nil
+}
+
+// invalidSubmission is the 400 response listing what is wrong with each
+// field.
+func invalidSubmission(fields types.FieldErrors) events.APIGatewayProxyResponse {
+	body, _ := json.Marshal(map[string]interface{}{
+		"error":  "Invalid submission",
+		"fields": fields,
+	})
+	return events.APIGatewayProxyResponse{
+		StatusCode: 400,
+		Headers: map[string]string{
+			"Content-Type": "application/json",
+		},
+		Body: string(body),
 	}
-	return validLanguages[lang]
 }
 
 func main() {
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"learncode/backend/types"
+)
+
+func TestSubmitRequestValidate(t *testing.T) {
+	valid := SubmitRequest{ProblemID: "prob-001", Language: "python", Code: "print(1)", Type: types.SubmissionSubmit}
+	tests := []struct {
+		name   string
+		edit   func(r *SubmitRequest)
+		fields []string
+	}{
+		{"valid", func(r *SubmitRequest) {}, nil},
+		{"valid run", func(r *SubmitRequest) { r.Type = types.SubmissionRun }, nil},
+		{"missing problem", func(r *SubmitRequest) { r.ProblemID = "" }, []string{"problem_id"}},
+		{"unknown language", func(r *SubmitRequest) { r.Language = "cobol" }, []string{"language"}},
+		{"lowercase type", func(r *SubmitRequest) { r.Type = "submit" }, []string{"type"}},
+		{"blank code", func(r *SubmitRequest) { r.Code = " \n\t" }, []string{"code"}},
+		{"code at the limit", func(r *SubmitRequest) { r.Code = strings.Repeat("x", types.MaxCodeBytes) }, nil},
+		{"code too long", func(r *SubmitRequest) { r.Code = strings.Repeat("x", types.MaxCodeBytes+1) }, []string{"code"}},
+		{"everything wrong", func(r *SubmitRequest) { *r = SubmitRequest{} }, []string{"code", "language", "problem_id", "type"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := valid
+			tt.edit(&req)
+
+			var fields []string
+			for field := range req.Validate() {
+				fields = append(fields, field)
+			}
+			sort.Strings(fields)
+			if !reflect.DeepEqual(fields, tt.fields) {
+				t.Errorf("Validate() rejected %v, want %v", fields, tt.fields)
+			}
+		})
+	}
+}
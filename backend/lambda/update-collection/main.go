@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"learncode/backend/db"
+	"learncode/backend/utils"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// UpdateCollectionRequest holds the fields to change; omitted fields keep
+// their current value.
+type UpdateCollectionRequest struct {
+	Title       *string   `json:"title"`
+	Description *string   `json:"description"`
+	ProblemIDs  *[]string `json:"problem_ids"`
+	Published   *bool     `json:"published"`
+}
+
+func handleRequest(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	user, errResponse := utils.AuthenticateInstructor(ctx, event.Headers)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+
+	// Get collection ID from path parameters
+	collectionID := event.PathParameters["id"]
+	if collectionID == "" {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 400,
+			Body:       `{"error": "Collection ID is required"}`,
+		}, nil
+	}
+
+	// Parse request body
+	var req UpdateCollectionRequest
+	if err := json.Unmarshal([]byte(event.Body), &req); err != nil {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 400,
+			Body:       fmt.Sprintf(`{"error": "Invalid request body: %v"}`, err),
+		}, nil
+	}
+
+	collection, err := db.GetCollection(ctx, collectionID)
+	if err != nil {
+		if errors.Is(err, db.ErrCollectionNotFound) {
+			return events.APIGatewayProxyResponse{
+				StatusCode: 404,
+				Body:       fmt.Sprintf(`{"error": "Collection not found: %s"}`, collectionID),
+			}, nil
+		}
+		return events.APIGatewayProxyResponse{
+			StatusCode: 500,
+			Body:       fmt.Sprintf(`{"error": "Failed to get collection: %v"}`, err),
+		}, nil
+	}
+
+	if !collection.CanEdit(user) {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 403,
+			Body:       `{"error": "Only the collection's owner or an admin can change it"}`,
+		}, nil
+	}
+
+	if req.Title != nil {
+		collection.Title = *req.Title
+	}
+	if req.Description != nil {
+		collection.Description = *req.Description
+	}
+	if req.ProblemIDs != nil {
+		collection.ProblemIDs = *req.ProblemIDs
+	}
+	if req.Published != nil {
+		collection.Published = *req.Published
+	}
+	collection.UpdatedAt = time.Now().Unix()
+
+	if err := collection.Validate(); err != nil {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 400,
+			Body:       fmt.Sprintf(`{"error": %q}`, err.Error()),
+		}, nil
+	}
+
+	// Every problem in the collection has to exist
+	if req.ProblemIDs != nil {
+		problems, err := db.GetProblemsByID(ctx, collection.ProblemIDs)
+		if err != nil {
+			return events.APIGatewayProxyResponse{
+				StatusCode: 500,
+				Body:       fmt.Sprintf(`{"error": "Failed to get problems: %v"}`, err),
+			}, nil
+		}
+		for _, id := range collection.ProblemIDs {
+			if problems[id] == nil {
+				return events.APIGatewayProxyResponse{
+					StatusCode: 400,
+					Body:       fmt.Sprintf(`{"error": "Problem not found: %s"}`, id),
+				}, nil
+			}
+		}
+	}
+
+	if err := db.SaveCollection(ctx, collection); err != nil {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 500,
+			Body:       fmt.Sprintf(`{"error": "Failed to save collection: %v"}`, err),
+		}, nil
+	}
+
+	responseBody, err := json.Marshal(map[string]interface{}{
+		"message":    "Collection updated successfully",
+		"collection": collection,
+	})
+	if err != nil {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 500,
+			Body:       fmt.Sprintf(`{"error": "Failed to marshal response: %v"}`, err),
+		}, nil
+	}
+
+	return events.APIGatewayProxyResponse{
+		StatusCode: 200,
+		Headers: map[string]string{
+			"Content-Type": "application/json",
+		},
+		Body: string(responseBody),
+	}, nil
+}
+
+func main() {
+	lambda.Start(handleRequest)
+}
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"learncode/backend/db"
+	"learncode/backend/judge"
+	"learncode/backend/testdata"
+	"learncode/backend/types"
+	"learncode/backend/utils"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// UpdateProblemRequest holds the fields to change; omitted fields keep their
+// current value.
+type UpdateProblemRequest struct {
+	Title              *string                    `json:"title"`
+	Description        *string                    `json:"description"`
+	Difficulty         *string                    `json:"difficulty"`
+	Input              *string                    `json:"input"`
+	Output             *string                    `json:"output"`
+	ExampleInput       *string                    `json:"example_input"`
+	ExampleOutput      *string                    `json:"example_output"`
+	ReferenceSolutions *[]types.ReferenceSolution `json:"reference_solutions"`
+	InputValidators    *[]types.Program           `json:"input_validators"`
+	StarterCode        *map[string]string         `json:"starter_code"`
+	AllowedLanguages   *[]string                  `json:"allowed_languages"`
+	Signature          *types.Signature           `json:"signature"`
+	Interactor         *types.Program             `json:"interactor"`
+	QueryLimit         *int                       `json:"query_limit"`
+	SQL                *types.SQLSpec             `json:"sql"`
+	Hints              *[]string                  `json:"hints"`
+	Editorial          *types.Editorial           `json:"editorial"`
+	EditorialAttempts  *int                       `json:"editorial_attempts"`
+	Rating             *int                       `json:"rating"`
+	Locale             *string                    `json:"locale"`
+	Clear              []string                   `json:"clear"` // Optional fields to remove, see clearable
+}
+
+// clearable lists the optional parts of a problem an update can remove,
+// which leaving their fields out or setting them to null can't. Clearing
+// the interactor also clears its query limit.
+var clearable = map[string]func(p *types.Problem){
+	"signature": func(p *types.Problem) { p.Signature = nil },
+	"interactor": func(p *types.Problem) {
+		p.Interactor = nil
+		p.QueryLimit = 0
+	},
+	"sql":       func(p *types.Problem) { p.SQL = nil },
+	"editorial": func(p *types.Problem) { p.Editorial = nil },
+}
+
+// validateClear checks that the fields to clear can be cleared and aren't
+// also being set.
+func (r *UpdateProblemRequest) validateClear() error {
+	set := map[string]bool{
+		"signature":  r.Signature != nil,
+		"interactor": r.Interactor != nil || r.QueryLimit != nil,
+		"sql":        r.SQL != nil,
+		"editorial":  r.Editorial != nil,
+	}
+	for _, field := range r.Clear {
+		if clearable[field] == nil {
+			return fmt.Errorf("%s can't be cleared", field)
+		}
+		if set[field] {
+			return fmt.Errorf("%s can't be both set and cleared", field)
+		}
+	}
+	return nil
+}
+
+func handleRequest(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	if _, errResponse := utils.AuthenticateAdmin(ctx, event.Headers); errResponse != nil {
+		return *errResponse, nil
+	}
+
+	// Get problem ID from path parameters
+	problemID := event.PathParameters["id"]
+	if problemID == "" {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 400,
+			Body:       `{"error": "Problem ID is required"}`,
+		}, nil
+	}
+
+	// Parse request body
+	var req UpdateProblemRequest
+	if err := json.Unmarshal([]byte(event.Body), &req); err != nil {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 400,
+			Body:       fmt.Sprintf(`{"error": "Invalid request body: %v"}`, err),
+		}, nil
+	}
+	// The stored input or output may only be a preview of an offloaded
+	// test, so one can't be replaced without the other
+	if (req.Input == nil) != (req.Output == nil) {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 400,
+			Body:       `{"error": "input and output must be set together"}`,
+		}, nil
+	}
+
+	if err := req.validateClear(); err != nil {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 400,
+			Body:       fmt.Sprintf(`{"error": %q}`, err.Error()),
+		}, nil
+	}
+
+	problem, err := db.GetProblem(ctx, problemID)
+	if err != nil {
+		if errors.Is(err, db.ErrProblemNotFound) {
+			return events.APIGatewayProxyResponse{
+				StatusCode: 404,
+				Body:       fmt.Sprintf(`{"error": "Problem not found: %s"}`, problemID),
+			}, nil
+		}
+		return events.APIGatewayProxyResponse{
+			StatusCode: 500,
+			Body:       fmt.Sprintf(`{"error": "Failed to get problem: %v"}`, err),
+		}, nil
+	}
+
+	// The save fails if the problem changed meanwhile
+	updatedAt, rating := problem.UpdatedAt, problem.Rating
+	applyUpdate(problem, &req)
+	problem.UpdatedAt = time.Now().Unix()
+
+	// Validate required fields and difficulty
+	if err := problem.Validate(); err != nil {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 400,
+			Body:       fmt.Sprintf(`{"error": %q}`, err.Error()),
+		}, nil
+	}
+
+	// Move large test data out of the item
+	if err := testdata.OffloadDefault(ctx, problem); err != nil {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 500,
+			Body:       fmt.Sprintf(`{"error": "Failed to store test data: %v"}`, err),
+		}, nil
+	}
+
+	// Run reference solutions and input validators before publishing
+	failures, err := judge.Verify(ctx, problem)
+	if err != nil {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 500,
+			Body:       fmt.Sprintf(`{"error": "Failed to validate problem: %v"}`, err),
+		}, nil
+	}
+	if len(failures) > 0 {
+		body, _ := json.Marshal(map[string]interface{}{
+			"error":    "Problem failed validation",
+			"failures": failures,
+		})
+		return events.APIGatewayProxyResponse{
+			StatusCode: 422,
+			Headers: map[string]string{
+				"Content-Type": "application/json",
+			},
+			Body: string(body),
+		}, nil
+	}
+
+	// A renamed problem gets a new slug; the old one redirects to it
+	if err := db.UpdateProblem(ctx, problem, updatedAt, rating); err != nil {
+		if errors.Is(err, db.ErrProblemChanged) {
+			return events.APIGatewayProxyResponse{
+				StatusCode: 409,
+				Body:       `{"error": "Problem was changed meanwhile, reload it and try again"}`,
+			}, nil
+		}
+		return events.APIGatewayProxyResponse{
+			StatusCode: 500,
+			Body:       fmt.Sprintf(`{"error": "Failed to save problem: %v"}`, err),
+		}, nil
+	}
+
+	responseBody, err := json.Marshal(map[string]interface{}{
+		"message": "Problem updated successfully",
+		"problem": problem,
+	})
+	if err != nil {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 500,
+			Body:       fmt.Sprintf(`{"error": "Failed to marshal response: %v"}`, err),
+		}, nil
+	}
+
+	return events.APIGatewayProxyResponse{
+		StatusCode: 200,
+		Headers: map[string]string{
+			"Content-Type": "application/json",
+		},
+		Body: string(responseBody),
+	}, nil
+}
+
+func applyUpdate(problem *types.Problem, req *UpdateProblemRequest) {
+	if req.Title != nil {
+		problem.Title = *req.Title
+	}
+	if req.Description != nil {
+		problem.Description = *req.Description
+	}
+	if req.Difficulty != nil {
+		problem.Difficulty = *req.Difficulty
+	}
+	if req.ExampleInput != nil {
+		problem.ExampleInput = *req.ExampleInput
+	}
+	if req.ExampleOutput != nil {
+		problem.ExampleOutput = *req.ExampleOutput
+	}
+	// Setting input and output edits the problem as a single-test problem,
+	// so any imported test set is replaced.
+	if req.Input != nil && req.Output != nil {
+		problem.Input = *req.Input
+		problem.Output = *req.Output
+		problem.Tests = nil
+	}
+	if req.ReferenceSolutions != nil {
+		problem.ReferenceSolutions = *req.ReferenceSolutions
+	}
+	if req.InputValidators != nil {
+		problem.InputValidators = *req.InputValidators
+	}
+	if req.StarterCode != nil {
+		problem.StarterCode = *req.StarterCode
+	}
+	if req.AllowedLanguages != nil {
+		problem.AllowedLanguages = *req.AllowedLanguages
+	}
+	if req.Signature != nil {
+		problem.Signature = req.Signature
+	}
+	if req.Interactor != nil {
+		problem.Interactor = req.Interactor
+	}
+	if req.QueryLimit != nil {
+		problem.QueryLimit = *req.QueryLimit
+	}
+	if req.SQL != nil {
+		problem.SQL = req.SQL
+	}
+	if req.Hints != nil {
+		problem.Hints = *req.Hints
+	}
+	if req.Editorial != nil {
+		problem.Editorial = req.Editorial
+	}
+	if req.EditorialAttempts != nil {
+		problem.EditorialAttempts = *req.EditorialAttempts
+	}
+	// An admin's rating is kept until they set it back to zero
+	if req.Rating != nil {
+		problem.Rating = *req.Rating
+		problem.RatingLocked = *req.Rating > 0
+	}
+	if req.Locale != nil {
+		problem.Locale = *req.Locale
+	}
+	for _, field := range req.Clear {
+		clearable[field](problem)
+	}
+}
+
+func main() {
+	lambda.Start(handleRequest)
+}
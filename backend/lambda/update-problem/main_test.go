@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"learncode/backend/types"
+)
+
+func TestApplyUpdateClear(t *testing.T) {
+	stored := types.Problem{
+		Signature:  &types.Signature{Function: "twoSum"},
+		Interactor: &types.Program{Language: "python", Code: "# interactor"},
+		QueryLimit: 30,
+		SQL:        &types.SQLSpec{Schema: "CREATE TABLE t (a INT);"},
+		Editorial:  &types.Editorial{Content: "Add them."},
+	}
+	tests := []struct {
+		name    string
+		body    string
+		wantErr string
+		check   func(p *types.Problem) bool
+	}{
+		{"left out", `{}`, "", func(p *types.Problem) bool {
+			return p.Signature != nil && p.Interactor != nil && p.SQL != nil && p.Editorial != nil
+		}},
+		{"null", `{"signature": null, "sql": null}`, "", func(p *types.Problem) bool {
+			return p.Signature != nil && p.SQL != nil
+		}},
+		{"signature", `{"clear": ["signature"]}`, "", func(p *types.Problem) bool {
+			return p.Signature == nil && p.Interactor != nil
+		}},
+		{"interactor and its query limit", `{"clear": ["interactor"]}`, "", func(p *types.Problem) bool {
+			return p.Interactor == nil && p.QueryLimit == 0
+		}},
+		{"several", `{"clear": ["sql", "editorial"]}`, "", func(p *types.Problem) bool {
+			return p.SQL == nil && p.Editorial == nil && p.Signature != nil
+		}},
+		{"unknown field", `{"clear": ["title"]}`, "title can't be cleared", nil},
+		{"set and cleared", `{"sql": {"schema": ""}, "clear": ["sql"]}`, "sql can't be both set and cleared", nil},
+		{"query limit and interactor cleared", `{"query_limit": 5, "clear": ["interactor"]}`, "interactor can't be both set and cleared", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req UpdateProblemRequest
+			if err := json.Unmarshal([]byte(tt.body), &req); err != nil {
+				t.Fatal(err)
+			}
+			err := req.validateClear()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("validateClear() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("validateClear() error = %v", err)
+			}
+
+			problem := stored
+			applyUpdate(&problem, &req)
+			if !tt.check(&problem) {
+				t.Errorf("applyUpdate(%s) = %+v", tt.body, problem)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"learncode/backend/db"
+	"learncode/backend/rating"
+	"learncode/backend/types"
+	"time"
+
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// handleRequest runs on a schedule. It rates every judged SUBMIT
+// submission made since the last run, stores the new ratings and marks the
+// submissions as rated.
+func handleRequest(ctx context.Context) error {
+	submissions, err := db.GetUnratedSubmissions(ctx)
+	if err != nil {
+		return err
+	}
+	if len(submissions) == 0 {
+		fmt.Println("No submissions to rate")
+		return nil
+	}
+
+	batch := &rating.Batch{
+		Users:    map[string]*types.User{},
+		Problems: map[string]*types.Problem{},
+	}
+	for _, submission := range submissions {
+		if _, ok := batch.Users[submission.UserID]; !ok {
+			user, err := db.GetUser(ctx, submission.UserID)
+			if err != nil {
+				return err
+			}
+			batch.Users[submission.UserID] = user
+		}
+		if _, ok := batch.Problems[submission.ProblemID]; !ok {
+			problem, err := db.GetProblem(ctx, submission.ProblemID)
+			if err != nil && !errors.Is(err, db.ErrProblemNotFound) {
+				return err
+			}
+			batch.Problems[submission.ProblemID] = problem
+		}
+	}
+
+	// Unknown users and deleted problems come back as nil and are skipped
+	batch.Apply(submissions)
+
+	// Ratings are stored before submissions are marked, so a run that fails
+	// halfway counts some attempts twice rather than losing them
+	for id, user := range batch.Users {
+		if user == nil || user.Rating == 0 {
+			continue
+		}
+		if err := db.SetUserRating(ctx, id, user.Rating, batch.Solved[id]); err != nil {
+			return err
+		}
+	}
+	for id, problem := range batch.Problems {
+		if problem == nil || problem.Rating == 0 || problem.RatingLocked {
+			continue
+		}
+		if err := db.SetProblemRating(ctx, id, problem.Rating); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now().Unix()
+	for _, submission := range append(batch.Rated, batch.Skipped...) {
+		if err := db.MarkSubmissionRated(ctx, &submission, now); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("Rated %d submissions, skipped %d\n", len(batch.Rated), len(batch.Skipped))
+	return nil
+}
+
+func main() {
+	lambda.Start(handleRequest)
+}
@@ -5,8 +5,11 @@ import (
 
 	"github.com/aws/aws-cdk-go/awscdk/v2"
 	"github.com/aws/aws-cdk-go/awscdk/v2/awsdynamodb"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awseventstargets"
 	"github.com/aws/aws-cdk-go/awscdk/v2/awsiam"
 	"github.com/aws/aws-cdk-go/awscdk/v2/awslambda"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awss3"
 	"github.com/aws/aws-cdk-go/awscdkapigatewayv2alpha/v2"
 	"github.com/aws/aws-cdk-go/awscdkapigatewayv2integrationsalpha/v2"
 	"github.com/aws/aws-cdk-go/awscdklambdagoalpha/v2"
@@ -68,6 +71,58 @@ func NewBackendStack(scope constructs.Construct, id string, props *BackendStackP
 		RemovalPolicy: awscdk.RemovalPolicy_DESTROY,
 	})
 
+	// Lets users list their own submissions across problems
+	submissionsTable.AddGlobalSecondaryIndex(&awsdynamodb.GlobalSecondaryIndexProps{
+		IndexName: jsii.String("user_id-created_at-index"),
+		PartitionKey: &awsdynamodb.Attribute{
+			Name: jsii.String("user_id"),
+			Type: awsdynamodb.AttributeType_STRING,
+		},
+		SortKey: &awsdynamodb.Attribute{
+			Name: jsii.String("created_at"),
+			Type: awsdynamodb.AttributeType_NUMBER,
+		},
+	})
+
+	// Finds a submission from its ID alone
+	submissionsTable.AddGlobalSecondaryIndex(&awsdynamodb.GlobalSecondaryIndexProps{
+		IndexName: jsii.String("submission_id-index"),
+		PartitionKey: &awsdynamodb.Attribute{
+			Name: jsii.String("submission_id"),
+			Type: awsdynamodb.AttributeType_STRING,
+		},
+		ProjectionType: awsdynamodb.ProjectionType_KEYS_ONLY,
+	})
+
+	// Sparse: holds only the judged submissions the rating job hasn't
+	// counted yet, so it doesn't have to scan the table
+	submissionsTable.AddGlobalSecondaryIndex(&awsdynamodb.GlobalSecondaryIndexProps{
+		IndexName: jsii.String("unrated-created_at-index"),
+		PartitionKey: &awsdynamodb.Attribute{
+			Name: jsii.String("unrated"),
+			Type: awsdynamodb.AttributeType_STRING,
+		},
+		SortKey: &awsdynamodb.Attribute{
+			Name: jsii.String("created_at"),
+			Type: awsdynamodb.AttributeType_NUMBER,
+		},
+	})
+	// Sparse: holds only the pending and running submissions, so the reaper
+	// doesn't have to scan the table for stuck ones
+	submissionsTable.AddGlobalSecondaryIndex(&awsdynamodb.GlobalSecondaryIndexProps{
+		IndexName: jsii.String("judging-updated_at-index"),
+		PartitionKey: &awsdynamodb.Attribute{
+			Name: jsii.String("judging"),
+			Type: awsdynamodb.AttributeType_STRING,
+		},
+		SortKey: &awsdynamodb.Attribute{
+			Name: jsii.String("updated_at"),
+			Type: awsdynamodb.AttributeType_NUMBER,
+		},
+		ProjectionType:   awsdynamodb.ProjectionType_INCLUDE,
+		NonKeyAttributes: jsii.Strings("user_id", "language", "status", "retries", "rejudge_queued"),
+	})
+
 	usersTable := awsdynamodb.NewTable(stack, jsii.String("Users"), &awsdynamodb.TableProps{
 		PartitionKey: &awsdynamodb.Attribute{
 			Name: jsii.String("id"),
@@ -77,6 +132,74 @@ func NewBackendStack(scope constructs.Construct, id string, props *BackendStackP
 		TableName:   jsii.String("Users"),
 	})
 
+	// Maps slugs, including those of renamed problems, to problem IDs
+	slugsTable := awsdynamodb.NewTable(stack, jsii.String("ProblemSlugs"), &awsdynamodb.TableProps{
+		PartitionKey: &awsdynamodb.Attribute{
+			Name: jsii.String("slug"),
+			Type: awsdynamodb.AttributeType_STRING,
+		},
+		BillingMode: awsdynamodb.BillingMode_PAY_PER_REQUEST,
+		TableName:   jsii.String("ProblemSlugs"),
+	})
+
+	// Per-problem counters kept up to date by the runners
+	statsTable := awsdynamodb.NewTable(stack, jsii.String("ProblemStats"), &awsdynamodb.TableProps{
+		PartitionKey: &awsdynamodb.Attribute{
+			Name: jsii.String("problem_id"),
+			Type: awsdynamodb.AttributeType_STRING,
+		},
+		BillingMode: awsdynamodb.BillingMode_PAY_PER_REQUEST,
+		TableName:   jsii.String("ProblemStats"),
+	})
+
+	// Fixed-window submission counters, removed by TTL once their window ends
+	rateLimitsTable := awsdynamodb.NewTable(stack, jsii.String("RateLimits"), &awsdynamodb.TableProps{
+		PartitionKey: &awsdynamodb.Attribute{
+			Name: jsii.String("key"),
+			Type: awsdynamodb.AttributeType_STRING,
+		},
+		BillingMode:         awsdynamodb.BillingMode_PAY_PER_REQUEST,
+		TableName:           jsii.String("RateLimits"),
+		TimeToLiveAttribute: jsii.String("expires_at"),
+	})
+
+	// Maps submit idempotency keys to the submissions they created
+	idempotencyTable := awsdynamodb.NewTable(stack, jsii.String("IdempotencyKeys"), &awsdynamodb.TableProps{
+		PartitionKey: &awsdynamodb.Attribute{
+			Name: jsii.String("key"),
+			Type: awsdynamodb.AttributeType_STRING,
+		},
+		BillingMode:         awsdynamodb.BillingMode_PAY_PER_REQUEST,
+		TableName:           jsii.String("IdempotencyKeys"),
+		TimeToLiveAttribute: jsii.String("expires_at"),
+	})
+
+	// Admin-started rejudges of a problem's submissions
+	rejudgesTable := awsdynamodb.NewTable(stack, jsii.String("Rejudges"), &awsdynamodb.TableProps{
+		PartitionKey: &awsdynamodb.Attribute{
+			Name: jsii.String("id"),
+			Type: awsdynamodb.AttributeType_STRING,
+		},
+		BillingMode: awsdynamodb.BillingMode_PAY_PER_REQUEST,
+		TableName:   jsii.String("Rejudges"),
+	})
+
+	// Runner jobs that kept failing on infrastructure errors, keyed by submission ID
+	deadLettersTable := awsdynamodb.NewTable(stack, jsii.String("DeadLetters"), &awsdynamodb.TableProps{
+		PartitionKey: &awsdynamodb.Attribute{
+			Name: jsii.String("id"),
+			Type: awsdynamodb.AttributeType_STRING,
+		},
+		BillingMode: awsdynamodb.BillingMode_PAY_PER_REQUEST,
+		TableName:   jsii.String("DeadLetters"),
+	})
+
+	// Large test case payloads, referenced from problems by key
+	testDataBucket := awss3.NewBucket(stack, jsii.String("TestData"), &awss3.BucketProps{
+		BlockPublicAccess: awss3.BlockPublicAccess_BLOCK_ALL(),
+		Encryption:        awss3.BucketEncryption_S3_MANAGED,
+	})
+
 	// Lambda execution role
 	lambdaRole := awsiam.NewRole(stack, jsii.String("LambdaExecutionRole"), &awsiam.RoleProps{
 		AssumedBy: awsiam.NewServicePrincipal(jsii.String("lambda.amazonaws.com"), nil),
@@ -108,6 +231,8 @@ func NewBackendStack(scope constructs.Construct, id string, props *BackendStackP
 	problemsTable.GrantReadWriteData(lambdaRole)
 	submissionsTable.GrantReadWriteData(lambdaRole)
 	usersTable.GrantReadWriteData(lambdaRole)
+	statsTable.GrantReadData(lambdaRole)
+	slugsTable.GrantReadWriteData(lambdaRole)
 
 	// Lambda Functions
 	submitLambda := awscdklambdagoalpha.NewGoFunction(stack, jsii.String("SubmitFunction"), &awscdklambdagoalpha.GoFunctionProps{
@@ -125,9 +250,15 @@ func NewBackendStack(scope constructs.Construct, id string, props *BackendStackP
 			"SUBMISSIONS_TABLE":  submissionsTable.TableName(),
 			"MOMENTO_AUTH_TOKEN": jsii.String(os.Getenv("MOMENTO_AUTH_TOKEN")),
 			"USERS_TABLE":        usersTable.TableName(),
+			"RATE_LIMITS_TABLE":  rateLimitsTable.TableName(),
+			"RATE_LIMITS":        jsii.String(os.Getenv("RATE_LIMITS")),
+			"IDEMPOTENCY_TABLE":  idempotencyTable.TableName(),
 		},
 	})
 
+	rateLimitsTable.GrantReadWriteData(submitLambda)
+	idempotencyTable.GrantReadWriteData(submitLambda)
+
 	// Get Problems Lambda
 	getProblemsLambda := awscdklambdagoalpha.NewGoFunction(stack, jsii.String("GetProblemsFunction"), &awscdklambdagoalpha.GoFunctionProps{
 		Runtime: awslambda.Runtime_PROVIDED_AL2(),
@@ -142,11 +273,13 @@ func NewBackendStack(scope constructs.Construct, id string, props *BackendStackP
 		Environment: &map[string]*string{
 			"PROBLEMS_TABLE": problemsTable.TableName(),
 			"USERS_TABLE":    usersTable.TableName(),
+			"STATS_TABLE":    statsTable.TableName(),
 		},
 	})
 
 	problemsTable.GrantReadData(getProblemsLambda)
 	usersTable.GrantReadData(getProblemsLambda)
+	statsTable.GrantReadData(getProblemsLambda)
 
 	// Delete Problem Lambda
 	deleteProblemLambda := awscdklambdagoalpha.NewGoFunction(stack, jsii.String("DeleteProblemLambda"), &awscdklambdagoalpha.GoFunctionProps{
@@ -179,19 +312,24 @@ func NewBackendStack(scope constructs.Construct, id string, props *BackendStackP
 				"GOARCH": jsii.String("amd64"),
 			},
 		},
+		Timeout: awscdk.Duration_Seconds(jsii.Number(30)),
 		Environment: &map[string]*string{
-			"PROBLEMS_TABLE": problemsTable.TableName(),
-			"USERS_TABLE":    usersTable.TableName(),
+			"PROBLEMS_TABLE":  problemsTable.TableName(),
+			"USERS_TABLE":     usersTable.TableName(),
+			"TESTDATA_BUCKET": testDataBucket.BucketName(),
+			"RUNNER_SECRET":   jsii.String(os.Getenv("RUNNER_SECRET")),
+			"SLUGS_TABLE":     slugsTable.TableName(),
 		},
 	})
 
 	problemsTable.GrantWriteData(addProblemLambda)
 	usersTable.GrantReadData(addProblemLambda)
+	testDataBucket.GrantReadWrite(addProblemLambda, nil)
 
-	// Get Problem Lambda
-	getProblemLambda := awscdklambdagoalpha.NewGoFunction(stack, jsii.String("GetProblemLambda"), &awscdklambdagoalpha.GoFunctionProps{
+	// Import Problem Lambda
+	importProblemLambda := awscdklambdagoalpha.NewGoFunction(stack, jsii.String("ImportProblemLambda"), &awscdklambdagoalpha.GoFunctionProps{
 		Runtime: awslambda.Runtime_PROVIDED_AL2(),
-		Entry:   jsii.String("lambda/get-problem"),
+		Entry:   jsii.String("lambda/import-problem"),
 		Role:    lambdaRole,
 		Bundling: &awscdklambdagoalpha.BundlingOptions{
 			Environment: &map[string]*string{
@@ -199,66 +337,92 @@ func NewBackendStack(scope constructs.Construct, id string, props *BackendStackP
 				"GOARCH": jsii.String("amd64"),
 			},
 		},
+		Timeout: awscdk.Duration_Seconds(jsii.Number(30)),
 		Environment: &map[string]*string{
-			"PROBLEMS_TABLE": problemsTable.TableName(),
-			"USERS_TABLE":    usersTable.TableName(),
+			"PROBLEMS_TABLE":  problemsTable.TableName(),
+			"USERS_TABLE":     usersTable.TableName(),
+			"TESTDATA_BUCKET": testDataBucket.BucketName(),
+			"RUNNER_SECRET":   jsii.String(os.Getenv("RUNNER_SECRET")),
+			"SLUGS_TABLE":     slugsTable.TableName(),
 		},
 	})
 
-	authLambda := awscdklambdagoalpha.NewGoFunction(stack, jsii.String("AuthFunction"), &awscdklambdagoalpha.GoFunctionProps{
+	problemsTable.GrantReadWriteData(importProblemLambda)
+	usersTable.GrantReadData(importProblemLambda)
+	testDataBucket.GrantReadWrite(importProblemLambda, nil)
+
+	// Update Problem Lambda
+	updateProblemLambda := awscdklambdagoalpha.NewGoFunction(stack, jsii.String("UpdateProblemLambda"), &awscdklambdagoalpha.GoFunctionProps{
 		Runtime: awslambda.Runtime_PROVIDED_AL2(),
-		Entry:   jsii.String("lambda/auth"),
+		Entry:   jsii.String("lambda/update-problem"),
 		Role:    lambdaRole,
+		Bundling: &awscdklambdagoalpha.BundlingOptions{
+			Environment: &map[string]*string{
+				"GOOS":   jsii.String("linux"),
+				"GOARCH": jsii.String("amd64"),
+			},
+		},
+		Timeout: awscdk.Duration_Seconds(jsii.Number(30)),
 		Environment: &map[string]*string{
-			"GITHUB_CLIENT_ID": jsii.String(os.Getenv("GITHUB_CLIENT_ID")),
-			"USERS_TABLE":      usersTable.TableName(),
+			"PROBLEMS_TABLE":  problemsTable.TableName(),
+			"USERS_TABLE":     usersTable.TableName(),
+			"TESTDATA_BUCKET": testDataBucket.BucketName(),
+			"RUNNER_SECRET":   jsii.String(os.Getenv("RUNNER_SECRET")),
+			"SLUGS_TABLE":     slugsTable.TableName(),
 		},
 	})
 
-	githubCallbackLambda := awscdklambdagoalpha.NewGoFunction(stack, jsii.String("GithubCallbackFunction"), &awscdklambdagoalpha.GoFunctionProps{
+	problemsTable.GrantReadWriteData(updateProblemLambda)
+	usersTable.GrantReadData(updateProblemLambda)
+	testDataBucket.GrantReadWrite(updateProblemLambda, nil)
+
+	// Export Problem Lambda
+	exportProblemLambda := awscdklambdagoalpha.NewGoFunction(stack, jsii.String("ExportProblemLambda"), &awscdklambdagoalpha.GoFunctionProps{
 		Runtime: awslambda.Runtime_PROVIDED_AL2(),
-		Entry:   jsii.String("lambda/github-callback"),
+		Entry:   jsii.String("lambda/export-problem"),
 		Role:    lambdaRole,
+		Bundling: &awscdklambdagoalpha.BundlingOptions{
+			Environment: &map[string]*string{
+				"GOOS":   jsii.String("linux"),
+				"GOARCH": jsii.String("amd64"),
+			},
+		},
 		Environment: &map[string]*string{
-			"GITHUB_CLIENT_ID":     jsii.String(os.Getenv("GITHUB_CLIENT_ID")),
-			"GITHUB_CLIENT_SECRET": jsii.String(os.Getenv("GITHUB_CLIENT_SECRET")),
-			"FRONTEND_URL":         jsii.String(os.Getenv("FRONTEND_URL")),
-			"USERS_TABLE":          usersTable.TableName(),
+			"PROBLEMS_TABLE":  problemsTable.TableName(),
+			"USERS_TABLE":     usersTable.TableName(),
+			"TESTDATA_BUCKET": testDataBucket.BucketName(),
 		},
 	})
 
-	// Auth verify Lambda
-	authVerifyLambda := awscdklambdagoalpha.NewGoFunction(stack, jsii.String("AuthVerifyFunction"), &awscdklambdagoalpha.GoFunctionProps{
+	problemsTable.GrantReadData(exportProblemLambda)
+	usersTable.GrantReadData(exportProblemLambda)
+	testDataBucket.GrantRead(exportProblemLambda, nil)
+
+	// Problem review workflow Lambdas
+	setProblemStatusLambda := awscdklambdagoalpha.NewGoFunction(stack, jsii.String("SetProblemStatusLambda"), &awscdklambdagoalpha.GoFunctionProps{
 		Runtime: awslambda.Runtime_PROVIDED_AL2(),
-		Entry:   jsii.String("lambda/auth-verify"),
+		Entry:   jsii.String("lambda/set-problem-status"),
 		Role:    lambdaRole,
+		Bundling: &awscdklambdagoalpha.BundlingOptions{
+			Environment: &map[string]*string{
+				"GOOS":   jsii.String("linux"),
+				"GOARCH": jsii.String("amd64"),
+			},
+		},
 		Environment: &map[string]*string{
-			"GITHUB_CLIENT_ID": jsii.String(os.Getenv("GITHUB_CLIENT_ID")),
-			"USERS_TABLE":      usersTable.TableName(),
+			"PROBLEMS_TABLE": problemsTable.TableName(),
+			"USERS_TABLE":    usersTable.TableName(),
 		},
 	})
 
-	// Create Node.js Lambda Layer
-	nodejsLayer := awslambda.NewLayerVersion(stack, jsii.String("NodejsLayer"), &awslambda.LayerVersionProps{
-		LayerVersionName: jsii.String("nodejs18"),
-		Description:      jsii.String("Node.js 18.x runtime"),
-		Code:             awslambda.Code_FromAsset(jsii.String("lambda/layers/nodejs"), nil),
-		CompatibleRuntimes: &[]awslambda.Runtime{
-			awslambda.Runtime_PROVIDED_AL2(),
-		},
-	})
+	problemsTable.GrantReadWriteData(setProblemStatusLambda)
+	usersTable.GrantReadData(setProblemStatusLambda)
 
-	// Runner Lambdas
-	nodejsRunner := awscdklambdagoalpha.NewGoFunction(stack, jsii.String("nodejs-runner"), &awscdklambdagoalpha.GoFunctionProps{
-		Runtime:    awslambda.Runtime_PROVIDED_AL2(),
-		Entry:      jsii.String("lambda/runners/nodejs"),
-		ModuleDir:  jsii.String("."),
-		Timeout:    awscdk.Duration_Seconds(jsii.Number(30)),
-		MemorySize: jsii.Number(512),
-		Role:       runnerRole,
-		Layers: &[]awslambda.ILayerVersion{
-			nodejsLayer,
-		},
+	// Admins give users roles such as instructor
+	setUserRoleLambda := awscdklambdagoalpha.NewGoFunction(stack, jsii.String("SetUserRoleLambda"), &awscdklambdagoalpha.GoFunctionProps{
+		Runtime: awslambda.Runtime_PROVIDED_AL2(),
+		Entry:   jsii.String("lambda/set-user-role"),
+		Role:    lambdaRole,
 		Bundling: &awscdklambdagoalpha.BundlingOptions{
 			Environment: &map[string]*string{
 				"GOOS":   jsii.String("linux"),
@@ -266,20 +430,16 @@ func NewBackendStack(scope constructs.Construct, id string, props *BackendStackP
 			},
 		},
 		Environment: &map[string]*string{
-			"PROBLEMS_TABLE":     problemsTable.TableName(),
-			"SUBMISSIONS_TABLE":  submissionsTable.TableName(),
-			"MOMENTO_AUTH_TOKEN": jsii.String(os.Getenv("MOMENTO_AUTH_TOKEN")),
-			"USERS_TABLE":        usersTable.TableName(),
+			"USERS_TABLE": usersTable.TableName(),
 		},
 	})
 
-	pythonRunner := awscdklambdagoalpha.NewGoFunction(stack, jsii.String("python-runner"), &awscdklambdagoalpha.GoFunctionProps{
-		Runtime:    awslambda.Runtime_PROVIDED_AL2(),
-		Entry:      jsii.String("lambda/runners/python"),
-		ModuleDir:  jsii.String("."),
-		Timeout:    awscdk.Duration_Seconds(jsii.Number(30)),
-		MemorySize: jsii.Number(512),
-		Role:       runnerRole,
+	usersTable.GrantReadWriteData(setUserRoleLambda)
+
+	setProblemTranslationLambda := awscdklambdagoalpha.NewGoFunction(stack, jsii.String("SetProblemTranslationLambda"), &awscdklambdagoalpha.GoFunctionProps{
+		Runtime: awslambda.Runtime_PROVIDED_AL2(),
+		Entry:   jsii.String("lambda/set-problem-translation"),
+		Role:    lambdaRole,
 		Bundling: &awscdklambdagoalpha.BundlingOptions{
 			Environment: &map[string]*string{
 				"GOOS":   jsii.String("linux"),
@@ -287,173 +447,780 @@ func NewBackendStack(scope constructs.Construct, id string, props *BackendStackP
 			},
 		},
 		Environment: &map[string]*string{
-			"PROBLEMS_TABLE":     problemsTable.TableName(),
-			"SUBMISSIONS_TABLE":  submissionsTable.TableName(),
-			"MOMENTO_AUTH_TOKEN": jsii.String(os.Getenv("MOMENTO_AUTH_TOKEN")),
-			"USERS_TABLE":        usersTable.TableName(),
+			"PROBLEMS_TABLE": problemsTable.TableName(),
+			"USERS_TABLE":    usersTable.TableName(),
 		},
 	})
 
-	// Create Java runner
-	javaRunner := awslambda.NewFunction(stack, jsii.String("java-runner"), &awslambda.FunctionProps{
-		Runtime:    awslambda.Runtime_JAVA_11(),
-		Handler:    jsii.String("main.java.Handler::handleRequest"),
-		Code:       awslambda.Code_FromAsset(jsii.String("lambda/runners/java/build/libs/java-runner.zip"), nil),
-		Timeout:    awscdk.Duration_Seconds(jsii.Number(30)),
-		MemorySize: jsii.Number(512),
-		Role:       runnerRole,
+	problemsTable.GrantReadWriteData(setProblemTranslationLambda)
+	usersTable.GrantReadData(setProblemTranslationLambda)
+
+	reviewProblemLambda := awscdklambdagoalpha.NewGoFunction(stack, jsii.String("ReviewProblemLambda"), &awscdklambdagoalpha.GoFunctionProps{
+		Runtime: awslambda.Runtime_PROVIDED_AL2(),
+		Entry:   jsii.String("lambda/review-problem"),
+		Role:    lambdaRole,
+		Bundling: &awscdklambdagoalpha.BundlingOptions{
+			Environment: &map[string]*string{
+				"GOOS":   jsii.String("linux"),
+				"GOARCH": jsii.String("amd64"),
+			},
+		},
 		Environment: &map[string]*string{
-			"PROBLEMS_TABLE":     problemsTable.TableName(),
-			"SUBMISSIONS_TABLE":  submissionsTable.TableName(),
-			"MOMENTO_AUTH_TOKEN": jsii.String(os.Getenv("MOMENTO_AUTH_TOKEN")),
-			"USERS_TABLE":        usersTable.TableName(),
+			"PROBLEMS_TABLE": problemsTable.TableName(),
+			"USERS_TABLE":    usersTable.TableName(),
 		},
 	})
 
-	// Grant DynamoDB permissions
-	problemsTable.GrantReadData(pythonRunner)
-	submissionsTable.GrantWriteData(pythonRunner)
-	problemsTable.GrantReadData(nodejsRunner)
-	submissionsTable.GrantWriteData(nodejsRunner)
-	problemsTable.GrantReadData(javaRunner)
-	submissionsTable.GrantWriteData(javaRunner)
-
-	nodejsRunner.Role().AddManagedPolicy(
-		awsiam.ManagedPolicy_FromAwsManagedPolicyName(jsii.String("AWSLambdaExecute")),
-	)
-
-	javaRunner.Role().AddManagedPolicy(
-		awsiam.ManagedPolicy_FromAwsManagedPolicyName(jsii.String("AWSLambdaExecute")),
-	)
-
-	pythonRunner.Role().AddManagedPolicy(
-		awsiam.ManagedPolicy_FromAwsManagedPolicyName(jsii.String("AWSLambdaExecute")),
-	)
+	problemsTable.GrantReadWriteData(reviewProblemLambda)
+	usersTable.GrantReadData(reviewProblemLambda)
 
-	// Create Runners API
-	runnersApi := awscdkapigatewayv2alpha.NewHttpApi(stack, jsii.String("runners-api"), &awscdkapigatewayv2alpha.HttpApiProps{
-		CorsPreflight: &awscdkapigatewayv2alpha.CorsPreflightOptions{
-			AllowHeaders: jsii.Strings("*"),
-			AllowMethods: &[]awscdkapigatewayv2alpha.CorsHttpMethod{
-				awscdkapigatewayv2alpha.CorsHttpMethod_ANY,
+	// Get Problem Lambda
+	getProblemLambda := awscdklambdagoalpha.NewGoFunction(stack, jsii.String("GetProblemLambda"), &awscdklambdagoalpha.GoFunctionProps{
+		Runtime: awslambda.Runtime_PROVIDED_AL2(),
+		Entry:   jsii.String("lambda/get-problem"),
+		Role:    lambdaRole,
+		Bundling: &awscdklambdagoalpha.BundlingOptions{
+			Environment: &map[string]*string{
+				"GOOS":   jsii.String("linux"),
+				"GOARCH": jsii.String("amd64"),
 			},
-			AllowOrigins: jsii.Strings("*"),
+		},
+		Environment: &map[string]*string{
+			"PROBLEMS_TABLE":    problemsTable.TableName(),
+			"USERS_TABLE":       usersTable.TableName(),
+			"SUBMISSIONS_TABLE": submissionsTable.TableName(),
+			"STATS_TABLE":       statsTable.TableName(),
+			"SLUGS_TABLE":       slugsTable.TableName(),
 		},
 	})
 
-	// Add Node.js runner integration
-	runnersApi.AddRoutes(&awscdkapigatewayv2alpha.AddRoutesOptions{
-		Path: jsii.String("/runners/nodejs"),
-		Methods: &[]awscdkapigatewayv2alpha.HttpMethod{
-			awscdkapigatewayv2alpha.HttpMethod_POST,
+	// Rating job: updates user and problem ratings from new submissions
+	updateRatingsLambda := awscdklambdagoalpha.NewGoFunction(stack, jsii.String("UpdateRatingsLambda"), &awscdklambdagoalpha.GoFunctionProps{
+		Runtime: awslambda.Runtime_PROVIDED_AL2(),
+		Entry:   jsii.String("lambda/update-ratings"),
+		Role:    lambdaRole,
+		Timeout: awscdk.Duration_Minutes(jsii.Number(5)),
+		// Runs never overlap, so no submission is rated twice
+		ReservedConcurrentExecutions: jsii.Number(1),
+		Bundling: &awscdklambdagoalpha.BundlingOptions{
+			Environment: &map[string]*string{
+				"GOOS":   jsii.String("linux"),
+				"GOARCH": jsii.String("amd64"),
+			},
+		},
+		Environment: &map[string]*string{
+			"PROBLEMS_TABLE":    problemsTable.TableName(),
+			"USERS_TABLE":       usersTable.TableName(),
+			"SUBMISSIONS_TABLE": submissionsTable.TableName(),
 		},
-		Integration: awscdkapigatewayv2integrationsalpha.NewHttpLambdaIntegration(
-			jsii.String("NodejsIntegration"),
-			nodejsRunner,
-			&awscdkapigatewayv2integrationsalpha.HttpLambdaIntegrationProps{},
-		),
 	})
 
-	// Add Python runner integration
-	runnersApi.AddRoutes(&awscdkapigatewayv2alpha.AddRoutesOptions{
-		Path: jsii.String("/runners/python"),
-		Methods: &[]awscdkapigatewayv2alpha.HttpMethod{
-			awscdkapigatewayv2alpha.HttpMethod_POST,
+	awsevents.NewRule(stack, jsii.String("UpdateRatingsSchedule"), &awsevents.RuleProps{
+		Schedule: awsevents.Schedule_Rate(awscdk.Duration_Hours(jsii.Number(1))),
+		Targets: &[]awsevents.IRuleTarget{
+			awseventstargets.NewLambdaFunction(updateRatingsLambda, &awseventstargets.LambdaFunctionProps{
+				RetryAttempts: jsii.Number(0),
+			}),
 		},
-		Integration: awscdkapigatewayv2integrationsalpha.NewHttpLambdaIntegration(
-			jsii.String("PythonRunnerIntegration"),
-			pythonRunner,
-			&awscdkapigatewayv2integrationsalpha.HttpLambdaIntegrationProps{},
-		),
 	})
 
-	// Add Java runner integration
-	runnersApi.AddRoutes(&awscdkapigatewayv2alpha.AddRoutesOptions{
-		Path: jsii.String("/runners/java"),
-		Methods: &[]awscdkapigatewayv2alpha.HttpMethod{
-			awscdkapigatewayv2alpha.HttpMethod_POST,
-		},
-		Integration: awscdkapigatewayv2integrationsalpha.NewHttpLambdaIntegration(
-			jsii.String("JavaIntegration"),
-			javaRunner,
+	rejudgeProblemLambda := awscdklambdagoalpha.NewGoFunction(stack, jsii.String("RejudgeProblemLambda"), &awscdklambdagoalpha.GoFunctionProps{
+		Runtime: awslambda.Runtime_PROVIDED_AL2(),
+		Entry:   jsii.String("lambda/rejudge-problem"),
+		Role:    lambdaRole,
+		Timeout: awscdk.Duration_Minutes(jsii.Number(5)),
+		Bundling: &awscdklambdagoalpha.BundlingOptions{
+			Environment: &map[string]*string{
+				"GOOS":   jsii.String("linux"),
+				"GOARCH": jsii.String("amd64"),
+			},
+		},
+		Environment: &map[string]*string{
+			"PROBLEMS_TABLE":    problemsTable.TableName(),
+			"SUBMISSIONS_TABLE": submissionsTable.TableName(),
+			"USERS_TABLE":       usersTable.TableName(),
+			"REJUDGES_TABLE":    rejudgesTable.TableName(),
+		},
+	})
+
+	rejudgesTable.GrantReadWriteData(rejudgeProblemLambda)
+
+	getRejudgeLambda := awscdklambdagoalpha.NewGoFunction(stack, jsii.String("GetRejudgeLambda"), &awscdklambdagoalpha.GoFunctionProps{
+		Runtime: awslambda.Runtime_PROVIDED_AL2(),
+		Entry:   jsii.String("lambda/get-rejudge"),
+		Role:    lambdaRole,
+		Bundling: &awscdklambdagoalpha.BundlingOptions{
+			Environment: &map[string]*string{
+				"GOOS":   jsii.String("linux"),
+				"GOARCH": jsii.String("amd64"),
+			},
+		},
+		Environment: &map[string]*string{
+			"SUBMISSIONS_TABLE": submissionsTable.TableName(),
+			"USERS_TABLE":       usersTable.TableName(),
+			"REJUDGES_TABLE":    rejudgesTable.TableName(),
+		},
+	})
+
+	rejudgesTable.GrantReadData(getRejudgeLambda)
+
+	// Rejudge worker: republishes queued rejudge submissions a batch at a time
+	rejudgeWorkerLambda := awscdklambdagoalpha.NewGoFunction(stack, jsii.String("RejudgeWorkerLambda"), &awscdklambdagoalpha.GoFunctionProps{
+		Runtime: awslambda.Runtime_PROVIDED_AL2(),
+		Entry:   jsii.String("lambda/rejudge-worker"),
+		Role:    lambdaRole,
+		Timeout: awscdk.Duration_Minutes(jsii.Number(1)),
+		// Runs never overlap, so no submission is republished twice
+		ReservedConcurrentExecutions: jsii.Number(1),
+		Bundling: &awscdklambdagoalpha.BundlingOptions{
+			Environment: &map[string]*string{
+				"GOOS":   jsii.String("linux"),
+				"GOARCH": jsii.String("amd64"),
+			},
+		},
+		Environment: &map[string]*string{
+			"SUBMISSIONS_TABLE":  submissionsTable.TableName(),
+			"REJUDGES_TABLE":     rejudgesTable.TableName(),
+			"MOMENTO_AUTH_TOKEN": jsii.String(os.Getenv("MOMENTO_AUTH_TOKEN")),
+			"REJUDGE_BATCH_SIZE": jsii.String(os.Getenv("REJUDGE_BATCH_SIZE")),
+		},
+	})
+
+	rejudgesTable.GrantReadWriteData(rejudgeWorkerLambda)
+
+	awsevents.NewRule(stack, jsii.String("RejudgeWorkerSchedule"), &awsevents.RuleProps{
+		Schedule: awsevents.Schedule_Rate(awscdk.Duration_Minutes(jsii.Number(1))),
+		Targets: &[]awsevents.IRuleTarget{
+			awseventstargets.NewLambdaFunction(rejudgeWorkerLambda, &awseventstargets.LambdaFunctionProps{
+				RetryAttempts: jsii.Number(0),
+			}),
+		},
+	})
+
+	// Reaper: retries submissions stuck in pending or running, then gives up
+	reapSubmissionsLambda := awscdklambdagoalpha.NewGoFunction(stack, jsii.String("ReapSubmissionsLambda"), &awscdklambdagoalpha.GoFunctionProps{
+		Runtime: awslambda.Runtime_PROVIDED_AL2(),
+		Entry:   jsii.String("lambda/reap-submissions"),
+		Role:    lambdaRole,
+		Timeout: awscdk.Duration_Minutes(jsii.Number(5)),
+		// Runs never overlap, so no submission is retried twice at once
+		ReservedConcurrentExecutions: jsii.Number(1),
+		Bundling: &awscdklambdagoalpha.BundlingOptions{
+			Environment: &map[string]*string{
+				"GOOS":   jsii.String("linux"),
+				"GOARCH": jsii.String("amd64"),
+			},
+		},
+		Environment: &map[string]*string{
+			"SUBMISSIONS_TABLE":        submissionsTable.TableName(),
+			"MOMENTO_AUTH_TOKEN":       jsii.String(os.Getenv("MOMENTO_AUTH_TOKEN")),
+			"STUCK_SUBMISSION_TIMEOUT": jsii.String(os.Getenv("STUCK_SUBMISSION_TIMEOUT")),
+			"MAX_SUBMISSION_RETRIES":   jsii.String(os.Getenv("MAX_SUBMISSION_RETRIES")),
+		},
+	})
+
+	awsevents.NewRule(stack, jsii.String("ReapSubmissionsSchedule"), &awsevents.RuleProps{
+		Schedule: awsevents.Schedule_Rate(awscdk.Duration_Minutes(jsii.Number(5))),
+		Targets: &[]awsevents.IRuleTarget{
+			awseventstargets.NewLambdaFunction(reapSubmissionsLambda, &awseventstargets.LambdaFunctionProps{
+				RetryAttempts: jsii.Number(0),
+			}),
+		},
+	})
+
+	getDeadLettersLambda := awscdklambdagoalpha.NewGoFunction(stack, jsii.String("GetDeadLettersLambda"), &awscdklambdagoalpha.GoFunctionProps{
+		Runtime: awslambda.Runtime_PROVIDED_AL2(),
+		Entry:   jsii.String("lambda/get-dead-letters"),
+		Role:    lambdaRole,
+		Bundling: &awscdklambdagoalpha.BundlingOptions{
+			Environment: &map[string]*string{
+				"GOOS":   jsii.String("linux"),
+				"GOARCH": jsii.String("amd64"),
+			},
+		},
+		Environment: &map[string]*string{
+			"USERS_TABLE":        usersTable.TableName(),
+			"DEAD_LETTERS_TABLE": deadLettersTable.TableName(),
+		},
+	})
+
+	deadLettersTable.GrantReadData(getDeadLettersLambda)
+
+	replayDeadLetterLambda := awscdklambdagoalpha.NewGoFunction(stack, jsii.String("ReplayDeadLetterLambda"), &awscdklambdagoalpha.GoFunctionProps{
+		Runtime: awslambda.Runtime_PROVIDED_AL2(),
+		Entry:   jsii.String("lambda/replay-dead-letter"),
+		Role:    lambdaRole,
+		Bundling: &awscdklambdagoalpha.BundlingOptions{
+			Environment: &map[string]*string{
+				"GOOS":   jsii.String("linux"),
+				"GOARCH": jsii.String("amd64"),
+			},
+		},
+		Environment: &map[string]*string{
+			"SUBMISSIONS_TABLE":  submissionsTable.TableName(),
+			"USERS_TABLE":        usersTable.TableName(),
+			"DEAD_LETTERS_TABLE": deadLettersTable.TableName(),
+			"MOMENTO_AUTH_TOKEN": jsii.String(os.Getenv("MOMENTO_AUTH_TOKEN")),
+		},
+	})
+
+	deadLettersTable.GrantReadWriteData(replayDeadLetterLambda)
+
+	authLambda := awscdklambdagoalpha.NewGoFunction(stack, jsii.String("AuthFunction"), &awscdklambdagoalpha.GoFunctionProps{
+		Runtime: awslambda.Runtime_PROVIDED_AL2(),
+		Entry:   jsii.String("lambda/auth"),
+		Role:    lambdaRole,
+		Environment: &map[string]*string{
+			"GITHUB_CLIENT_ID": jsii.String(os.Getenv("GITHUB_CLIENT_ID")),
+			"USERS_TABLE":      usersTable.TableName(),
+		},
+	})
+
+	githubCallbackLambda := awscdklambdagoalpha.NewGoFunction(stack, jsii.String("GithubCallbackFunction"), &awscdklambdagoalpha.GoFunctionProps{
+		Runtime: awslambda.Runtime_PROVIDED_AL2(),
+		Entry:   jsii.String("lambda/github-callback"),
+		Role:    lambdaRole,
+		Environment: &map[string]*string{
+			"GITHUB_CLIENT_ID":     jsii.String(os.Getenv("GITHUB_CLIENT_ID")),
+			"GITHUB_CLIENT_SECRET": jsii.String(os.Getenv("GITHUB_CLIENT_SECRET")),
+			"FRONTEND_URL":         jsii.String(os.Getenv("FRONTEND_URL")),
+			"USERS_TABLE":          usersTable.TableName(),
+		},
+	})
+
+	// Auth verify Lambda
+	authVerifyLambda := awscdklambdagoalpha.NewGoFunction(stack, jsii.String("AuthVerifyFunction"), &awscdklambdagoalpha.GoFunctionProps{
+		Runtime: awslambda.Runtime_PROVIDED_AL2(),
+		Entry:   jsii.String("lambda/auth-verify"),
+		Role:    lambdaRole,
+		Environment: &map[string]*string{
+			"GITHUB_CLIENT_ID": jsii.String(os.Getenv("GITHUB_CLIENT_ID")),
+			"USERS_TABLE":      usersTable.TableName(),
+		},
+	})
+
+	// Create Node.js Lambda Layer
+	nodejsLayer := awslambda.NewLayerVersion(stack, jsii.String("NodejsLayer"), &awslambda.LayerVersionProps{
+		LayerVersionName: jsii.String("nodejs18"),
+		Description:      jsii.String("Node.js 18.x runtime"),
+		Code:             awslambda.Code_FromAsset(jsii.String("lambda/layers/nodejs"), nil),
+		CompatibleRuntimes: &[]awslambda.Runtime{
+			awslambda.Runtime_PROVIDED_AL2(),
+		},
+	})
+
+	// Create C++ Lambda Layer
+	cppLayer := awslambda.NewLayerVersion(stack, jsii.String("CppLayer"), &awslambda.LayerVersionProps{
+		LayerVersionName: jsii.String("gcc"),
+		Description:      jsii.String("GCC toolchain for compiling C++ submissions"),
+		Code:             awslambda.Code_FromAsset(jsii.String("lambda/layers/cpp"), nil),
+		CompatibleRuntimes: &[]awslambda.Runtime{
+			awslambda.Runtime_PROVIDED_AL2(),
+		},
+	})
+
+	// Create Java Lambda Layer
+	javaLayer := awslambda.NewLayerVersion(stack, jsii.String("JavaLayer"), &awslambda.LayerVersionProps{
+		LayerVersionName: jsii.String("jdk17"),
+		Description:      jsii.String("JDK 17 for compiling and running Java submissions"),
+		Code:             awslambda.Code_FromAsset(jsii.String("lambda/layers/java"), nil),
+		CompatibleRuntimes: &[]awslambda.Runtime{
+			awslambda.Runtime_PROVIDED_AL2(),
+		},
+	})
+
+	// Runner Lambdas
+	nodejsRunner := awscdklambdagoalpha.NewGoFunction(stack, jsii.String("nodejs-runner"), &awscdklambdagoalpha.GoFunctionProps{
+		Runtime:    awslambda.Runtime_PROVIDED_AL2(),
+		Entry:      jsii.String("lambda/runners/nodejs"),
+		ModuleDir:  jsii.String("."),
+		Timeout:    awscdk.Duration_Seconds(jsii.Number(30)),
+		MemorySize: jsii.Number(512),
+		Role:       runnerRole,
+		Layers: &[]awslambda.ILayerVersion{
+			nodejsLayer,
+		},
+		Bundling: &awscdklambdagoalpha.BundlingOptions{
+			Environment: &map[string]*string{
+				"GOOS":   jsii.String("linux"),
+				"GOARCH": jsii.String("amd64"),
+			},
+		},
+		Environment: &map[string]*string{
+			"PROBLEMS_TABLE":     problemsTable.TableName(),
+			"SUBMISSIONS_TABLE":  submissionsTable.TableName(),
+			"MOMENTO_AUTH_TOKEN": jsii.String(os.Getenv("MOMENTO_AUTH_TOKEN")),
+			"USERS_TABLE":        usersTable.TableName(),
+			"TESTDATA_BUCKET":    testDataBucket.BucketName(),
+			"RUNNER_SECRET":      jsii.String(os.Getenv("RUNNER_SECRET")),
+			"STATS_TABLE":        statsTable.TableName(),
+		},
+	})
+
+	pythonRunner := awscdklambdagoalpha.NewGoFunction(stack, jsii.String("python-runner"), &awscdklambdagoalpha.GoFunctionProps{
+		Runtime:    awslambda.Runtime_PROVIDED_AL2(),
+		Entry:      jsii.String("lambda/runners/python"),
+		ModuleDir:  jsii.String("."),
+		Timeout:    awscdk.Duration_Seconds(jsii.Number(30)),
+		MemorySize: jsii.Number(512),
+		Role:       runnerRole,
+		Bundling: &awscdklambdagoalpha.BundlingOptions{
+			Environment: &map[string]*string{
+				"GOOS":   jsii.String("linux"),
+				"GOARCH": jsii.String("amd64"),
+			},
+		},
+		Environment: &map[string]*string{
+			"PROBLEMS_TABLE":     problemsTable.TableName(),
+			"SUBMISSIONS_TABLE":  submissionsTable.TableName(),
+			"MOMENTO_AUTH_TOKEN": jsii.String(os.Getenv("MOMENTO_AUTH_TOKEN")),
+			"USERS_TABLE":        usersTable.TableName(),
+			"TESTDATA_BUCKET":    testDataBucket.BucketName(),
+			"RUNNER_SECRET":      jsii.String(os.Getenv("RUNNER_SECRET")),
+			"STATS_TABLE":        statsTable.TableName(),
+		},
+	})
+
+	cppRunner := awscdklambdagoalpha.NewGoFunction(stack, jsii.String("cpp-runner"), &awscdklambdagoalpha.GoFunctionProps{
+		Runtime:    awslambda.Runtime_PROVIDED_AL2(),
+		Entry:      jsii.String("lambda/runners/cpp"),
+		ModuleDir:  jsii.String("."),
+		Timeout:    awscdk.Duration_Seconds(jsii.Number(30)),
+		MemorySize: jsii.Number(1024),
+		Role:       runnerRole,
+		Layers: &[]awslambda.ILayerVersion{
+			cppLayer,
+		},
+		Bundling: &awscdklambdagoalpha.BundlingOptions{
+			Environment: &map[string]*string{
+				"GOOS":   jsii.String("linux"),
+				"GOARCH": jsii.String("amd64"),
+			},
+		},
+		Environment: &map[string]*string{
+			"PROBLEMS_TABLE":     problemsTable.TableName(),
+			"SUBMISSIONS_TABLE":  submissionsTable.TableName(),
+			"MOMENTO_AUTH_TOKEN": jsii.String(os.Getenv("MOMENTO_AUTH_TOKEN")),
+			"USERS_TABLE":        usersTable.TableName(),
+			"TESTDATA_BUCKET":    testDataBucket.BucketName(),
+			"RUNNER_SECRET":      jsii.String(os.Getenv("RUNNER_SECRET")),
+			"STATS_TABLE":        statsTable.TableName(),
+		},
+	})
+
+	// SQL queries run against SQLite embedded in the runner itself
+	sqlRunner := awscdklambdagoalpha.NewGoFunction(stack, jsii.String("sql-runner"), &awscdklambdagoalpha.GoFunctionProps{
+		Runtime:    awslambda.Runtime_PROVIDED_AL2(),
+		Entry:      jsii.String("lambda/runners/sql"),
+		ModuleDir:  jsii.String("."),
+		Timeout:    awscdk.Duration_Seconds(jsii.Number(30)),
+		MemorySize: jsii.Number(512),
+		Role:       runnerRole,
+		Bundling: &awscdklambdagoalpha.BundlingOptions{
+			Environment: &map[string]*string{
+				"GOOS":   jsii.String("linux"),
+				"GOARCH": jsii.String("amd64"),
+			},
+		},
+		Environment: &map[string]*string{
+			"PROBLEMS_TABLE":     problemsTable.TableName(),
+			"SUBMISSIONS_TABLE":  submissionsTable.TableName(),
+			"MOMENTO_AUTH_TOKEN": jsii.String(os.Getenv("MOMENTO_AUTH_TOKEN")),
+			"USERS_TABLE":        usersTable.TableName(),
+			"TESTDATA_BUCKET":    testDataBucket.BucketName(),
+			"RUNNER_SECRET":      jsii.String(os.Getenv("RUNNER_SECRET")),
+			"STATS_TABLE":        statsTable.TableName(),
+		},
+	})
+
+	javaRunner := awscdklambdagoalpha.NewGoFunction(stack, jsii.String("java-runner"), &awscdklambdagoalpha.GoFunctionProps{
+		Runtime:    awslambda.Runtime_PROVIDED_AL2(),
+		Entry:      jsii.String("lambda/runners/java"),
+		ModuleDir:  jsii.String("."),
+		Timeout:    awscdk.Duration_Seconds(jsii.Number(30)),
+		MemorySize: jsii.Number(1024),
+		Role:       runnerRole,
+		Layers: &[]awslambda.ILayerVersion{
+			javaLayer,
+		},
+		Bundling: &awscdklambdagoalpha.BundlingOptions{
+			Environment: &map[string]*string{
+				"GOOS":   jsii.String("linux"),
+				"GOARCH": jsii.String("amd64"),
+			},
+		},
+		Environment: &map[string]*string{
+			"PROBLEMS_TABLE":     problemsTable.TableName(),
+			"SUBMISSIONS_TABLE":  submissionsTable.TableName(),
+			"MOMENTO_AUTH_TOKEN": jsii.String(os.Getenv("MOMENTO_AUTH_TOKEN")),
+			"USERS_TABLE":        usersTable.TableName(),
+			"TESTDATA_BUCKET":    testDataBucket.BucketName(),
+			"RUNNER_SECRET":      jsii.String(os.Getenv("RUNNER_SECRET")),
+			"STATS_TABLE":        statsTable.TableName(),
+		},
+	})
+
+	// Grant DynamoDB permissions
+	problemsTable.GrantReadData(pythonRunner)
+	submissionsTable.GrantWriteData(pythonRunner)
+	problemsTable.GrantReadData(nodejsRunner)
+	submissionsTable.GrantWriteData(nodejsRunner)
+	problemsTable.GrantReadData(javaRunner)
+	submissionsTable.GrantWriteData(javaRunner)
+	testDataBucket.GrantRead(javaRunner, nil)
+	testDataBucket.GrantRead(pythonRunner, nil)
+	testDataBucket.GrantRead(nodejsRunner, nil)
+	problemsTable.GrantReadData(cppRunner)
+	submissionsTable.GrantWriteData(cppRunner)
+	testDataBucket.GrantRead(cppRunner, nil)
+	problemsTable.GrantReadData(sqlRunner)
+	submissionsTable.GrantWriteData(sqlRunner)
+	testDataBucket.GrantRead(sqlRunner, nil)
+
+	// Runners count verdicts and mark problems solved for their users
+	for _, runner := range []awslambda.IFunction{pythonRunner, nodejsRunner, cppRunner, javaRunner, sqlRunner} {
+		statsTable.GrantReadWriteData(runner)
+		usersTable.GrantReadWriteData(runner)
+	}
+
+	// Runners dead-letter jobs they give up on
+	for _, runner := range []awscdklambdagoalpha.GoFunction{pythonRunner, nodejsRunner, cppRunner, javaRunner, sqlRunner} {
+		runner.AddEnvironment(jsii.String("DEAD_LETTERS_TABLE"), deadLettersTable.TableName(), nil)
+		deadLettersTable.GrantWriteData(runner)
+	}
+
+	nodejsRunner.Role().AddManagedPolicy(
+		awsiam.ManagedPolicy_FromAwsManagedPolicyName(jsii.String("AWSLambdaExecute")),
+	)
+
+	javaRunner.Role().AddManagedPolicy(
+		awsiam.ManagedPolicy_FromAwsManagedPolicyName(jsii.String("AWSLambdaExecute")),
+	)
+
+	pythonRunner.Role().AddManagedPolicy(
+		awsiam.ManagedPolicy_FromAwsManagedPolicyName(jsii.String("AWSLambdaExecute")),
+	)
+
+	cppRunner.Role().AddManagedPolicy(
+		awsiam.ManagedPolicy_FromAwsManagedPolicyName(jsii.String("AWSLambdaExecute")),
+	)
+
+	sqlRunner.Role().AddManagedPolicy(
+		awsiam.ManagedPolicy_FromAwsManagedPolicyName(jsii.String("AWSLambdaExecute")),
+	)
+
+	// Create Runners API
+	runnersApi := awscdkapigatewayv2alpha.NewHttpApi(stack, jsii.String("runners-api"), &awscdkapigatewayv2alpha.HttpApiProps{
+		CorsPreflight: &awscdkapigatewayv2alpha.CorsPreflightOptions{
+			AllowHeaders: jsii.Strings("*"),
+			AllowMethods: &[]awscdkapigatewayv2alpha.CorsHttpMethod{
+				awscdkapigatewayv2alpha.CorsHttpMethod_ANY,
+			},
+			AllowOrigins: jsii.Strings("*"),
+		},
+	})
+
+	// Add Node.js runner integration
+	runnersApi.AddRoutes(&awscdkapigatewayv2alpha.AddRoutesOptions{
+		Path: jsii.String("/runners/nodejs"),
+		Methods: &[]awscdkapigatewayv2alpha.HttpMethod{
+			awscdkapigatewayv2alpha.HttpMethod_POST,
+		},
+		Integration: awscdkapigatewayv2integrationsalpha.NewHttpLambdaIntegration(
+			jsii.String("NodejsIntegration"),
+			nodejsRunner,
+			&awscdkapigatewayv2integrationsalpha.HttpLambdaIntegrationProps{},
+		),
+	})
+
+	// Add Python runner integration
+	runnersApi.AddRoutes(&awscdkapigatewayv2alpha.AddRoutesOptions{
+		Path: jsii.String("/runners/python"),
+		Methods: &[]awscdkapigatewayv2alpha.HttpMethod{
+			awscdkapigatewayv2alpha.HttpMethod_POST,
+		},
+		Integration: awscdkapigatewayv2integrationsalpha.NewHttpLambdaIntegration(
+			jsii.String("PythonRunnerIntegration"),
+			pythonRunner,
+			&awscdkapigatewayv2integrationsalpha.HttpLambdaIntegrationProps{},
+		),
+	})
+
+	// Add C++ runner integration
+	runnersApi.AddRoutes(&awscdkapigatewayv2alpha.AddRoutesOptions{
+		Path: jsii.String("/runners/cpp"),
+		Methods: &[]awscdkapigatewayv2alpha.HttpMethod{
+			awscdkapigatewayv2alpha.HttpMethod_POST,
+		},
+		Integration: awscdkapigatewayv2integrationsalpha.NewHttpLambdaIntegration(
+			jsii.String("CppRunnerIntegration"),
+			cppRunner,
+			&awscdkapigatewayv2integrationsalpha.HttpLambdaIntegrationProps{},
+		),
+	})
+
+	// Add SQL runner integration
+	runnersApi.AddRoutes(&awscdkapigatewayv2alpha.AddRoutesOptions{
+		Path: jsii.String("/runners/sql"),
+		Methods: &[]awscdkapigatewayv2alpha.HttpMethod{
+			awscdkapigatewayv2alpha.HttpMethod_POST,
+		},
+		Integration: awscdkapigatewayv2integrationsalpha.NewHttpLambdaIntegration(
+			jsii.String("SqlRunnerIntegration"),
+			sqlRunner,
+			&awscdkapigatewayv2integrationsalpha.HttpLambdaIntegrationProps{},
+		),
+	})
+
+	// Add Java runner integration
+	runnersApi.AddRoutes(&awscdkapigatewayv2alpha.AddRoutesOptions{
+		Path: jsii.String("/runners/java"),
+		Methods: &[]awscdkapigatewayv2alpha.HttpMethod{
+			awscdkapigatewayv2alpha.HttpMethod_POST,
+		},
+		Integration: awscdkapigatewayv2integrationsalpha.NewHttpLambdaIntegration(
+			jsii.String("JavaIntegration"),
+			javaRunner,
+			&awscdkapigatewayv2integrationsalpha.HttpLambdaIntegrationProps{},
+		),
+	})
+
+	// Reference solutions and input validators are run synchronously through
+	// the runners when a problem is saved
+	for _, runner := range []struct {
+		language string
+		function awslambda.IFunction
+	}{
+		{"python", pythonRunner},
+		{"nodejs", nodejsRunner},
+		{"cpp", cppRunner},
+		{"java", javaRunner},
+		{"sql", sqlRunner},
+	} {
+		runnersApi.AddRoutes(&awscdkapigatewayv2alpha.AddRoutesOptions{
+			Path: jsii.String("/runners/" + runner.language + "/validate"),
+			Methods: &[]awscdkapigatewayv2alpha.HttpMethod{
+				awscdkapigatewayv2alpha.HttpMethod_POST,
+			},
+			Integration: awscdkapigatewayv2integrationsalpha.NewHttpLambdaIntegration(
+				jsii.String(runner.language+"ValidateIntegration"),
+				runner.function,
+				&awscdkapigatewayv2integrationsalpha.HttpLambdaIntegrationProps{},
+			),
+		})
+	}
+
+	addProblemLambda.AddEnvironment(jsii.String("RUNNERS_API_URL"), runnersApi.Url(), nil)
+	importProblemLambda.AddEnvironment(jsii.String("RUNNERS_API_URL"), runnersApi.Url(), nil)
+	updateProblemLambda.AddEnvironment(jsii.String("RUNNERS_API_URL"), runnersApi.Url(), nil)
+
+	// HTTP API
+	httpApi := awscdkapigatewayv2alpha.NewHttpApi(stack, jsii.String("LearnCodeApi"), &awscdkapigatewayv2alpha.HttpApiProps{
+		ApiName: jsii.String("LearnCode API"),
+		CorsPreflight: &awscdkapigatewayv2alpha.CorsPreflightOptions{
+			AllowHeaders:  jsii.Strings("Authorization", "Content-Type", "Idempotency-Key"),
+			ExposeHeaders: jsii.Strings("Retry-After", "Idempotent-Replayed"),
+			AllowMethods: &[]awscdkapigatewayv2alpha.CorsHttpMethod{
+				awscdkapigatewayv2alpha.CorsHttpMethod_GET,
+				awscdkapigatewayv2alpha.CorsHttpMethod_POST,
+				awscdkapigatewayv2alpha.CorsHttpMethod_DELETE,
+				awscdkapigatewayv2alpha.CorsHttpMethod_PUT,
+				awscdkapigatewayv2alpha.CorsHttpMethod_OPTIONS,
+				awscdkapigatewayv2alpha.CorsHttpMethod_PATCH,
+			},
+			AllowOrigins: jsii.Strings("*"),
+		},
+	})
+
+	// Routes for GitHub auth
+	httpApi.AddRoutes(&awscdkapigatewayv2alpha.AddRoutesOptions{
+		Path: jsii.String("/auth/github"),
+		Methods: &[]awscdkapigatewayv2alpha.HttpMethod{
+			awscdkapigatewayv2alpha.HttpMethod_GET,
+		},
+		Integration: awscdkapigatewayv2integrationsalpha.NewHttpLambdaIntegration(
+			jsii.String("AuthIntegration"),
+			authLambda,
+			&awscdkapigatewayv2integrationsalpha.HttpLambdaIntegrationProps{},
+		),
+	})
+
+	httpApi.AddRoutes(&awscdkapigatewayv2alpha.AddRoutesOptions{
+		Path: jsii.String("/auth/github/callback"),
+		Methods: &[]awscdkapigatewayv2alpha.HttpMethod{
+			awscdkapigatewayv2alpha.HttpMethod_GET,
+		},
+		Integration: awscdkapigatewayv2integrationsalpha.NewHttpLambdaIntegration(
+			jsii.String("GithubCallbackIntegration"),
+			githubCallbackLambda,
+			&awscdkapigatewayv2integrationsalpha.HttpLambdaIntegrationProps{},
+		),
+	})
+
+	httpApi.AddRoutes(&awscdkapigatewayv2alpha.AddRoutesOptions{
+		Path: jsii.String("/problems"),
+		Methods: &[]awscdkapigatewayv2alpha.HttpMethod{
+			awscdkapigatewayv2alpha.HttpMethod_GET,
+		},
+		Integration: awscdkapigatewayv2integrationsalpha.NewHttpLambdaIntegration(
+			jsii.String("GetProblemsIntegration"),
+			getProblemsLambda,
+			&awscdkapigatewayv2integrationsalpha.HttpLambdaIntegrationProps{},
+		),
+	})
+
+	httpApi.AddRoutes(&awscdkapigatewayv2alpha.AddRoutesOptions{
+		Path: jsii.String("/admin/add"),
+		Methods: &[]awscdkapigatewayv2alpha.HttpMethod{
+			awscdkapigatewayv2alpha.HttpMethod_POST,
+		},
+		Integration: awscdkapigatewayv2integrationsalpha.NewHttpLambdaIntegration(
+			jsii.String("AddProblemIntegration"),
+			addProblemLambda,
+			&awscdkapigatewayv2integrationsalpha.HttpLambdaIntegrationProps{},
+		),
+	})
+
+	httpApi.AddRoutes(&awscdkapigatewayv2alpha.AddRoutesOptions{
+		Path: jsii.String("/admin/problems/{id}"),
+		Methods: &[]awscdkapigatewayv2alpha.HttpMethod{
+			awscdkapigatewayv2alpha.HttpMethod_DELETE,
+		},
+		Integration: awscdkapigatewayv2integrationsalpha.NewHttpLambdaIntegration(
+			jsii.String("DeleteProblemIntegration"),
+			deleteProblemLambda,
+			&awscdkapigatewayv2integrationsalpha.HttpLambdaIntegrationProps{},
+		),
+	})
+
+	httpApi.AddRoutes(&awscdkapigatewayv2alpha.AddRoutesOptions{
+		Path: jsii.String("/admin/problems/{id}"),
+		Methods: &[]awscdkapigatewayv2alpha.HttpMethod{
+			awscdkapigatewayv2alpha.HttpMethod_PUT,
+		},
+		Integration: awscdkapigatewayv2integrationsalpha.NewHttpLambdaIntegration(
+			jsii.String("UpdateProblemIntegration"),
+			updateProblemLambda,
+			&awscdkapigatewayv2integrationsalpha.HttpLambdaIntegrationProps{},
+		),
+	})
+
+	httpApi.AddRoutes(&awscdkapigatewayv2alpha.AddRoutesOptions{
+		Path: jsii.String("/admin/problems/import"),
+		Methods: &[]awscdkapigatewayv2alpha.HttpMethod{
+			awscdkapigatewayv2alpha.HttpMethod_POST,
+		},
+		Integration: awscdkapigatewayv2integrationsalpha.NewHttpLambdaIntegration(
+			jsii.String("ImportProblemIntegration"),
+			importProblemLambda,
+			&awscdkapigatewayv2integrationsalpha.HttpLambdaIntegrationProps{},
+		),
+	})
+
+	httpApi.AddRoutes(&awscdkapigatewayv2alpha.AddRoutesOptions{
+		Path: jsii.String("/admin/problems/{id}/export"),
+		Methods: &[]awscdkapigatewayv2alpha.HttpMethod{
+			awscdkapigatewayv2alpha.HttpMethod_GET,
+		},
+		Integration: awscdkapigatewayv2integrationsalpha.NewHttpLambdaIntegration(
+			jsii.String("ExportProblemIntegration"),
+			exportProblemLambda,
+			&awscdkapigatewayv2integrationsalpha.HttpLambdaIntegrationProps{},
+		),
+	})
+
+	httpApi.AddRoutes(&awscdkapigatewayv2alpha.AddRoutesOptions{
+		Path: jsii.String("/admin/problems/{id}/status"),
+		Methods: &[]awscdkapigatewayv2alpha.HttpMethod{
+			awscdkapigatewayv2alpha.HttpMethod_PUT,
+		},
+		Integration: awscdkapigatewayv2integrationsalpha.NewHttpLambdaIntegration(
+			jsii.String("SetProblemStatusIntegration"),
+			setProblemStatusLambda,
+			&awscdkapigatewayv2integrationsalpha.HttpLambdaIntegrationProps{},
+		),
+	})
+
+	httpApi.AddRoutes(&awscdkapigatewayv2alpha.AddRoutesOptions{
+		Path: jsii.String("/admin/users/{id}/role"),
+		Methods: &[]awscdkapigatewayv2alpha.HttpMethod{
+			awscdkapigatewayv2alpha.HttpMethod_PUT,
+		},
+		Integration: awscdkapigatewayv2integrationsalpha.NewHttpLambdaIntegration(
+			jsii.String("SetUserRoleIntegration"),
+			setUserRoleLambda,
 			&awscdkapigatewayv2integrationsalpha.HttpLambdaIntegrationProps{},
 		),
 	})
 
-	// HTTP API
-	httpApi := awscdkapigatewayv2alpha.NewHttpApi(stack, jsii.String("LearnCodeApi"), &awscdkapigatewayv2alpha.HttpApiProps{
-		ApiName: jsii.String("LearnCode API"),
-		CorsPreflight: &awscdkapigatewayv2alpha.CorsPreflightOptions{
-			AllowHeaders: jsii.Strings("Authorization", "Content-Type"),
-			AllowMethods: &[]awscdkapigatewayv2alpha.CorsHttpMethod{
-				awscdkapigatewayv2alpha.CorsHttpMethod_GET,
-				awscdkapigatewayv2alpha.CorsHttpMethod_POST,
-				awscdkapigatewayv2alpha.CorsHttpMethod_DELETE,
-				awscdkapigatewayv2alpha.CorsHttpMethod_PUT,
-				awscdkapigatewayv2alpha.CorsHttpMethod_OPTIONS,
-				awscdkapigatewayv2alpha.CorsHttpMethod_PATCH,
-			},
-			AllowOrigins: jsii.Strings("*"),
+	httpApi.AddRoutes(&awscdkapigatewayv2alpha.AddRoutesOptions{
+		Path: jsii.String("/admin/problems/{id}/translations/{locale}"),
+		Methods: &[]awscdkapigatewayv2alpha.HttpMethod{
+			awscdkapigatewayv2alpha.HttpMethod_PUT,
 		},
+		Integration: awscdkapigatewayv2integrationsalpha.NewHttpLambdaIntegration(
+			jsii.String("SetProblemTranslationIntegration"),
+			setProblemTranslationLambda,
+			&awscdkapigatewayv2integrationsalpha.HttpLambdaIntegrationProps{},
+		),
 	})
 
-	// Routes for GitHub auth
 	httpApi.AddRoutes(&awscdkapigatewayv2alpha.AddRoutesOptions{
-		Path: jsii.String("/auth/github"),
+		Path: jsii.String("/admin/problems/{id}/reviews"),
 		Methods: &[]awscdkapigatewayv2alpha.HttpMethod{
-			awscdkapigatewayv2alpha.HttpMethod_GET,
+			awscdkapigatewayv2alpha.HttpMethod_POST,
 		},
 		Integration: awscdkapigatewayv2integrationsalpha.NewHttpLambdaIntegration(
-			jsii.String("AuthIntegration"),
-			authLambda,
+			jsii.String("ReviewProblemIntegration"),
+			reviewProblemLambda,
 			&awscdkapigatewayv2integrationsalpha.HttpLambdaIntegrationProps{},
 		),
 	})
 
 	httpApi.AddRoutes(&awscdkapigatewayv2alpha.AddRoutesOptions{
-		Path: jsii.String("/auth/github/callback"),
+		Path: jsii.String("/admin/problems/{id}/rejudge"),
 		Methods: &[]awscdkapigatewayv2alpha.HttpMethod{
-			awscdkapigatewayv2alpha.HttpMethod_GET,
+			awscdkapigatewayv2alpha.HttpMethod_POST,
 		},
 		Integration: awscdkapigatewayv2integrationsalpha.NewHttpLambdaIntegration(
-			jsii.String("GithubCallbackIntegration"),
-			githubCallbackLambda,
+			jsii.String("RejudgeProblemIntegration"),
+			rejudgeProblemLambda,
 			&awscdkapigatewayv2integrationsalpha.HttpLambdaIntegrationProps{},
 		),
 	})
 
 	httpApi.AddRoutes(&awscdkapigatewayv2alpha.AddRoutesOptions{
-		Path: jsii.String("/problems"),
+		Path: jsii.String("/admin/rejudges/{id}"),
 		Methods: &[]awscdkapigatewayv2alpha.HttpMethod{
 			awscdkapigatewayv2alpha.HttpMethod_GET,
 		},
 		Integration: awscdkapigatewayv2integrationsalpha.NewHttpLambdaIntegration(
-			jsii.String("GetProblemsIntegration"),
-			getProblemsLambda,
+			jsii.String("GetRejudgeIntegration"),
+			getRejudgeLambda,
 			&awscdkapigatewayv2integrationsalpha.HttpLambdaIntegrationProps{},
 		),
 	})
 
 	httpApi.AddRoutes(&awscdkapigatewayv2alpha.AddRoutesOptions{
-		Path: jsii.String("/admin/add"),
+		Path: jsii.String("/admin/dead-letters"),
 		Methods: &[]awscdkapigatewayv2alpha.HttpMethod{
-			awscdkapigatewayv2alpha.HttpMethod_POST,
+			awscdkapigatewayv2alpha.HttpMethod_GET,
 		},
 		Integration: awscdkapigatewayv2integrationsalpha.NewHttpLambdaIntegration(
-			jsii.String("AddProblemIntegration"),
-			addProblemLambda,
+			jsii.String("GetDeadLettersIntegration"),
+			getDeadLettersLambda,
 			&awscdkapigatewayv2integrationsalpha.HttpLambdaIntegrationProps{},
 		),
 	})
 
 	httpApi.AddRoutes(&awscdkapigatewayv2alpha.AddRoutesOptions{
-		Path: jsii.String("/admin/problems/{id}"),
+		Path: jsii.String("/admin/dead-letters/{id}/replay"),
 		Methods: &[]awscdkapigatewayv2alpha.HttpMethod{
-			awscdkapigatewayv2alpha.HttpMethod_DELETE,
+			awscdkapigatewayv2alpha.HttpMethod_POST,
 		},
 		Integration: awscdkapigatewayv2integrationsalpha.NewHttpLambdaIntegration(
-			jsii.String("DeleteProblemIntegration"),
-			deleteProblemLambda,
+			jsii.String("ReplayDeadLetterIntegration"),
+			replayDeadLetterLambda,
 			&awscdkapigatewayv2integrationsalpha.HttpLambdaIntegrationProps{},
 		),
 	})
@@ -526,6 +1293,296 @@ func NewBackendStack(scope constructs.Construct, id string, props *BackendStackP
 		),
 	})
 
+	getUserSubmissionsLambda := awscdklambdagoalpha.NewGoFunction(stack, jsii.String("GetUserSubmissionsLambda"), &awscdklambdagoalpha.GoFunctionProps{
+		Runtime: awslambda.Runtime_PROVIDED_AL2(),
+		Entry:   jsii.String("lambda/get-user-submissions"),
+		Role:    lambdaRole,
+		Bundling: &awscdklambdagoalpha.BundlingOptions{
+			Environment: &map[string]*string{
+				"GOOS":   jsii.String("linux"),
+				"GOARCH": jsii.String("amd64"),
+			},
+		},
+		Timeout: awscdk.Duration_Seconds(jsii.Number(30)),
+		Environment: &map[string]*string{
+			"SUBMISSIONS_TABLE": submissionsTable.TableName(),
+			"USERS_TABLE":       usersTable.TableName(),
+		},
+	})
+
+	submissionsTable.GrantReadData(getUserSubmissionsLambda)
+	usersTable.GrantReadData(getUserSubmissionsLambda)
+
+	httpApi.AddRoutes(&awscdkapigatewayv2alpha.AddRoutesOptions{
+		Path:    jsii.String("/me/submissions"),
+		Methods: &[]awscdkapigatewayv2alpha.HttpMethod{awscdkapigatewayv2alpha.HttpMethod_GET},
+		Integration: awscdkapigatewayv2integrationsalpha.NewHttpLambdaIntegration(
+			jsii.String("GetUserSubmissionsIntegration"),
+			getUserSubmissionsLambda,
+			&awscdkapigatewayv2integrationsalpha.HttpLambdaIntegrationProps{},
+		),
+	})
+
+	// Hands out Momento tokens for the caller's submission events topic
+	getSubmissionEventsLambda := awscdklambdagoalpha.NewGoFunction(stack, jsii.String("GetSubmissionEventsLambda"), &awscdklambdagoalpha.GoFunctionProps{
+		Runtime: awslambda.Runtime_PROVIDED_AL2(),
+		Entry:   jsii.String("lambda/get-submission-events"),
+		Role:    lambdaRole,
+		Bundling: &awscdklambdagoalpha.BundlingOptions{
+			Environment: &map[string]*string{
+				"GOOS":   jsii.String("linux"),
+				"GOARCH": jsii.String("amd64"),
+			},
+		},
+		Environment: &map[string]*string{
+			"MOMENTO_AUTH_TOKEN": jsii.String(os.Getenv("MOMENTO_AUTH_TOKEN")),
+			"USERS_TABLE":        usersTable.TableName(),
+		},
+	})
+
+	usersTable.GrantReadData(getSubmissionEventsLambda)
+
+	httpApi.AddRoutes(&awscdkapigatewayv2alpha.AddRoutesOptions{
+		Path:    jsii.String("/me/submissions/events"),
+		Methods: &[]awscdkapigatewayv2alpha.HttpMethod{awscdkapigatewayv2alpha.HttpMethod_GET},
+		Integration: awscdkapigatewayv2integrationsalpha.NewHttpLambdaIntegration(
+			jsii.String("GetSubmissionEventsIntegration"),
+			getSubmissionEventsLambda,
+			&awscdkapigatewayv2integrationsalpha.HttpLambdaIntegrationProps{},
+		),
+	})
+
+	getSubmissionByIDLambda := awscdklambdagoalpha.NewGoFunction(stack, jsii.String("GetSubmissionByIDLambda"), &awscdklambdagoalpha.GoFunctionProps{
+		Runtime: awslambda.Runtime_PROVIDED_AL2(),
+		Entry:   jsii.String("lambda/get-submission-by-id"),
+		Role:    lambdaRole,
+		Bundling: &awscdklambdagoalpha.BundlingOptions{
+			Environment: &map[string]*string{
+				"GOOS":   jsii.String("linux"),
+				"GOARCH": jsii.String("amd64"),
+			},
+		},
+		Environment: &map[string]*string{
+			"SUBMISSIONS_TABLE": submissionsTable.TableName(),
+			"USERS_TABLE":       usersTable.TableName(),
+		},
+	})
+
+	submissionsTable.GrantReadData(getSubmissionByIDLambda)
+	usersTable.GrantReadData(getSubmissionByIDLambda)
+
+	httpApi.AddRoutes(&awscdkapigatewayv2alpha.AddRoutesOptions{
+		Path:    jsii.String("/submissions/{id}"),
+		Methods: &[]awscdkapigatewayv2alpha.HttpMethod{awscdkapigatewayv2alpha.HttpMethod_GET},
+		Integration: awscdkapigatewayv2integrationsalpha.NewHttpLambdaIntegration(
+			jsii.String("GetSubmissionByIDIntegration"),
+			getSubmissionByIDLambda,
+			&awscdkapigatewayv2integrationsalpha.HttpLambdaIntegrationProps{},
+		),
+	})
+
+	setSubmissionVisibilityLambda := awscdklambdagoalpha.NewGoFunction(stack, jsii.String("SetSubmissionVisibilityLambda"), &awscdklambdagoalpha.GoFunctionProps{
+		Runtime: awslambda.Runtime_PROVIDED_AL2(),
+		Entry:   jsii.String("lambda/set-submission-visibility"),
+		Role:    lambdaRole,
+		Bundling: &awscdklambdagoalpha.BundlingOptions{
+			Environment: &map[string]*string{
+				"GOOS":   jsii.String("linux"),
+				"GOARCH": jsii.String("amd64"),
+			},
+		},
+		Environment: &map[string]*string{
+			"SUBMISSIONS_TABLE": submissionsTable.TableName(),
+			"USERS_TABLE":       usersTable.TableName(),
+		},
+	})
+
+	submissionsTable.GrantReadWriteData(setSubmissionVisibilityLambda)
+	usersTable.GrantReadData(setSubmissionVisibilityLambda)
+
+	httpApi.AddRoutes(&awscdkapigatewayv2alpha.AddRoutesOptions{
+		Path:    jsii.String("/submissions/{id}/visibility"),
+		Methods: &[]awscdkapigatewayv2alpha.HttpMethod{awscdkapigatewayv2alpha.HttpMethod_PUT},
+		Integration: awscdkapigatewayv2integrationsalpha.NewHttpLambdaIntegration(
+			jsii.String("SetSubmissionVisibilityIntegration"),
+			setSubmissionVisibilityLambda,
+			&awscdkapigatewayv2integrationsalpha.HttpLambdaIntegrationProps{},
+		),
+	})
+
+	// Collections Lambdas
+	collectionsTable := awsdynamodb.NewTable(stack, jsii.String("Collections"), &awsdynamodb.TableProps{
+		PartitionKey: &awsdynamodb.Attribute{
+			Name: jsii.String("id"),
+			Type: awsdynamodb.AttributeType_STRING,
+		},
+		BillingMode: awsdynamodb.BillingMode_PAY_PER_REQUEST,
+		TableName:   jsii.String("Collections"),
+	})
+
+	collectionEnvironment := map[string]*string{
+		"COLLECTIONS_TABLE": collectionsTable.TableName(),
+		"PROBLEMS_TABLE":    problemsTable.TableName(),
+		"USERS_TABLE":       usersTable.TableName(),
+		"SUBMISSIONS_TABLE": submissionsTable.TableName(),
+	}
+
+	createCollectionLambda := awscdklambdagoalpha.NewGoFunction(stack, jsii.String("CreateCollectionLambda"), &awscdklambdagoalpha.GoFunctionProps{
+		Runtime: awslambda.Runtime_PROVIDED_AL2(),
+		Entry:   jsii.String("lambda/create-collection"),
+		Role:    lambdaRole,
+		Bundling: &awscdklambdagoalpha.BundlingOptions{
+			Environment: &map[string]*string{
+				"GOOS":   jsii.String("linux"),
+				"GOARCH": jsii.String("amd64"),
+			},
+		},
+		Environment: &collectionEnvironment,
+	})
+	collectionsTable.GrantReadWriteData(createCollectionLambda)
+
+	httpApi.AddRoutes(&awscdkapigatewayv2alpha.AddRoutesOptions{
+		Path: jsii.String("/collections"),
+		Methods: &[]awscdkapigatewayv2alpha.HttpMethod{
+			awscdkapigatewayv2alpha.HttpMethod_POST,
+		},
+		Integration: awscdkapigatewayv2integrationsalpha.NewHttpLambdaIntegration(
+			jsii.String("CreateCollectionIntegration"),
+			createCollectionLambda,
+			&awscdkapigatewayv2integrationsalpha.HttpLambdaIntegrationProps{},
+		),
+	})
+
+	getCollectionsLambda := awscdklambdagoalpha.NewGoFunction(stack, jsii.String("GetCollectionsLambda"), &awscdklambdagoalpha.GoFunctionProps{
+		Runtime: awslambda.Runtime_PROVIDED_AL2(),
+		Entry:   jsii.String("lambda/get-collections"),
+		Role:    lambdaRole,
+		Bundling: &awscdklambdagoalpha.BundlingOptions{
+			Environment: &map[string]*string{
+				"GOOS":   jsii.String("linux"),
+				"GOARCH": jsii.String("amd64"),
+			},
+		},
+		Environment: &collectionEnvironment,
+	})
+	collectionsTable.GrantReadData(getCollectionsLambda)
+
+	httpApi.AddRoutes(&awscdkapigatewayv2alpha.AddRoutesOptions{
+		Path: jsii.String("/collections"),
+		Methods: &[]awscdkapigatewayv2alpha.HttpMethod{
+			awscdkapigatewayv2alpha.HttpMethod_GET,
+		},
+		Integration: awscdkapigatewayv2integrationsalpha.NewHttpLambdaIntegration(
+			jsii.String("GetCollectionsIntegration"),
+			getCollectionsLambda,
+			&awscdkapigatewayv2integrationsalpha.HttpLambdaIntegrationProps{},
+		),
+	})
+
+	getCollectionLambda := awscdklambdagoalpha.NewGoFunction(stack, jsii.String("GetCollectionLambda"), &awscdklambdagoalpha.GoFunctionProps{
+		Runtime: awslambda.Runtime_PROVIDED_AL2(),
+		Entry:   jsii.String("lambda/get-collection"),
+		Role:    lambdaRole,
+		Bundling: &awscdklambdagoalpha.BundlingOptions{
+			Environment: &map[string]*string{
+				"GOOS":   jsii.String("linux"),
+				"GOARCH": jsii.String("amd64"),
+			},
+		},
+		Environment: &collectionEnvironment,
+	})
+	collectionsTable.GrantReadData(getCollectionLambda)
+
+	httpApi.AddRoutes(&awscdkapigatewayv2alpha.AddRoutesOptions{
+		Path: jsii.String("/collections/{id}"),
+		Methods: &[]awscdkapigatewayv2alpha.HttpMethod{
+			awscdkapigatewayv2alpha.HttpMethod_GET,
+		},
+		Integration: awscdkapigatewayv2integrationsalpha.NewHttpLambdaIntegration(
+			jsii.String("GetCollectionIntegration"),
+			getCollectionLambda,
+			&awscdkapigatewayv2integrationsalpha.HttpLambdaIntegrationProps{},
+		),
+	})
+
+	updateCollectionLambda := awscdklambdagoalpha.NewGoFunction(stack, jsii.String("UpdateCollectionLambda"), &awscdklambdagoalpha.GoFunctionProps{
+		Runtime: awslambda.Runtime_PROVIDED_AL2(),
+		Entry:   jsii.String("lambda/update-collection"),
+		Role:    lambdaRole,
+		Bundling: &awscdklambdagoalpha.BundlingOptions{
+			Environment: &map[string]*string{
+				"GOOS":   jsii.String("linux"),
+				"GOARCH": jsii.String("amd64"),
+			},
+		},
+		Environment: &collectionEnvironment,
+	})
+	collectionsTable.GrantReadWriteData(updateCollectionLambda)
+
+	httpApi.AddRoutes(&awscdkapigatewayv2alpha.AddRoutesOptions{
+		Path: jsii.String("/collections/{id}"),
+		Methods: &[]awscdkapigatewayv2alpha.HttpMethod{
+			awscdkapigatewayv2alpha.HttpMethod_PUT,
+		},
+		Integration: awscdkapigatewayv2integrationsalpha.NewHttpLambdaIntegration(
+			jsii.String("UpdateCollectionIntegration"),
+			updateCollectionLambda,
+			&awscdkapigatewayv2integrationsalpha.HttpLambdaIntegrationProps{},
+		),
+	})
+
+	deleteCollectionLambda := awscdklambdagoalpha.NewGoFunction(stack, jsii.String("DeleteCollectionLambda"), &awscdklambdagoalpha.GoFunctionProps{
+		Runtime: awslambda.Runtime_PROVIDED_AL2(),
+		Entry:   jsii.String("lambda/delete-collection"),
+		Role:    lambdaRole,
+		Bundling: &awscdklambdagoalpha.BundlingOptions{
+			Environment: &map[string]*string{
+				"GOOS":   jsii.String("linux"),
+				"GOARCH": jsii.String("amd64"),
+			},
+		},
+		Environment: &collectionEnvironment,
+	})
+	collectionsTable.GrantReadWriteData(deleteCollectionLambda)
+
+	httpApi.AddRoutes(&awscdkapigatewayv2alpha.AddRoutesOptions{
+		Path: jsii.String("/collections/{id}"),
+		Methods: &[]awscdkapigatewayv2alpha.HttpMethod{
+			awscdkapigatewayv2alpha.HttpMethod_DELETE,
+		},
+		Integration: awscdkapigatewayv2integrationsalpha.NewHttpLambdaIntegration(
+			jsii.String("DeleteCollectionIntegration"),
+			deleteCollectionLambda,
+			&awscdkapigatewayv2integrationsalpha.HttpLambdaIntegrationProps{},
+		),
+	})
+
+	getCollectionProgressLambda := awscdklambdagoalpha.NewGoFunction(stack, jsii.String("GetCollectionProgressLambda"), &awscdklambdagoalpha.GoFunctionProps{
+		Runtime: awslambda.Runtime_PROVIDED_AL2(),
+		Entry:   jsii.String("lambda/get-collection-progress"),
+		Role:    lambdaRole,
+		Bundling: &awscdklambdagoalpha.BundlingOptions{
+			Environment: &map[string]*string{
+				"GOOS":   jsii.String("linux"),
+				"GOARCH": jsii.String("amd64"),
+			},
+		},
+		Environment: &collectionEnvironment,
+	})
+	collectionsTable.GrantReadData(getCollectionProgressLambda)
+
+	httpApi.AddRoutes(&awscdkapigatewayv2alpha.AddRoutesOptions{
+		Path: jsii.String("/collections/{id}/progress"),
+		Methods: &[]awscdkapigatewayv2alpha.HttpMethod{
+			awscdkapigatewayv2alpha.HttpMethod_GET,
+		},
+		Integration: awscdkapigatewayv2integrationsalpha.NewHttpLambdaIntegration(
+			jsii.String("GetCollectionProgressIntegration"),
+			getCollectionProgressLambda,
+			&awscdkapigatewayv2integrationsalpha.HttpLambdaIntegrationProps{},
+		),
+	})
+
 	// Output the API endpoints
 	awscdk.NewCfnOutput(stack, jsii.String("MainApiEndpoint"), &awscdk.CfnOutputProps{
 		Value:       httpApi.Url(),
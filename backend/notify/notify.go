@@ -0,0 +1,106 @@
+// Package notify pushes a submission's status changes to its owner while
+// it is being judged, so clients don't have to poll for the verdict.
+//
+// Events are published to the owner's topic (see Topic) in the Momento
+// cache, which browsers subscribe to with a token from
+// GET /me/submissions/events. When NOTIFY_URL is set they are POSTed to
+// NOTIFY_URL/topics/<topic> instead, which is how the local stand-in
+// (cmd/learncode-events) receives them.
+//
+// Publishing is best effort: failures are logged and never fail a run, and
+// the stored submission stays the source of truth.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"learncode/backend/types"
+	"learncode/backend/utils"
+)
+
+// StatusTesting is the status of test events.
+const StatusTesting = "testing"
+
+// Event is one status change of a submission. The final event carries the
+// verdict and the submission's final status.
+type Event struct {
+	SubmissionID string            `json:"submission_id"`
+	ProblemID    string            `json:"problem_id"`
+	Status       string            `json:"status"` // pending, running, testing, then the final status
+	Verdict      string            `json:"verdict,omitempty"`
+	TestIndex    int               `json:"test_index,omitempty"` // 1-based
+	Test         *types.TestResult `json:"test,omitempty"`
+	Time         int64             `json:"time"`
+}
+
+var httpClient = &http.Client{Timeout: 5 * time.Second}
+
+// Topic returns the topic a user's submission events go to.
+func Topic(userID string) string {
+	return "submissions-" + userID
+}
+
+// Status publishes a status change such as pending or running.
+func Status(ctx context.Context, submission *types.Submission, status types.SubmissionStatus) {
+	publish(ctx, submission, Event{Status: string(status)})
+}
+
+// TestDone publishes the result of the index'th test (1-based).
+func TestDone(ctx context.Context, submission *types.Submission, index int, test types.TestResult) {
+	publish(ctx, submission, Event{Status: StatusTesting, TestIndex: index, Test: &test})
+}
+
+// Verdict publishes the final status and verdict.
+func Verdict(ctx context.Context, submission *types.Submission, status types.SubmissionStatus, verdict string) {
+	publish(ctx, submission, Event{Status: string(status), Verdict: verdict})
+}
+
+func publish(ctx context.Context, submission *types.Submission, event Event) {
+	event.SubmissionID = submission.SubmissionID
+	event.ProblemID = submission.ProblemID
+	event.Time = time.Now().Unix()
+
+	message, err := json.Marshal(event)
+	if err != nil {
+		fmt.Printf("Failed to marshal submission event: %v\n", err)
+		return
+	}
+
+	topic := Topic(submission.UserID)
+	if baseURL := os.Getenv("NOTIFY_URL"); baseURL != "" {
+		err = post(ctx, baseURL, topic, message)
+	} else {
+		err = utils.PublishToTopic(ctx, topic, message)
+	}
+	if err != nil {
+		fmt.Printf("Failed to publish submission event: %v\n", err)
+	}
+}
+
+func post(ctx context.Context, baseURL string, topic string, message []byte) error {
+	endpoint := strings.TrimSuffix(baseURL, "/") + "/topics/" + url.PathEscape(topic)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(message))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s returned %s", endpoint, resp.Status)
+	}
+	return nil
+}
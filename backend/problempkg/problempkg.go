@@ -0,0 +1,703 @@
+// Package problempkg reads and writes portable problem packages.
+//
+// A package is a directory or zip archive laid out like an ICPC problem
+// package, so most ICPC packages and Polygon full packages can be imported
+// as is:
+//
+//	problem.yaml                       metadata (see Metadata)
+//	statement.md                       statement markdown
+//	statement.<locale>.md              translated statements, titled in
+//	                                   problem.yaml
+//	data/sample/<name>.in, .ans        example shown to learners
+//	data/secret/<name>.in, .ans        hidden tests, sorted by path
+//	output_validators/<dir>/<file>     optional custom checker, or the
+//	                                   interactor of an interactive problem
+//	input_validators/<dir>/<file>      optional input validators
+//	submissions/<verdict>/<file>       reference solutions
+//	starter_code/solution.<ext>        optional starter code, by language
+//	editorial.md                       optional editorial
+//	editorial/solution.<ext>           editorial solutions, by language
+//	schema.sql                         schema of a SQL problem, whose test
+//	                                   inputs are seed data
+//
+// For compatibility, statements are also looked up at
+// problem_statement/problem.md and problem_statement/problem.en.md, Polygon
+// tests are read from tests/NN and tests/NN.a when there is no data
+// directory, and a root-level check.<ext> or checker.<ext> is used as the
+// checker. As in ICPC packages, `validation: custom interactive` in
+// problem.yaml marks the output validator as an interactor; a root-level
+// checker.<ext> is then the checker. Polygon's root-level interactor.<ext>
+// is also recognized.
+package problempkg
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"learncode/backend/types"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FormatVersion is written to problem.yaml on export. Packages without a
+// format_version are treated as foreign (ICPC or Polygon) packages.
+const FormatVersion = 1
+
+type Metadata struct {
+	FormatVersion int    `yaml:"format_version,omitempty"`
+	ID            string `yaml:"id,omitempty"`
+	Title         string `yaml:"title,omitempty"`
+	Name          string `yaml:"name,omitempty"` // ICPC spelling of title
+	Difficulty    string `yaml:"difficulty,omitempty"`
+	Rating        int    `yaml:"rating,omitempty"`     // Initial difficulty rating; the rating job adjusts it
+	Validation    string `yaml:"validation,omitempty"` // "custom interactive" for interactive problems
+	Limits        Limits `yaml:"limits,omitempty"`
+	// Languages restricts submissions to these languages; empty allows all.
+	Languages []string `yaml:"languages,omitempty"`
+	// Signature makes this a function-signature problem (see types.Signature).
+	Signature *types.Signature `yaml:"signature,omitempty"`
+	// OrderedRows makes a SQL problem compare rows in order.
+	OrderedRows bool `yaml:"ordered_rows,omitempty"`
+	// Hints are revealed to learners one at a time, in order.
+	Hints []string `yaml:"hints,omitempty"`
+	// EditorialAttempts is the number of failed submissions that unlock the
+	// editorial; zero means only acceptance does.
+	EditorialAttempts int `yaml:"editorial_attempts,omitempty"`
+	// Locale is the language of title and statement.md; empty means English.
+	Locale string `yaml:"locale,omitempty"`
+	// Titles holds translated titles by locale. Each needs a
+	// statement.<locale>.md.
+	Titles map[string]string `yaml:"titles,omitempty"`
+}
+
+type Limits struct {
+	TimeLimit float64 `yaml:"time_limit,omitempty"` // Seconds, as in the ICPC format
+	Memory    int     `yaml:"memory,omitempty"`     // MiB
+	Queries   int     `yaml:"queries,omitempty"`    // Most lines an interactive solution may write
+}
+
+var extensions = map[string]string{
+	".py":   "python",
+	".js":   "nodejs",
+	".cpp":  "cpp",
+	".cc":   "cpp",
+	".cxx":  "cpp",
+	".java": "java",
+	".sql":  "sql",
+}
+
+var languageExtensions = map[string]string{
+	"python": ".py",
+	"nodejs": ".js",
+	"cpp":    ".cpp",
+	"java":   ".java",
+	"sql":    ".sql",
+}
+
+// ICPC submission directory names mapped to judge verdicts.
+var verdictDirs = map[string]string{
+	"accepted":            types.VerdictAccepted,
+	"wrong_answer":        types.VerdictWrongAnswer,
+	"time_limit_exceeded": types.VerdictTimeLimitExceeded,
+	"run_time_error":      types.VerdictRuntimeError,
+	"compile_error":       types.VerdictCompileError,
+}
+
+var statementPaths = []string{
+	"statement.md",
+	"problem_statement/problem.md",
+	"problem_statement/problem.en.md",
+}
+
+// Read loads a problem from a package. The returned problem has no
+// timestamps set; it is up to the caller to fill them in.
+func Read(fsys fs.FS) (*types.Problem, error) {
+	metaData, err := fs.ReadFile(fsys, "problem.yaml")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read problem.yaml: %v", err)
+	}
+
+	var meta Metadata
+	if err := yaml.Unmarshal(metaData, &meta); err != nil {
+		return nil, fmt.Errorf("invalid problem.yaml: %v", err)
+	}
+	if meta.FormatVersion > FormatVersion {
+		return nil, fmt.Errorf("unsupported package format_version %d", meta.FormatVersion)
+	}
+
+	problem := &types.Problem{
+		ID:         meta.ID,
+		Title:      meta.Title,
+		Difficulty: meta.Difficulty,
+		Rating:     meta.Rating,
+		Locale:     meta.Locale,
+	}
+	if problem.Title == "" {
+		problem.Title = meta.Name
+	}
+	if meta.Limits.TimeLimit > 0 {
+		problem.TimeLimitMs = int(math.Round(meta.Limits.TimeLimit * 1000))
+	}
+	problem.MemoryLimitMB = meta.Limits.Memory
+	problem.QueryLimit = meta.Limits.Queries
+	problem.AllowedLanguages = meta.Languages
+	problem.Signature = meta.Signature
+	problem.Hints = meta.Hints
+	problem.EditorialAttempts = meta.EditorialAttempts
+
+	if schema, err := fs.ReadFile(fsys, "schema.sql"); err == nil {
+		problem.SQL = &types.SQLSpec{Schema: string(schema), Ordered: meta.OrderedRows}
+	} else if !isNotExist(err) {
+		return nil, fmt.Errorf("failed to read schema.sql: %v", err)
+	}
+
+	for _, p := range statementPaths {
+		data, err := fs.ReadFile(fsys, p)
+		if err == nil {
+			problem.Description = string(data)
+			break
+		}
+	}
+
+	problem.Translations, err = readTranslations(fsys, meta.Titles)
+	if err != nil {
+		return nil, err
+	}
+
+	samples, err := readTests(fsys, "data/sample")
+	if err != nil {
+		return nil, err
+	}
+	if len(samples) > 0 {
+		problem.ExampleInput = samples[0].Input
+		problem.ExampleOutput = samples[0].Output
+	}
+
+	problem.Tests, err = readTests(fsys, "data/secret")
+	if err != nil {
+		return nil, err
+	}
+	if len(problem.Tests) == 0 {
+		problem.Tests, err = readPolygonTests(fsys)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if strings.Contains(meta.Validation, "interactive") {
+		problem.Interactor, err = readProgram(fsys, "output_validators")
+		if err != nil {
+			return nil, err
+		}
+		problem.Checker, err = readProgram(fsys, "", "checker", "check")
+	} else {
+		problem.Checker, err = readProgram(fsys, "output_validators", "checker", "check")
+		if err != nil {
+			return nil, err
+		}
+		problem.Interactor, err = readProgram(fsys, "", "interactor")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	problem.ReferenceSolutions, err = readSolutions(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	problem.InputValidators, err = readInputValidators(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	problem.StarterCode, err = readCodeByLanguage(fsys, "starter_code")
+	if err != nil {
+		return nil, err
+	}
+
+	problem.Editorial, err = readEditorial(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	if problem.Title == "" {
+		return nil, fmt.Errorf("package has no title")
+	}
+	if problem.Description == "" {
+		return nil, fmt.Errorf("package has no statement")
+	}
+	if len(problem.Tests) == 0 {
+		return nil, fmt.Errorf("package has no tests")
+	}
+
+	// Keep the legacy single-test fields populated for older clients.
+	problem.Input = problem.Tests[0].Input
+	problem.Output = problem.Tests[0].Output
+
+	return problem, nil
+}
+
+// ReadZip loads a problem from a zip archive. Archives whose entries all
+// live under a single top-level directory are unwrapped first.
+func ReadZip(data []byte) (*types.Problem, error) {
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid zip archive: %v", err)
+	}
+
+	var fsys fs.FS = reader
+	if _, err := fs.Stat(reader, "problem.yaml"); err != nil {
+		entries, err := fs.ReadDir(reader, ".")
+		if err == nil && len(entries) == 1 && entries[0].IsDir() {
+			fsys, err = fs.Sub(reader, entries[0].Name())
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return Read(fsys)
+}
+
+// ReadDir loads a problem from a package directory.
+func ReadDir(dir string) (*types.Problem, error) {
+	return Read(os.DirFS(dir))
+}
+
+func readTests(fsys fs.FS, dir string) ([]types.TestCase, error) {
+	var inputs []string
+	err := fs.WalkDir(fsys, dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && path.Ext(p) == ".in" {
+			inputs = append(inputs, p)
+		}
+		return nil
+	})
+	if err != nil && !isNotExist(err) {
+		return nil, fmt.Errorf("failed to list %s: %v", dir, err)
+	}
+	sort.Strings(inputs)
+
+	var tests []types.TestCase
+	for _, p := range inputs {
+		base := strings.TrimSuffix(p, ".in")
+		test, err := readTest(fsys, p, base+".ans", strings.TrimPrefix(base, dir+"/"))
+		if err != nil {
+			return nil, err
+		}
+		tests = append(tests, *test)
+	}
+
+	return tests, nil
+}
+
+func readPolygonTests(fsys fs.FS) ([]types.TestCase, error) {
+	entries, err := fs.ReadDir(fsys, "tests")
+	if err != nil {
+		if isNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to list tests: %v", err)
+	}
+
+	var tests []types.TestCase
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || path.Ext(name) != "" {
+			continue
+		}
+		test, err := readTest(fsys, "tests/"+name, "tests/"+name+".a", name)
+		if err != nil {
+			return nil, err
+		}
+		tests = append(tests, *test)
+	}
+
+	return tests, nil
+}
+
+func readTest(fsys fs.FS, inputPath string, answerPath string, name string) (*types.TestCase, error) {
+	input, err := fs.ReadFile(fsys, inputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", inputPath, err)
+	}
+	answer, err := fs.ReadFile(fsys, answerPath)
+	if err != nil {
+		return nil, fmt.Errorf("test %s has no answer file %s", inputPath, answerPath)
+	}
+	return &types.TestCase{Name: name, Input: string(input), Output: string(answer)}, nil
+}
+
+// readProgram returns the first program found under dir (if not empty), or
+// else the first root-level file named after one of rootNames.
+func readProgram(fsys fs.FS, dir string, rootNames ...string) (*types.Program, error) {
+	var candidates []string
+	if dir != "" {
+		err := fs.WalkDir(fsys, dir, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && extensions[path.Ext(p)] != "" {
+				candidates = append(candidates, p)
+			}
+			return nil
+		})
+		if err != nil && !isNotExist(err) {
+			return nil, fmt.Errorf("failed to list %s: %v", dir, err)
+		}
+		sort.Strings(candidates)
+	}
+
+	var rootCandidates []string
+	for ext := range extensions {
+		for _, name := range rootNames {
+			if _, err := fs.Stat(fsys, name+ext); err == nil {
+				rootCandidates = append(rootCandidates, name+ext)
+			}
+		}
+	}
+	sort.Strings(rootCandidates)
+	candidates = append(candidates, rootCandidates...)
+
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	code, err := fs.ReadFile(fsys, candidates[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", candidates[0], err)
+	}
+	return &types.Program{Language: extensions[path.Ext(candidates[0])], Code: string(code)}, nil
+}
+
+func readSolutions(fsys fs.FS) ([]types.ReferenceSolution, error) {
+	var solutions []types.ReferenceSolution
+	err := fs.WalkDir(fsys, "submissions", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		parts := strings.Split(p, "/")
+		if len(parts) < 3 {
+			return nil
+		}
+		language := extensions[path.Ext(p)]
+		verdict := verdictDirs[parts[1]]
+		if language == "" || verdict == "" {
+			return nil
+		}
+
+		code, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		solutions = append(solutions, types.ReferenceSolution{
+			Name:            strings.TrimSuffix(path.Base(p), path.Ext(p)),
+			Language:        language,
+			Code:            string(code),
+			ExpectedVerdict: verdict,
+		})
+		return nil
+	})
+	if err != nil && !isNotExist(err) {
+		return nil, fmt.Errorf("failed to read submissions: %v", err)
+	}
+
+	return solutions, nil
+}
+
+func readInputValidators(fsys fs.FS) ([]types.Program, error) {
+	var paths []string
+	err := fs.WalkDir(fsys, "input_validators", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && extensions[path.Ext(p)] != "" {
+			paths = append(paths, p)
+		}
+		return nil
+	})
+	if err != nil && !isNotExist(err) {
+		return nil, fmt.Errorf("failed to list input_validators: %v", err)
+	}
+	sort.Strings(paths)
+
+	var validators []types.Program
+	for _, p := range paths {
+		code, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %v", p, err)
+		}
+		validators = append(validators, types.Program{Language: extensions[path.Ext(p)], Code: string(code)})
+	}
+
+	return validators, nil
+}
+
+// readCodeByLanguage reads one source file per language from dir.
+func readCodeByLanguage(fsys fs.FS, dir string) (map[string]string, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		if isNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to list %s: %v", dir, err)
+	}
+
+	code := map[string]string{}
+	for _, entry := range entries {
+		language := extensions[path.Ext(entry.Name())]
+		if entry.IsDir() || language == "" {
+			continue
+		}
+		if _, ok := code[language]; ok {
+			return nil, fmt.Errorf("%s has more than one %s file", dir, language)
+		}
+		p := dir + "/" + entry.Name()
+		data, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %v", p, err)
+		}
+		code[language] = string(data)
+	}
+	if len(code) == 0 {
+		return nil, nil
+	}
+
+	return code, nil
+}
+
+func readEditorial(fsys fs.FS) (*types.Editorial, error) {
+	solutions, err := readCodeByLanguage(fsys, "editorial")
+	if err != nil {
+		return nil, err
+	}
+	content, err := fs.ReadFile(fsys, "editorial.md")
+	if err != nil && !isNotExist(err) {
+		return nil, fmt.Errorf("failed to read editorial.md: %v", err)
+	}
+	if len(content) == 0 && solutions == nil {
+		return nil, nil
+	}
+
+	return &types.Editorial{Content: string(content), Solutions: solutions}, nil
+}
+
+// readTranslations reads statement.<locale>.md for each translated title.
+func readTranslations(fsys fs.FS, titles map[string]string) (map[string]types.Translation, error) {
+	if len(titles) == 0 {
+		return nil, nil
+	}
+
+	translations := make(map[string]types.Translation, len(titles))
+	for locale, title := range titles {
+		statement, err := fs.ReadFile(fsys, "statement."+locale+".md")
+		if err != nil {
+			return nil, fmt.Errorf("failed to read statement for locale %s: %v", locale, err)
+		}
+		translations[locale] = types.Translation{Title: title, Description: string(statement)}
+	}
+
+	return translations, nil
+}
+
+func isNotExist(err error) bool {
+	return errors.Is(err, fs.ErrNotExist)
+}
+
+// Files renders a problem as package files keyed by slash-separated path.
+func Files(problem *types.Problem) (map[string][]byte, error) {
+	meta := Metadata{
+		FormatVersion: FormatVersion,
+		ID:            problem.ID,
+		Title:         problem.Title,
+		Difficulty:    problem.Difficulty,
+		Rating:        problem.Rating,
+		Limits: Limits{
+			TimeLimit: float64(problem.TimeLimitMs) / 1000,
+			Memory:    problem.MemoryLimitMB,
+			Queries:   problem.QueryLimit,
+		},
+		Languages:         problem.AllowedLanguages,
+		Signature:         problem.Signature,
+		Hints:             problem.Hints,
+		EditorialAttempts: problem.EditorialAttempts,
+		Locale:            problem.Locale,
+	}
+	if len(problem.Translations) > 0 {
+		meta.Titles = make(map[string]string, len(problem.Translations))
+	}
+	for locale, translation := range problem.Translations {
+		meta.Titles[locale] = translation.Title
+	}
+	if problem.SQL != nil {
+		meta.OrderedRows = problem.SQL.Ordered
+	}
+	if problem.Interactor != nil {
+		meta.Validation = "custom interactive"
+	}
+	metaData, err := yaml.Marshal(meta)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal problem.yaml: %v", err)
+	}
+
+	files := map[string][]byte{
+		"problem.yaml": metaData,
+		"statement.md": []byte(problem.Description),
+	}
+	for locale, translation := range problem.Translations {
+		files["statement."+locale+".md"] = []byte(translation.Description)
+	}
+	if problem.SQL != nil {
+		files["schema.sql"] = []byte(problem.SQL.Schema)
+	}
+
+	if problem.ExampleInput != "" || problem.ExampleOutput != "" {
+		files["data/sample/1.in"] = []byte(problem.ExampleInput)
+		files["data/sample/1.ans"] = []byte(problem.ExampleOutput)
+	}
+
+	tests := problem.TestCases()
+	for i, test := range tests {
+		name := test.Name
+		if name == "" {
+			name = fmt.Sprintf("%0*d", len(fmt.Sprint(len(tests))), i+1)
+		}
+		files["data/secret/"+name+".in"] = []byte(test.Input)
+		files["data/secret/"+name+".ans"] = []byte(test.Output)
+	}
+
+	if problem.Interactor != nil {
+		ext, ok := languageExtensions[problem.Interactor.Language]
+		if !ok {
+			return nil, fmt.Errorf("unsupported interactor language: %s", problem.Interactor.Language)
+		}
+		files["output_validators/interactor/interactor"+ext] = []byte(problem.Interactor.Code)
+	}
+
+	if problem.Checker != nil {
+		ext, ok := languageExtensions[problem.Checker.Language]
+		if !ok {
+			return nil, fmt.Errorf("unsupported checker language: %s", problem.Checker.Language)
+		}
+		// The output validator slot is taken by the interactor
+		if problem.Interactor != nil {
+			files["checker"+ext] = []byte(problem.Checker.Code)
+		} else {
+			files["output_validators/checker/checker"+ext] = []byte(problem.Checker.Code)
+		}
+	}
+
+	for i, validator := range problem.InputValidators {
+		ext, ok := languageExtensions[validator.Language]
+		if !ok {
+			return nil, fmt.Errorf("unsupported input validator language: %s", validator.Language)
+		}
+		files[fmt.Sprintf("input_validators/validator%d/validator%s", i+1, ext)] = []byte(validator.Code)
+	}
+
+	for i, solution := range problem.ReferenceSolutions {
+		ext, ok := languageExtensions[solution.Language]
+		if !ok {
+			return nil, fmt.Errorf("unsupported solution language: %s", solution.Language)
+		}
+		dir := ""
+		for d, verdict := range verdictDirs {
+			if verdict == solution.ExpectedVerdict {
+				dir = d
+			}
+		}
+		if dir == "" {
+			return nil, fmt.Errorf("unsupported expected verdict: %s", solution.ExpectedVerdict)
+		}
+		name := solution.Name
+		if name == "" {
+			name = fmt.Sprintf("solution%d", i+1)
+		}
+		files["submissions/"+dir+"/"+name+ext] = []byte(solution.Code)
+	}
+
+	for language, code := range problem.StarterCode {
+		ext, ok := languageExtensions[language]
+		if !ok {
+			return nil, fmt.Errorf("unsupported starter code language: %s", language)
+		}
+		files["starter_code/solution"+ext] = []byte(code)
+	}
+
+	if problem.Editorial != nil {
+		files["editorial.md"] = []byte(problem.Editorial.Content)
+		for language, code := range problem.Editorial.Solutions {
+			ext, ok := languageExtensions[language]
+			if !ok {
+				return nil, fmt.Errorf("unsupported editorial solution language: %s", language)
+			}
+			files["editorial/solution"+ext] = []byte(code)
+		}
+	}
+
+	return files, nil
+}
+
+// WriteZip writes a problem as a zip package.
+func WriteZip(w io.Writer, problem *types.Problem) error {
+	files, err := Files(problem)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	archive := zip.NewWriter(w)
+	for _, name := range names {
+		f, err := archive.Create(name)
+		if err != nil {
+			return fmt.Errorf("failed to add %s: %v", name, err)
+		}
+		if _, err := f.Write(files[name]); err != nil {
+			return fmt.Errorf("failed to write %s: %v", name, err)
+		}
+	}
+
+	return archive.Close()
+}
+
+// WriteDir writes a problem as a package directory, creating it if needed.
+func WriteDir(dir string, problem *types.Problem) error {
+	files, err := Files(problem)
+	if err != nil {
+		return err
+	}
+
+	for name, data := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			return fmt.Errorf("failed to create %s: %v", filepath.Dir(p), err)
+		}
+		if err := os.WriteFile(p, data, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %v", p, err)
+		}
+	}
+
+	return nil
+}
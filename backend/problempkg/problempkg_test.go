@@ -0,0 +1,271 @@
+package problempkg
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"learncode/backend/types"
+)
+
+func files(m map[string]string) fstest.MapFS {
+	fsys := fstest.MapFS{}
+	for name, data := range m {
+		fsys[name] = &fstest.MapFile{Data: []byte(data)}
+	}
+	return fsys
+}
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		check func(t *testing.T, problem *types.Problem)
+	}{
+		{
+			name: "icpc package",
+			files: map[string]string{
+				"problem.yaml":                      "name: Sum\nlimits:\n  time_limit: 1.5\n  memory: 128\n",
+				"problem_statement/problem.md":      "Add two numbers.",
+				"data/sample/1.in":                  "1 2\n",
+				"data/sample/1.ans":                 "3\n",
+				"data/secret/b/2.in":                "5 5\n",
+				"data/secret/b/2.ans":               "10\n",
+				"data/secret/a.in":                  "2 2\n",
+				"data/secret/a.ans":                 "4\n",
+				"output_validators/check/check.cpp": "// checker",
+				"submissions/accepted/sum.py":       "print(sum(map(int, input().split())))",
+				"submissions/wrong_answer/zero.py":  "print(0)",
+				"submissions/other/ignored.py":      "",
+				"input_validators/v/validate.py":    "# validator",
+				"starter_code/solution.js":          "// start",
+			},
+			check: func(t *testing.T, problem *types.Problem) {
+				if problem.Title != "Sum" || problem.Description != "Add two numbers." {
+					t.Errorf("title, statement = %q, %q", problem.Title, problem.Description)
+				}
+				if problem.TimeLimitMs != 1500 || problem.MemoryLimitMB != 128 {
+					t.Errorf("limits = %d ms, %d MB, want 1500 ms, 128 MB", problem.TimeLimitMs, problem.MemoryLimitMB)
+				}
+				if problem.ExampleInput != "1 2\n" || problem.ExampleOutput != "3\n" {
+					t.Errorf("example = %q, %q", problem.ExampleInput, problem.ExampleOutput)
+				}
+				want := []types.TestCase{
+					{Name: "a", Input: "2 2\n", Output: "4\n"},
+					{Name: "b/2", Input: "5 5\n", Output: "10\n"},
+				}
+				if !reflect.DeepEqual(problem.Tests, want) {
+					t.Errorf("tests = %+v, want %+v", problem.Tests, want)
+				}
+				if problem.Input != "2 2\n" || problem.Output != "4\n" {
+					t.Errorf("legacy test = %q, %q, want the first test", problem.Input, problem.Output)
+				}
+				if problem.Checker == nil || problem.Checker.Language != "cpp" || problem.Interactor != nil {
+					t.Errorf("checker, interactor = %+v, %+v", problem.Checker, problem.Interactor)
+				}
+				if len(problem.ReferenceSolutions) != 2 {
+					t.Errorf("got %d reference solutions, want 2", len(problem.ReferenceSolutions))
+				}
+				if len(problem.InputValidators) != 1 || problem.StarterCode["nodejs"] != "// start" {
+					t.Errorf("validators, starter code = %+v, %+v", problem.InputValidators, problem.StarterCode)
+				}
+			},
+		},
+		{
+			name: "interactive package",
+			files: map[string]string{
+				"problem.yaml":                      "title: Guess\nvalidation: custom interactive\nlimits:\n  queries: 30\n",
+				"statement.md":                      "Guess the number.",
+				"data/secret/1.in":                  "42",
+				"data/secret/1.ans":                 "",
+				"output_validators/i/interactor.py": "# interactor",
+				"checker.py":                        "# checker",
+			},
+			check: func(t *testing.T, problem *types.Problem) {
+				if problem.Interactor == nil || problem.Interactor.Code != "# interactor" {
+					t.Errorf("interactor = %+v", problem.Interactor)
+				}
+				if problem.Checker == nil || problem.Checker.Code != "# checker" {
+					t.Errorf("checker = %+v", problem.Checker)
+				}
+				if problem.QueryLimit != 30 {
+					t.Errorf("query limit = %d, want 30", problem.QueryLimit)
+				}
+			},
+		},
+		{
+			name: "polygon package",
+			files: map[string]string{
+				"problem.yaml":   "title: Echo\n",
+				"statement.md":   "Print the input.",
+				"tests/01":       "a",
+				"tests/01.a":     "a",
+				"tests/02":       "b",
+				"tests/02.a":     "b",
+				"interactor.cpp": "// interactor",
+			},
+			check: func(t *testing.T, problem *types.Problem) {
+				if len(problem.Tests) != 2 || problem.Tests[1].Name != "02" || problem.Tests[1].Output != "b" {
+					t.Errorf("tests = %+v", problem.Tests)
+				}
+				if problem.Interactor == nil || problem.Interactor.Language != "cpp" {
+					t.Errorf("interactor = %+v", problem.Interactor)
+				}
+			},
+		},
+		{
+			name: "sql package",
+			files: map[string]string{
+				"problem.yaml":      "title: Names\nordered_rows: true\n",
+				"statement.md":      "List the names.",
+				"schema.sql":        "CREATE TABLE people (name TEXT);",
+				"data/secret/1.in":  "INSERT INTO people VALUES ('a');",
+				"data/secret/1.ans": "name\na\n",
+			},
+			check: func(t *testing.T, problem *types.Problem) {
+				if problem.SQL == nil || !problem.SQL.Ordered || problem.SQL.Schema != "CREATE TABLE people (name TEXT);" {
+					t.Errorf("sql = %+v", problem.SQL)
+				}
+			},
+		},
+		{
+			name: "translations",
+			files: map[string]string{
+				"problem.yaml":      "title: Sum\nlocale: en\ntitles:\n  de: Summe\n",
+				"statement.md":      "Add.",
+				"statement.de.md":   "Addiere.",
+				"data/secret/1.in":  "1",
+				"data/secret/1.ans": "1",
+			},
+			check: func(t *testing.T, problem *types.Problem) {
+				want := map[string]types.Translation{"de": {Title: "Summe", Description: "Addiere."}}
+				if !reflect.DeepEqual(problem.Translations, want) {
+					t.Errorf("translations = %+v, want %+v", problem.Translations, want)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problem, err := Read(files(tt.files))
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t, problem)
+		})
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	valid := map[string]string{
+		"problem.yaml":      "title: Sum\n",
+		"statement.md":      "Add.",
+		"data/secret/1.in":  "1",
+		"data/secret/1.ans": "1",
+	}
+	tests := []struct {
+		name    string
+		remove  []string
+		add     map[string]string
+		wantErr string
+	}{
+		{"no problem.yaml", []string{"problem.yaml"}, nil, "failed to read problem.yaml"},
+		{"invalid yaml", nil, map[string]string{"problem.yaml": "title: [\n"}, "invalid problem.yaml"},
+		{"newer format", nil, map[string]string{"problem.yaml": "format_version: 99\ntitle: Sum\n"}, "unsupported package format_version 99"},
+		{"no title", nil, map[string]string{"problem.yaml": "difficulty: easy\n"}, "package has no title"},
+		{"no statement", []string{"statement.md"}, nil, "package has no statement"},
+		{"no tests", []string{"data/secret/1.in", "data/secret/1.ans"}, nil, "package has no tests"},
+		{"no answer", []string{"data/secret/1.ans"}, nil, "has no answer file"},
+		{"missing translation", nil, map[string]string{"problem.yaml": "title: Sum\ntitles:\n  de: Summe\n"}, "failed to read statement for locale de"},
+		{"duplicate starter code", nil, map[string]string{"starter_code/a.cpp": "", "starter_code/b.cc": ""}, "more than one cpp file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := files(valid)
+			for _, name := range tt.remove {
+				delete(fsys, name)
+			}
+			for name, data := range tt.add {
+				fsys[name] = &fstest.MapFile{Data: []byte(data)}
+			}
+			_, err := Read(fsys)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Read() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// A problem written as a package reads back the same, wrapped in a
+// top-level directory or not.
+func TestZipRoundTrip(t *testing.T) {
+	problem := &types.Problem{
+		ID:               "sum",
+		Title:            "Sum",
+		Description:      "Add two numbers.",
+		Difficulty:       "easy",
+		Rating:           1200,
+		TimeLimitMs:      2000,
+		MemoryLimitMB:    256,
+		AllowedLanguages: []string{"python", "cpp"},
+		ExampleInput:     "1 2\n",
+		ExampleOutput:    "3\n",
+		Tests: []types.TestCase{
+			{Name: "01", Input: "1 2\n", Output: "3\n"},
+			{Name: "02", Input: "2 2\n", Output: "4\n"},
+		},
+		Checker:            &types.Program{Language: "python", Code: "# checker"},
+		ReferenceSolutions: []types.ReferenceSolution{{Name: "sum", Language: "python", Code: "print(3)", ExpectedVerdict: types.VerdictAccepted}},
+		Hints:              []string{"Use +."},
+		Editorial:          &types.Editorial{Content: "Add them.", Solutions: map[string]string{"cpp": "// sum"}},
+	}
+	problem.Input, problem.Output = problem.Tests[0].Input, problem.Tests[0].Output
+
+	var buf bytes.Buffer
+	if err := WriteZip(&buf, problem); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadZip(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, problem) {
+		t.Errorf("ReadZip(WriteZip()) = %+v, want %+v", got, problem)
+	}
+
+	// Re-archive the same files under a directory
+	reader, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wrapped bytes.Buffer
+	archive := zip.NewWriter(&wrapped)
+	for _, f := range reader.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		w, err := archive.Create("sum/" + f.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.Copy(w, r); err != nil {
+			t.Fatal(err)
+		}
+		r.Close()
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	got, err = ReadZip(wrapped.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Title != problem.Title || len(got.Tests) != len(problem.Tests) {
+		t.Errorf("wrapped package read as %+v", got)
+	}
+}
@@ -0,0 +1,21 @@
+import sys
+
+n, secret = map(int, open(sys.argv[1]).read().split())
+print(n, flush=True)
+
+while True:
+    line = sys.stdin.readline()
+    if not line:
+        print("no answer given", file=sys.stderr)
+        sys.exit(1)
+    parts = line.split()
+    if len(parts) != 2 or parts[0] not in ("?", "!") or not parts[1].lstrip("-").isdigit():
+        print("malformed line: %r" % line.strip(), file=sys.stderr)
+        sys.exit(2)
+    x = int(parts[1])
+    if parts[0] == "!":
+        if x != secret:
+            print("answered %d, secret was %d" % (x, secret), file=sys.stderr)
+            sys.exit(1)
+        sys.exit(0)
+    print("<" if secret < x else (">" if secret > x else "="), flush=True)
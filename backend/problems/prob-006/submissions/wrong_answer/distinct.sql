@@ -0,0 +1,3 @@
+SELECT DISTINCT name
+FROM customers
+WHERE id NOT IN (SELECT customer_id FROM orders);
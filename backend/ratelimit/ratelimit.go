@@ -0,0 +1,220 @@
+// Package ratelimit limits how often code can be run and submitted, since
+// every request invokes a runner.
+//
+// Each request counts against fixed-window rates per user and per client
+// IP, and against a daily quota that depends on the user's role, all kept
+// separately for RUN and SUBMIT. The defaults can be overridden with a JSON
+// RATE_LIMITS environment variable in the shape of Config, for example
+//
+//	{"user": {"SUBMIT": "10/1m"}, "daily": {"learner": {"RUN": 1000}}}
+package ratelimit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"learncode/backend/db"
+	"learncode/backend/types"
+)
+
+// Rate allows Count requests per Window. It is written as "count/window",
+// such as "20/1m".
+type Rate struct {
+	Count  int
+	Window time.Duration
+}
+
+// Config holds the limits, each keyed by submission type (RUN or SUBMIT).
+// Types without a rate or quota are not limited.
+type Config struct {
+	User  map[string]Rate           `json:"user"`
+	IP    map[string]Rate           `json:"ip"`
+	Daily map[string]map[string]int `json:"daily"` // By role
+}
+
+// DefaultConfig is used for anything RATE_LIMITS doesn't set. The per-IP
+// rates are looser than the per-user ones since classrooms share an IP.
+var DefaultConfig = Config{
+	User: map[string]Rate{
+		"RUN":    {Count: 20, Window: time.Minute},
+		"SUBMIT": {Count: 6, Window: time.Minute},
+	},
+	IP: map[string]Rate{
+		"RUN":    {Count: 120, Window: time.Minute},
+		"SUBMIT": {Count: 40, Window: time.Minute},
+	},
+	Daily: map[string]map[string]int{
+		types.RoleLearner:    {"RUN": 500, "SUBMIT": 200},
+		types.RoleInstructor: {"RUN": 2000, "SUBMIT": 1000},
+	},
+}
+
+// Denied explains why a request was rejected.
+type Denied struct {
+	Reason     string
+	RetryAfter time.Duration
+}
+
+// RetryAfterSeconds is RetryAfter rounded up to whole seconds, as sent in
+// the Retry-After header.
+func (d *Denied) RetryAfterSeconds() int {
+	return int(math.Ceil(d.RetryAfter.Seconds()))
+}
+
+// LoadConfig returns DefaultConfig with the overrides from RATE_LIMITS.
+func LoadConfig() (*Config, error) {
+	config := Config{
+		User:  maps.Clone(DefaultConfig.User),
+		IP:    maps.Clone(DefaultConfig.IP),
+		Daily: map[string]map[string]int{},
+	}
+	for role, quotas := range DefaultConfig.Daily {
+		config.Daily[role] = maps.Clone(quotas)
+	}
+
+	value := os.Getenv("RATE_LIMITS")
+	if value == "" {
+		return &config, nil
+	}
+
+	var overrides Config
+	if err := json.Unmarshal([]byte(value), &overrides); err != nil {
+		return nil, fmt.Errorf("invalid RATE_LIMITS: %v", err)
+	}
+	for submissionType, rate := range overrides.User {
+		config.User[submissionType] = rate
+	}
+	for submissionType, rate := range overrides.IP {
+		config.IP[submissionType] = rate
+	}
+	for role, quotas := range overrides.Daily {
+		if config.Daily[role] == nil {
+			config.Daily[role] = map[string]int{}
+		}
+		for submissionType, quota := range quotas {
+			config.Daily[role][submissionType] = quota
+		}
+	}
+	return &config, nil
+}
+
+// Role returns the role whose daily quota applies to the user. Roles
+// without quotas of their own get the learner quotas, except admins.
+func (c *Config) Role(user *types.User) string {
+	if user.IsAdmin {
+		return types.RoleAdmin
+	}
+	if _, ok := c.Daily[user.Role]; ok && user.Role != "" {
+		return user.Role
+	}
+	return types.RoleLearner
+}
+
+// Allow counts a request of the given type from the user at ip and returns
+// why it was denied, or nil if it may go ahead. Denied requests aren't
+// counted against any limit.
+func (c *Config) Allow(ctx context.Context, user *types.User, ip string, submissionType string, now time.Time) (*Denied, error) {
+	counters, denials := c.counters(user, ip, submissionType, now)
+	if len(counters) == 0 {
+		return nil, nil
+	}
+	full, err := db.IncrementCounters(ctx, counters)
+	if err != nil || full < 0 {
+		return nil, err
+	}
+	return &denials[full], nil
+}
+
+// Release takes back a request that Allow counted at now, for requests
+// that fail before a runner is invoked.
+func (c *Config) Release(ctx context.Context, user *types.User, ip string, submissionType string, now time.Time) error {
+	counters, _ := c.counters(user, ip, submissionType, now)
+	if len(counters) == 0 {
+		return nil
+	}
+	return db.DecrementCounters(ctx, counters)
+}
+
+// counters returns the counters a request of the given type from the user
+// at ip counts against at now, each with the denial for when it is full.
+func (c *Config) counters(user *types.User, ip string, submissionType string, now time.Time) ([]db.Counter, []Denied) {
+	var counters []db.Counter
+	var denials []Denied
+
+	add := func(key string, limit int, start time.Time, window time.Duration, reason string) {
+		end := start.Add(window)
+		counters = append(counters, db.Counter{
+			Key:       fmt.Sprintf("%s#%s#%d", key, submissionType, start.Unix()),
+			Limit:     limit,
+			ExpiresAt: end.Unix(),
+		})
+		denials = append(denials, Denied{Reason: reason, RetryAfter: end.Sub(now)})
+	}
+
+	if rate, ok := c.IP[submissionType]; ok && ip != "" {
+		add("ip#"+ip, rate.Count, now.Truncate(rate.Window), rate.Window,
+			fmt.Sprintf("Too many %s requests from your network; the limit is %s", submissionType, rate))
+	}
+	if rate, ok := c.User[submissionType]; ok {
+		add("user#"+user.ID, rate.Count, now.Truncate(rate.Window), rate.Window,
+			fmt.Sprintf("Too many %s requests; the limit is %s", submissionType, rate))
+	}
+	if quota, ok := c.Daily[c.Role(user)][submissionType]; ok {
+		day := now.UTC().Truncate(24 * time.Hour)
+		add("daily#"+user.ID, quota, day, 24*time.Hour,
+			fmt.Sprintf("Daily %s quota of %d used up", submissionType, quota))
+	}
+	return counters, denials
+}
+
+func (r Rate) String() string {
+	return fmt.Sprintf("%d/%s", r.Count, formatWindow(r.Window))
+}
+
+func (r Rate) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.String())
+}
+
+func (r *Rate) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	count, window, ok := strings.Cut(s, "/")
+	if !ok {
+		return fmt.Errorf("rate %q is not count/window", s)
+	}
+	n, err := strconv.Atoi(count)
+	if err != nil || n < 1 {
+		return fmt.Errorf("rate %q needs a positive count", s)
+	}
+	d, err := time.ParseDuration(window)
+	if err != nil || d < time.Second {
+		return fmt.Errorf("rate %q needs a window of at least 1s", s)
+	}
+
+	r.Count = n
+	r.Window = d
+	return nil
+}
+
+// formatWindow drops the zero units time.Duration.String adds, so a minute
+// reads "1m" rather than "1m0s".
+func formatWindow(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
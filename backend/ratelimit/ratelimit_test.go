@@ -0,0 +1,116 @@
+package ratelimit
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"learncode/backend/db"
+	"learncode/backend/types"
+)
+
+func TestRole(t *testing.T) {
+	config := &Config{Daily: map[string]map[string]int{
+		types.RoleLearner:    {"RUN": 1},
+		types.RoleInstructor: {"RUN": 2},
+	}}
+	tests := []struct {
+		name string
+		user types.User
+		want string
+	}{
+		{"learner", types.User{}, types.RoleLearner},
+		{"instructor", types.User{Role: types.RoleInstructor}, types.RoleInstructor},
+		{"admin", types.User{IsAdmin: true}, types.RoleAdmin},
+		{"admin instructor", types.User{IsAdmin: true, Role: types.RoleInstructor}, types.RoleAdmin},
+		{"role without quotas", types.User{Role: "mentor"}, types.RoleLearner},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := config.Role(&tt.user); got != tt.want {
+				t.Errorf("Role() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCounters(t *testing.T) {
+	config := &Config{
+		User:  map[string]Rate{"SUBMIT": {Count: 6, Window: time.Minute}},
+		IP:    map[string]Rate{"SUBMIT": {Count: 40, Window: 10 * time.Minute}},
+		Daily: map[string]map[string]int{types.RoleLearner: {"SUBMIT": 200}},
+	}
+	user := &types.User{ID: "u1"}
+	now := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	minute := time.Date(2024, 5, 6, 7, 8, 0, 0, time.UTC).Unix()
+	tenMinutes := time.Date(2024, 5, 6, 7, 0, 0, 0, time.UTC).Unix()
+	day := time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC).Unix()
+
+	tests := []struct {
+		name           string
+		ip             string
+		submissionType string
+		counters       []db.Counter
+		retryAfter     []time.Duration
+	}{
+		{
+			name:           "every limit",
+			ip:             "10.0.0.1",
+			submissionType: "SUBMIT",
+			counters: []db.Counter{
+				{Key: "ip#10.0.0.1#SUBMIT#" + strconv.FormatInt(tenMinutes, 10), Limit: 40, ExpiresAt: tenMinutes + 600},
+				{Key: "user#u1#SUBMIT#" + strconv.FormatInt(minute, 10), Limit: 6, ExpiresAt: minute + 60},
+				{Key: "daily#u1#SUBMIT#" + strconv.FormatInt(day, 10), Limit: 200, ExpiresAt: day + 86400},
+			},
+			retryAfter: []time.Duration{
+				time.Minute + 51*time.Second,
+				51 * time.Second,
+				16*time.Hour + 51*time.Minute + 51*time.Second,
+			},
+		},
+		{
+			name:           "no ip",
+			submissionType: "SUBMIT",
+			counters: []db.Counter{
+				{Key: "user#u1#SUBMIT#" + strconv.FormatInt(minute, 10), Limit: 6, ExpiresAt: minute + 60},
+				{Key: "daily#u1#SUBMIT#" + strconv.FormatInt(day, 10), Limit: 200, ExpiresAt: day + 86400},
+			},
+			retryAfter: []time.Duration{51 * time.Second, 16*time.Hour + 51*time.Minute + 51*time.Second},
+		},
+		{
+			name:           "type without limits",
+			ip:             "10.0.0.1",
+			submissionType: "RUN",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counters, denials := config.counters(user, tt.ip, tt.submissionType, now)
+			if !reflect.DeepEqual(counters, tt.counters) {
+				t.Errorf("counters = %+v, want %+v", counters, tt.counters)
+			}
+			if len(denials) != len(tt.retryAfter) {
+				t.Fatalf("got %d denials, want %d", len(denials), len(tt.retryAfter))
+			}
+			for i, denial := range denials {
+				if denial.RetryAfter != tt.retryAfter[i] {
+					t.Errorf("denial %d retry after = %v, want %v", i, denial.RetryAfter, tt.retryAfter[i])
+				}
+			}
+		})
+	}
+}
+
+// The daily window starts at midnight UTC whatever the time zone of now.
+func TestCountersDailyWindowIsUTC(t *testing.T) {
+	config := &Config{Daily: map[string]map[string]int{types.RoleLearner: {"RUN": 10}}}
+	zone := time.FixedZone("UTC+10", 10*60*60)
+	now := time.Date(2024, 5, 7, 3, 0, 0, 0, zone) // 17:00 on May 6 in UTC
+
+	counters, _ := config.counters(&types.User{ID: "u1"}, "", "RUN", now)
+	day := time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC).Unix()
+	if len(counters) != 1 || counters[0].Key != "daily#u1#RUN#"+strconv.FormatInt(day, 10) {
+		t.Errorf("counters = %+v, want the window starting %d", counters, day)
+	}
+}
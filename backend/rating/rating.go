@@ -0,0 +1,93 @@
+// Package rating updates user skill ratings and problem difficulty ratings
+// from submission outcomes with an Elo-style model: every judged attempt is
+// a game between the user and the problem, which the user wins by getting
+// accepted.
+package rating
+
+import (
+	"math"
+	"sort"
+
+	"learncode/backend/types"
+)
+
+// K is the most a single attempt can move either rating.
+const K = 32
+
+// minRating keeps ratings positive, since zero means unrated.
+const minRating = 100
+
+// Expected returns the probability that a user with userRating solves a
+// problem with problemRating.
+func Expected(userRating int, problemRating int) float64 {
+	return 1 / (1 + math.Pow(10, float64(problemRating-userRating)/400))
+}
+
+// Update returns the new user and problem ratings after one attempt.
+func Update(userRating int, problemRating int, solved bool) (int, int) {
+	score := 0.0
+	if solved {
+		score = 1
+	}
+	delta := int(math.Round(K * (score - Expected(userRating, problemRating))))
+	return max(userRating+delta, minRating), max(problemRating-delta, minRating)
+}
+
+// Batch applies a set of submissions to the ratings of the users and
+// problems involved.
+type Batch struct {
+	Users    map[string]*types.User    // Keyed by user ID
+	Problems map[string]*types.Problem // Keyed by problem ID
+
+	// Rated lists the submissions that were counted, and Skipped those that
+	// came after the user's first acceptance of the problem or belong to a
+	// user or problem that no longer exists. Both should be marked rated.
+	Rated   []types.Submission
+	Skipped []types.Submission
+	// Solved lists the problems each user solved for the first time in the
+	// batch.
+	Solved map[string][]string
+}
+
+// Apply rates finished SUBMIT submissions in the order they were made.
+// Attempts only count up to a user's first acceptance of a problem, so
+// resubmitting a solved problem changes nothing.
+func (b *Batch) Apply(submissions []types.Submission) {
+	if b.Solved == nil {
+		b.Solved = map[string][]string{}
+	}
+	sort.SliceStable(submissions, func(i, j int) bool {
+		return submissions[i].CreatedAt < submissions[j].CreatedAt
+	})
+
+	for _, submission := range submissions {
+		user := b.Users[submission.UserID]
+		problem := b.Problems[submission.ProblemID]
+		if user == nil || problem == nil || solved(user, problem.ID) {
+			b.Skipped = append(b.Skipped, submission)
+			continue
+		}
+
+		// Users are rated against a locked problem rating, which stays put
+		accepted := submission.Accepted()
+		userRating, problemRating := Update(user.EffectiveRating(), problem.EffectiveRating(), accepted)
+		user.Rating = userRating
+		if !problem.RatingLocked {
+			problem.Rating = problemRating
+		}
+		if accepted {
+			user.RatedSolves = append(user.RatedSolves, problem.ID)
+			b.Solved[user.ID] = append(b.Solved[user.ID], problem.ID)
+		}
+		b.Rated = append(b.Rated, submission)
+	}
+}
+
+func solved(user *types.User, problemID string) bool {
+	for _, id := range user.RatedSolves {
+		if id == problemID {
+			return true
+		}
+	}
+	return false
+}
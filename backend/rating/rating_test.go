@@ -0,0 +1,118 @@
+package rating
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"learncode/backend/types"
+)
+
+func TestExpected(t *testing.T) {
+	if got := Expected(1500, 1500); got != 0.5 {
+		t.Errorf("Expected(1500, 1500) = %v, want 0.5", got)
+	}
+	if got := Expected(1900, 1500); math.Abs(got-10.0/11) > 1e-9 {
+		t.Errorf("Expected(1900, 1500) = %v, want 10/11", got)
+	}
+	if sum := Expected(1300, 1700) + Expected(1700, 1300); math.Abs(sum-1) > 1e-9 {
+		t.Errorf("Expected is not symmetric: sum %v, want 1", sum)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	tests := []struct {
+		name               string
+		user, problem      int
+		solved             bool
+		wantUser, wantProb int
+	}{
+		{"even win", 1500, 1500, true, 1516, 1484},
+		{"even loss", 1500, 1500, false, 1484, 1516},
+		{"expected win moves little", 1900, 1500, true, 1903, 1497},
+		{"upset moves a lot", 1100, 1500, true, 1129, 1471},
+		{"ratings stay positive", 105, 105, false, minRating, 121},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user, problem := Update(tt.user, tt.problem, tt.solved)
+			if user != tt.wantUser || problem != tt.wantProb {
+				t.Errorf("Update(%d, %d, %v) = %d, %d, want %d, %d",
+					tt.user, tt.problem, tt.solved, user, problem, tt.wantUser, tt.wantProb)
+			}
+		})
+	}
+}
+
+func TestBatchApply(t *testing.T) {
+	alice := &types.User{ID: "alice"}
+	bob := &types.User{ID: "bob", Rating: 1600, RatedSolves: []string{"p2"}}
+	p1 := &types.Problem{ID: "p1", Difficulty: "Easy"}
+	p2 := &types.Problem{ID: "p2", Rating: 1700}
+	batch := &Batch{
+		Users:    map[string]*types.User{"alice": alice, "bob": bob},
+		Problems: map[string]*types.Problem{"p1": p1, "p2": p2},
+	}
+
+	submissions := []types.Submission{
+		{SubmissionID: "a3", UserID: "alice", ProblemID: "p1", CreatedAt: 30, Status: types.StatusCompleted},
+		{SubmissionID: "a1", UserID: "alice", ProblemID: "p1", CreatedAt: 10, Status: types.StatusError},
+		{SubmissionID: "a2", UserID: "alice", ProblemID: "p1", CreatedAt: 20, Status: types.StatusCompleted},
+		{SubmissionID: "b1", UserID: "bob", ProblemID: "p2", CreatedAt: 15, Status: types.StatusCompleted},
+		{SubmissionID: "x1", UserID: "carol", ProblemID: "p1", CreatedAt: 5, Status: types.StatusCompleted},
+		{SubmissionID: "x2", UserID: "bob", ProblemID: "gone", CreatedAt: 25, Status: types.StatusError},
+	}
+	batch.Apply(submissions)
+
+	if got := ids(batch.Rated); !reflect.DeepEqual(got, []string{"a1", "a2"}) {
+		t.Errorf("Rated = %v, want [a1 a2]", got)
+	}
+	if got := ids(batch.Skipped); !reflect.DeepEqual(got, []string{"x1", "b1", "x2", "a3"}) {
+		t.Errorf("Skipped = %v, want [x1 b1 x2 a3]", got)
+	}
+	if want := map[string][]string{"alice": {"p1"}}; !reflect.DeepEqual(batch.Solved, want) {
+		t.Errorf("Solved = %v, want %v", batch.Solved, want)
+	}
+
+	// A loss then a win against an Easy problem starting at 1200
+	user, problem := Update(types.DefaultRating, 1200, false)
+	user, problem = Update(user, problem, true)
+	if alice.Rating != user || p1.Rating != problem {
+		t.Errorf("alice, p1 rated %d, %d, want %d, %d", alice.Rating, p1.Rating, user, problem)
+	}
+	if bob.Rating != 1600 || p2.Rating != 1700 {
+		t.Errorf("bob, p2 rated %d, %d, want them unchanged", bob.Rating, p2.Rating)
+	}
+}
+
+func TestBatchApplyLockedRating(t *testing.T) {
+	alice := &types.User{ID: "alice"}
+	p1 := &types.Problem{ID: "p1", Rating: 1800, RatingLocked: true}
+	batch := &Batch{
+		Users:    map[string]*types.User{"alice": alice},
+		Problems: map[string]*types.Problem{"p1": p1},
+	}
+
+	batch.Apply([]types.Submission{
+		{SubmissionID: "a1", UserID: "alice", ProblemID: "p1", CreatedAt: 10, Status: types.StatusError},
+		{SubmissionID: "a2", UserID: "alice", ProblemID: "p1", CreatedAt: 20, Status: types.StatusError},
+	})
+
+	// Both losses are against the pinned 1800
+	user, _ := Update(types.DefaultRating, 1800, false)
+	user, _ = Update(user, 1800, false)
+	if alice.Rating != user {
+		t.Errorf("alice rated %d, want %d", alice.Rating, user)
+	}
+	if p1.Rating != 1800 {
+		t.Errorf("p1 rated %d, want its locked 1800", p1.Rating)
+	}
+}
+
+func ids(submissions []types.Submission) []string {
+	var ids []string
+	for _, s := range submissions {
+		ids = append(ids, s.SubmissionID)
+	}
+	return ids
+}
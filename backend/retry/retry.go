@@ -0,0 +1,120 @@
+// Package retry retries operations that fail for transient reasons, such as
+// DynamoDB throttling or a network blip, with exponential backoff.
+//
+// Errors are treated as transient unless they are wrapped with Permanent,
+// which callers do for failures that can't go away by trying again, like a
+// problem that has been deleted.
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+)
+
+// Policy says how often and how patiently to retry.
+type Policy struct {
+	Attempts  int           // Including the first one
+	BaseDelay time.Duration // Before the first retry, doubling after that
+	MaxDelay  time.Duration
+}
+
+// DefaultPolicy gives up after about three seconds of backoff, well within
+// the runners' 30 second timeout.
+var DefaultPolicy = Policy{
+	Attempts:  4,
+	BaseDelay: 250 * time.Millisecond,
+	MaxDelay:  2 * time.Second,
+}
+
+// deadlineReserve is left of a context's deadline for handling the failure
+// once retrying stops.
+const deadlineReserve = 3 * time.Second
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent marks err as not worth retrying.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err, or an error it wraps, was marked with
+// Permanent.
+func IsPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
+}
+
+// Error is returned when an operation failed for good. Err is the last
+// failure.
+type Error struct {
+	Attempts int
+	Err      error
+}
+
+func (e *Error) Error() string {
+	if e.Attempts == 1 {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%v (after %d attempts)", e.Err, e.Attempts)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Do calls op until it succeeds, returns a permanent error or the attempts
+// run out, waiting with jittered exponential backoff in between. It also
+// stops early unless the time left before ctx's deadline covers the wait
+// and another call taking as long as the last one. Failures are returned as
+// *Error.
+func (p Policy) Do(ctx context.Context, op func() error) error {
+	for attempt := 1; ; attempt++ {
+		start := time.Now()
+		err := op()
+		if err == nil {
+			return nil
+		}
+		if IsPermanent(err) || attempt >= p.Attempts {
+			return &Error{Attempts: attempt, Err: err}
+		}
+
+		delay := p.delay(attempt)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline)-delay-time.Since(start) < deadlineReserve {
+			return &Error{Attempts: attempt, Err: err}
+		}
+		select {
+		case <-ctx.Done():
+			return &Error{Attempts: attempt, Err: err}
+		case <-time.After(delay):
+		}
+	}
+}
+
+// delay is the wait before retry number attempt: a random duration up to
+// BaseDelay doubled for each earlier retry, capped at MaxDelay.
+func (p Policy) delay(attempt int) time.Duration {
+	ceiling := p.BaseDelay << (attempt - 1)
+	if ceiling > p.MaxDelay || ceiling <= 0 {
+		ceiling = p.MaxDelay
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return rand.N(ceiling) + 1
+}
@@ -0,0 +1,147 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var errTransient = errors.New("throttled")
+
+// fast retries without waiting noticeably.
+var fast = Policy{Attempts: 4, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+
+func TestDo(t *testing.T) {
+	tests := []struct {
+		name         string
+		failures     int // Calls failing with errTransient before one succeeds
+		wantCalls    int
+		wantAttempts int // Of the returned *Error; zero for success
+	}{
+		{"first try", 0, 1, 0},
+		{"after retries", 3, 4, 0},
+		{"attempts run out", 10, 4, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			err := fast.Do(context.Background(), func() error {
+				calls++
+				if calls <= tt.failures {
+					return errTransient
+				}
+				return nil
+			})
+			if calls != tt.wantCalls {
+				t.Errorf("op called %d times, want %d", calls, tt.wantCalls)
+			}
+			if tt.wantAttempts == 0 {
+				if err != nil {
+					t.Errorf("Do() = %v, want nil", err)
+				}
+				return
+			}
+			var retryErr *Error
+			if !errors.As(err, &retryErr) || retryErr.Attempts != tt.wantAttempts {
+				t.Fatalf("Do() = %v, want *Error after %d attempts", err, tt.wantAttempts)
+			}
+			if !errors.Is(err, errTransient) {
+				t.Errorf("Do() = %v, want it to wrap the last failure", err)
+			}
+		})
+	}
+}
+
+func TestDoPermanent(t *testing.T) {
+	errGone := errors.New("problem deleted")
+	calls := 0
+	err := fast.Do(context.Background(), func() error {
+		calls++
+		return Permanent(errGone)
+	})
+	if calls != 1 {
+		t.Errorf("op called %d times, want 1", calls)
+	}
+	if !errors.Is(err, errGone) || !IsPermanent(err) {
+		t.Errorf("Do() = %v, want the permanent error", err)
+	}
+	var retryErr *Error
+	if !errors.As(err, &retryErr) || retryErr.Attempts != 1 {
+		t.Errorf("Do() = %v, want *Error after 1 attempt", err)
+	}
+}
+
+func TestPermanent(t *testing.T) {
+	if Permanent(nil) != nil {
+		t.Error("Permanent(nil) != nil")
+	}
+	if IsPermanent(errTransient) {
+		t.Error("IsPermanent(plain error) = true")
+	}
+	wrapped := errors.Join(errTransient, Permanent(errors.New("bad config")))
+	if !IsPermanent(wrapped) {
+		t.Error("IsPermanent doesn't see a wrapped permanent error")
+	}
+}
+
+func TestDoDeadline(t *testing.T) {
+	// Too close to the deadline for another try after the failure
+	ctx, cancel := context.WithTimeout(context.Background(), deadlineReserve+500*time.Millisecond)
+	defer cancel()
+
+	calls := 0
+	err := fast.Do(ctx, func() error {
+		calls++
+		time.Sleep(time.Second) // Another call this long wouldn't finish in time
+		return errTransient
+	})
+	if calls != 1 {
+		t.Errorf("op called %d times, want 1", calls)
+	}
+	if !errors.Is(err, errTransient) {
+		t.Errorf("Do() = %v, want the failure", err)
+	}
+}
+
+func TestDoDeadlineRoom(t *testing.T) {
+	// Quick calls leave room for every attempt
+	ctx, cancel := context.WithTimeout(context.Background(), deadlineReserve+time.Second)
+	defer cancel()
+
+	calls := 0
+	fast.Do(ctx, func() error {
+		calls++
+		return errTransient
+	})
+	if calls != fast.Attempts {
+		t.Errorf("op called %d times, want %d", calls, fast.Attempts)
+	}
+}
+
+func TestDoCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	slow := Policy{Attempts: 4, BaseDelay: time.Hour, MaxDelay: time.Hour}
+
+	calls := 0
+	err := slow.Do(ctx, func() error {
+		calls++
+		cancel()
+		return errTransient
+	})
+	if calls != 1 || !errors.Is(err, errTransient) {
+		t.Errorf("op called %d times returning %v, want 1 call returning the failure", calls, err)
+	}
+}
+
+func TestDelay(t *testing.T) {
+	p := Policy{Attempts: 10, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	for attempt := 1; attempt <= 8; attempt++ {
+		ceiling := min(p.BaseDelay<<(attempt-1), p.MaxDelay)
+		for i := 0; i < 100; i++ {
+			if d := p.delay(attempt); d <= 0 || d > ceiling {
+				t.Fatalf("delay(%d) = %v, want in (0, %v]", attempt, d, ceiling)
+			}
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+
+	"learncode/backend/db"
+	"learncode/backend/judge"
+	"learncode/backend/notify"
+	"learncode/backend/types"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// Handler returns the webhook handler of the runner lambda for language.
+// Every runner judges the same way and only differs in the toolchain its
+// lambda has installed.
+func Handler(language string) func(context.Context, events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return func(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		// Reference solutions and input validators are checked synchronously
+		if response, ok := judge.HandleValidation(ctx, event); ok {
+			return response, nil
+		}
+
+		// Parse submission data
+		submission, err := judge.ParseSubmission(event.Body)
+		if err != nil {
+			return events.APIGatewayProxyResponse{
+				StatusCode: 400,
+				Body:       fmt.Sprintf(`{"error": "%v"}`, err),
+			}, nil
+		}
+
+		// Get problem from DynamoDB
+		problem, err := LoadProblem(ctx, submission)
+		if err != nil {
+			return DeadLetter(ctx, submission, types.StageLoadProblem, err), nil
+		}
+
+		// Update status to running
+		if err := SetStatus(ctx, submission, types.StatusRunning, nil); err != nil {
+			return DeadLetter(ctx, submission, types.StageStart, err), nil
+		}
+		notify.Status(ctx, submission, types.StatusRunning)
+
+		// Run code against the problem's tests, streaming each test's result
+		result, err := Judge(ctx, problem, language, submission)
+		if err != nil {
+			return DeadLetter(ctx, submission, types.StageJudge, err), nil
+		}
+
+		// Update submission status
+		status := types.VerdictStatus(result.Verdict)
+		if err := SetStatus(ctx, submission, status, &result.Output); err != nil {
+			return DeadLetter(ctx, submission, types.StageFinish, err), nil
+		}
+
+		// Store the verdict and count it towards the problem's statistics
+		if err := db.RecordVerdict(ctx, submission, result.Verdict, result.Tests); err != nil {
+			fmt.Printf("Failed to record verdict: %v\n", err)
+		}
+		notify.Verdict(ctx, submission, status, result.Verdict)
+
+		return events.APIGatewayProxyResponse{
+			StatusCode: 200,
+			Body:       fmt.Sprintf(`{"status": %q, "output": %q}`, status, result.Output),
+		}, nil
+	}
+}
@@ -0,0 +1,128 @@
+// Package runner holds the steps the runner lambdas take to judge a
+// submission, each retrying transient infrastructure failures.
+//
+// Failures caused by the submitted code never reach these errors; they are
+// reported through the judge's verdict. What is left is infrastructure:
+// DynamoDB, S3 or the runner's own sandbox. Once a step runs out of retries
+// the runner hands the job to DeadLetter, which stores it for an admin to
+// inspect and replay.
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"learncode/backend/db"
+	"learncode/backend/judge"
+	"learncode/backend/notify"
+	"learncode/backend/retry"
+	"learncode/backend/types"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// LoadProblem gets the problem a submission is for. A deleted problem is a
+// permanent failure.
+func LoadProblem(ctx context.Context, submission *types.Submission) (*types.Problem, error) {
+	var problem *types.Problem
+	err := retry.DefaultPolicy.Do(ctx, func() error {
+		var err error
+		problem, err = db.GetProblem(ctx, submission.ProblemID)
+		if errors.Is(err, db.ErrProblemNotFound) {
+			return retry.Permanent(err)
+		}
+		return err
+	})
+	return problem, err
+}
+
+// SetStatus updates the submission's status, and its result if not nil. A
+// status the submission can't move to is a permanent failure.
+func SetStatus(ctx context.Context, submission *types.Submission, status types.SubmissionStatus, result *string) error {
+	return retry.DefaultPolicy.Do(ctx, func() error {
+		err := db.UpdateSubmissionStatus(ctx, submission.ProblemID, submission.SubmissionID, status, result)
+		if errors.Is(err, db.ErrInvalidTransition) {
+			return retry.Permanent(err)
+		}
+		return err
+	})
+}
+
+// Judge runs the submission as the runner's language against the problem's
+// tests, streaming each test's result to the submission's owner. A problem
+// that can't be judged is a permanent failure, and tests already reported
+// aren't reported again when a run is retried.
+func Judge(ctx context.Context, problem *types.Problem, language string, submission *types.Submission) (*judge.Result, error) {
+	var result *judge.Result
+	reported := 0
+	err := retry.DefaultPolicy.Do(ctx, func() error {
+		var err error
+		result, err = judge.JudgeWithProgress(ctx, problem, language, submission.Code, func(index int, test judge.TestResult) {
+			if index > reported {
+				notify.TestDone(ctx, submission, index, test)
+				reported = index
+			}
+		})
+		if errors.Is(err, judge.ErrProblemConfig) {
+			return retry.Permanent(err)
+		}
+		return err
+	})
+	return result, err
+}
+
+// DeadLetter stores a job that failed at stage for good and marks its
+// submission system_error, then returns the webhook response. The response
+// is a 200 once the dead letter is stored, since redelivering the message
+// wouldn't help.
+//
+// A job whose submission moved on without it, such as a redelivered message
+// for a submission that was already judged, is dropped instead.
+func DeadLetter(ctx context.Context, submission *types.Submission, stage string, err error) events.APIGatewayProxyResponse {
+	if errors.Is(err, db.ErrInvalidTransition) {
+		fmt.Printf("Dropping %s at %s: %v\n", submission.SubmissionID, stage, err)
+		return events.APIGatewayProxyResponse{
+			StatusCode: 200,
+			Body:       fmt.Sprintf(`{"status": "skipped", "stage": %q}`, stage),
+		}
+	}
+
+	attempts := 1
+	var retryErr *retry.Error
+	if errors.As(err, &retryErr) {
+		attempts = retryErr.Attempts
+	}
+	now := time.Now().Unix()
+
+	fmt.Printf("Dead-lettering %s at %s: %v\n", submission.SubmissionID, stage, err)
+	if saveErr := db.SaveDeadLetter(ctx, &types.DeadLetter{
+		ID:         submission.SubmissionID,
+		ProblemID:  submission.ProblemID,
+		UserID:     submission.UserID,
+		Language:   submission.Language,
+		Stage:      stage,
+		Error:      err.Error(),
+		Attempts:   attempts,
+		Submission: submission,
+		FailedAt:   now,
+	}); saveErr != nil {
+		return events.APIGatewayProxyResponse{
+			StatusCode: 500,
+			Body:       fmt.Sprintf(`{"error": "Failed at %s: %v; %v"}`, stage, err, saveErr),
+		}
+	}
+
+	// Best effort: if this fails too, the reaper retries the submission
+	reason := fmt.Sprintf("The %s runner failed at %s and gave up; an admin can replay it", submission.Language, stage)
+	if markErr := db.SetSystemError(ctx, submission, reason, now); markErr != nil {
+		fmt.Printf("Failed to mark %s system_error: %v\n", submission.SubmissionID, markErr)
+	}
+	notify.Verdict(ctx, submission, types.StatusSystemError, "")
+
+	return events.APIGatewayProxyResponse{
+		StatusCode: 200,
+		Body:       fmt.Sprintf(`{"error": "Failed at %s", "dead_lettered": true}`, stage),
+	}
+}
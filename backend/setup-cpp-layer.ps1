@@ -0,0 +1,21 @@
+# Create layer directory structure
+New-Item -ItemType Directory -Force -Path lambda/layers/cpp
+
+# Set working directory
+Push-Location lambda/layers/cpp
+
+# Download a relocatable GCC toolchain (statically linked, musl based)
+Invoke-WebRequest -Uri "https://musl.cc/x86_64-linux-musl-native.tgz" -OutFile "gcc.tgz"
+
+# Extract using 7zip (needs to be installed)
+7z x -y gcc.tgz
+7z x -y gcc.tar
+
+# Lambda puts /opt/bin on the PATH, so the toolchain goes at the layer root
+Copy-Item -Recurse -Force "x86_64-linux-musl-native/*" -Destination "."
+
+# Cleanup
+Remove-Item -Recurse -Force -ErrorAction SilentlyContinue gcc.tgz, gcc.tar, x86_64-linux-musl-native
+
+# Return to original directory
+Pop-Location
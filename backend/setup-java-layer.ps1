@@ -0,0 +1,40 @@
+# Create layer directory structure
+New-Item -ItemType Directory -Force -Path lambda/layers/java
+
+# Set working directory
+Push-Location lambda/layers/java
+
+# Download the Linux JDK, whose jmods the runtime is built from, and the
+# same JDK release for this machine, whose jlink builds it. jlink can build
+# an image for another platform as long as both JDKs are the same release.
+Invoke-WebRequest -Uri "https://github.com/adoptium/temurin17-binaries/releases/download/jdk-17.0.13%2B11/OpenJDK17U-jdk_x64_linux_hotspot_17.0.13_11.tar.gz" -OutFile "jdk.tar.gz"
+Invoke-WebRequest -Uri "https://github.com/adoptium/temurin17-binaries/releases/download/jdk-17.0.13%2B11/OpenJDK17U-jdk_x64_windows_hotspot_17.0.13_11.zip" -OutFile "host-jdk.zip"
+
+# Extract using 7zip (needs to be installed)
+7z x -y jdk.tar.gz
+7z x -y jdk.tar
+7z x -y -ohost host-jdk.zip
+
+# A full JDK is over 300 MB unzipped, more than Lambda allows a function and
+# its layers together (250 MB). Link a runtime with only what javac and
+# java need; the judge runs them as /opt/java/bin/javac and /opt/java/bin/java
+Remove-Item -Recurse -Force -ErrorAction SilentlyContinue java
+& "host/jdk-17.0.13+11/bin/jlink.exe" `
+    --module-path "jdk-17.0.13+11/jmods" `
+    --add-modules java.base,jdk.compiler `
+    --strip-debug --compress=2 --no-header-files --no-man-pages `
+    --output java
+
+# Cleanup
+Remove-Item -Recurse -Force -ErrorAction SilentlyContinue jdk.tar.gz, jdk.tar, host-jdk.zip, jdk-17.0.13+11, host
+
+# Report the layer size and refuse one that leaves the runner too little room
+$size = (Get-ChildItem -Recurse -File java | Measure-Object -Property Length -Sum).Sum / 1MB
+Write-Output ("Java layer: {0:N0} MB unzipped" -f $size)
+if ($size -gt 200) {
+    Pop-Location
+    throw "Java layer is over 200 MB; check the modules passed to jlink"
+}
+
+# Return to original directory
+Pop-Location
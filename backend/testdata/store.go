@@ -0,0 +1,115 @@
+package testdata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// Store holds test data payloads by key.
+type Store interface {
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Put(ctx context.Context, key string, body io.ReadSeeker) error
+}
+
+// NewStore returns the store configured by the environment:
+//
+//   - TESTDATA_BUCKET selects an S3 bucket. TESTDATA_ENDPOINT optionally
+//     points the client at an S3-compatible server such as MinIO.
+//   - TESTDATA_DIR selects a local directory, for development.
+//
+// It returns a nil store when neither is set.
+func NewStore(ctx context.Context) (Store, error) {
+	if bucket := os.Getenv("TESTDATA_BUCKET"); bucket != "" {
+		cfg, err := config.LoadDefaultConfig(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load SDK config: %v", err)
+		}
+
+		client := s3.NewFromConfig(cfg, func(o *s3.Options) {
+			if endpoint := os.Getenv("TESTDATA_ENDPOINT"); endpoint != "" {
+				o.BaseEndpoint = aws.String(endpoint)
+				o.UsePathStyle = true
+			}
+		})
+		return &S3Store{Client: client, Bucket: bucket}, nil
+	}
+
+	if dir := os.Getenv("TESTDATA_DIR"); dir != "" {
+		return DirStore(dir), nil
+	}
+
+	return nil, nil
+}
+
+type S3Store struct {
+	Client *s3.Client
+	Bucket string
+}
+
+func (s *S3Store) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	result, err := s.Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s: %v", key, err)
+	}
+	return result.Body, nil
+}
+
+func (s *S3Store) Put(ctx context.Context, key string, body io.ReadSeeker) error {
+	_, err := s.Client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(key),
+		Body:   body,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put %s: %v", key, err)
+	}
+	return nil
+}
+
+// DirStore keeps payloads as files under a local directory.
+type DirStore string
+
+func (d DirStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	return os.Open(filepath.Join(string(d), filepath.FromSlash(key)))
+}
+
+func (d DirStore) Put(ctx context.Context, key string, body io.ReadSeeker) error {
+	path := filepath.Join(string(d), filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, body); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Discard computes keys without storing anything, for dry runs.
+var Discard Store = discardStore{}
+
+type discardStore struct{}
+
+func (discardStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	return nil, errors.New("discard store holds no data")
+}
+
+func (discardStore) Put(ctx context.Context, key string, body io.ReadSeeker) error {
+	return nil
+}
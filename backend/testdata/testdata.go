@@ -0,0 +1,322 @@
+// Package testdata moves large test case payloads out of DynamoDB items and
+// into an object store, and gives runners cached, checksum-verified access
+// to them.
+package testdata
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"learncode/backend/types"
+)
+
+// InlineLimit is the largest payload kept inline in the problem item.
+// DynamoDB caps whole items at 400 KB, so anything bigger goes to the store.
+const InlineLimit = 32 * 1024
+
+// ItemLimit is how big a problem item may get with its payloads inline.
+// Beyond it the largest payloads go to the store too, leaving room under
+// DynamoDB's 400 KB for attribute overhead and later reviews and
+// translations.
+const ItemLimit = 300 * 1024
+
+// previewLength is how much of an offloaded legacy Input/Output is kept on
+// the problem for display.
+const previewLength = 1024
+
+// CacheDir survives between warm invocations of a runner, so payloads are
+// only downloaded once per container. CacheLimit bounds its size.
+var (
+	CacheDir         = filepath.Join(os.TempDir(), "testdata-cache")
+	CacheLimit int64 = 256 << 20
+)
+
+var (
+	defaultStore Store
+	storeErr     error
+	storeOnce    sync.Once
+	cacheMu      sync.Mutex
+)
+
+func store(ctx context.Context) (Store, error) {
+	storeOnce.Do(func() {
+		defaultStore, storeErr = NewStore(ctx)
+	})
+	if storeErr != nil {
+		return nil, storeErr
+	}
+	if defaultStore == nil {
+		return nil, fmt.Errorf("no test data store configured: set TESTDATA_BUCKET or TESTDATA_DIR")
+	}
+	return defaultStore, nil
+}
+
+// OpenInput returns a reader over the test's input, streamed from the
+// local cache when the input lives in the store.
+func OpenInput(ctx context.Context, test types.TestCase) (io.ReadCloser, error) {
+	if test.InputKey == "" {
+		return io.NopCloser(strings.NewReader(test.Input)), nil
+	}
+	return open(ctx, test.InputKey, test.InputSHA256)
+}
+
+// ReadOutput returns the test's expected output.
+func ReadOutput(ctx context.Context, test types.TestCase) (string, error) {
+	if test.OutputKey == "" {
+		return test.Output, nil
+	}
+
+	f, err := open(ctx, test.OutputKey, test.OutputSHA256)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %v", test.OutputKey, err)
+	}
+	return string(data), nil
+}
+
+// ReadInput returns the test's input as a string.
+func ReadInput(ctx context.Context, test types.TestCase) (string, error) {
+	f, err := OpenInput(ctx, test)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %v", test.InputKey, err)
+	}
+	return string(data), nil
+}
+
+// open returns the cached copy of a payload, downloading it first if needed.
+// Cached files are named by checksum, so a changed payload never hits a
+// stale entry.
+func open(ctx context.Context, key string, checksum string) (io.ReadCloser, error) {
+	if checksum == "" {
+		return nil, fmt.Errorf("test data %s has no checksum", key)
+	}
+
+	path := filepath.Join(CacheDir, checksum)
+	if f, err := os.Open(path); err == nil {
+		now := time.Now()
+		os.Chtimes(path, now, now)
+		return f, nil
+	}
+
+	s, err := store(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := s.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	if err := os.MkdirAll(CacheDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create cache dir: %v", err)
+	}
+	tmp, err := os.CreateTemp(CacheDir, "download-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cache file: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(tmp, hash), body); err != nil {
+		tmp.Close()
+		return nil, fmt.Errorf("failed to download %s: %v", key, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write cache file: %v", err)
+	}
+
+	if actual := hex.EncodeToString(hash.Sum(nil)); actual != checksum {
+		return nil, fmt.Errorf("checksum mismatch for %s: expected %s, got %s", key, checksum, actual)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return nil, fmt.Errorf("failed to cache %s: %v", key, err)
+	}
+	evict()
+
+	return os.Open(path)
+}
+
+// evict removes least recently used cache entries until the cache fits in
+// CacheLimit.
+func evict() {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+
+	entries, err := os.ReadDir(CacheDir)
+	if err != nil {
+		return
+	}
+
+	var files []os.FileInfo
+	var total int64
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || strings.HasPrefix(entry.Name(), "download-") {
+			continue
+		}
+		files = append(files, info)
+		total += info.Size()
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].ModTime().Before(files[j].ModTime())
+	})
+	for _, info := range files {
+		if total <= CacheLimit {
+			break
+		}
+		if os.Remove(filepath.Join(CacheDir, info.Name())) == nil {
+			total -= info.Size()
+		}
+	}
+}
+
+// Offload moves every test payload larger than InlineLimit into s and
+// replaces it with a content-addressed key and checksum, then the largest
+// of the rest until the marshalled problem fits in ItemLimit. Legacy
+// single-test problems are converted to a one-element Tests list first, and
+// the legacy Input/Output fields are cut down to a preview.
+func Offload(ctx context.Context, s Store, problem *types.Problem) error {
+	if len(problem.Tests) == 0 && needsOffload(problem) {
+		problem.Tests = []types.TestCase{{Input: problem.Input, Output: problem.Output}}
+		problem.Input = preview(problem.Input, 0)
+		problem.Output = preview(problem.Output, 0)
+	}
+	problem.Input = preview(problem.Input, InlineLimit)
+	problem.Output = preview(problem.Output, InlineLimit)
+
+	// Every inline payload, largest first
+	var payloads []payload
+	for i := range problem.Tests {
+		test := &problem.Tests[i]
+		payloads = append(payloads,
+			payload{&test.Input, &test.InputKey, &test.InputSHA256},
+			payload{&test.Output, &test.OutputKey, &test.OutputSHA256})
+	}
+	sort.SliceStable(payloads, func(i, j int) bool {
+		return len(*payloads[i].data) > len(*payloads[j].data)
+	})
+
+	size := itemSize(problem)
+	for _, p := range payloads {
+		if len(*p.data) <= InlineLimit && size <= ItemLimit {
+			break
+		}
+		if *p.data == "" {
+			break
+		}
+		key, checksum, err := put(ctx, s, *p.data)
+		if err != nil {
+			return err
+		}
+		size += len(key) + len(checksum) + len(`"input_key":"","input_sha256":"",`) - len(*p.data)
+		*p.data, *p.key, *p.checksum = "", key, checksum
+	}
+
+	if size > ItemLimit {
+		return fmt.Errorf("problem is %d KB even with its test data in the store", size>>10)
+	}
+	return nil
+}
+
+// payload is one input or output of a test.
+type payload struct {
+	data, key, checksum *string
+}
+
+// OffloadDefault is Offload against the store configured by the
+// environment. Problems that fit inline don't need a store at all.
+func OffloadDefault(ctx context.Context, problem *types.Problem) error {
+	if !needsOffload(problem) {
+		return nil
+	}
+	s, err := store(ctx)
+	if err != nil {
+		return err
+	}
+	return Offload(ctx, s, problem)
+}
+
+func needsOffload(problem *types.Problem) bool {
+	if len(problem.Input) > InlineLimit || len(problem.Output) > InlineLimit {
+		return true
+	}
+	for _, test := range problem.Tests {
+		if len(test.Input) > InlineLimit || len(test.Output) > InlineLimit {
+			return true
+		}
+	}
+	return itemSize(problem) > ItemLimit
+}
+
+// itemSize estimates the size of the problem's DynamoDB item from its JSON,
+// which uses the same attribute names and is no smaller.
+func itemSize(problem *types.Problem) int {
+	data, _ := json.Marshal(problem)
+	return len(data)
+}
+
+// Inline loads every stored payload back into the problem, for exporting.
+func Inline(ctx context.Context, problem *types.Problem) error {
+	for i := range problem.Tests {
+		test := &problem.Tests[i]
+		if test.InputKey != "" {
+			input, err := ReadInput(ctx, *test)
+			if err != nil {
+				return err
+			}
+			test.Input, test.InputKey, test.InputSHA256 = input, "", ""
+		}
+		if test.OutputKey != "" {
+			output, err := ReadOutput(ctx, *test)
+			if err != nil {
+				return err
+			}
+			test.Output, test.OutputKey, test.OutputSHA256 = output, "", ""
+		}
+	}
+	return nil
+}
+
+func put(ctx context.Context, s Store, payload string) (string, string, error) {
+	sum := sha256.Sum256([]byte(payload))
+	checksum := hex.EncodeToString(sum[:])
+	key := "tests/" + checksum
+
+	if err := s.Put(ctx, key, strings.NewReader(payload)); err != nil {
+		return "", "", fmt.Errorf("failed to store test data: %v", err)
+	}
+	return key, checksum, nil
+}
+
+// preview cuts s down to a preview if it is longer than limit.
+func preview(s string, limit int) string {
+	if len(s) <= limit || len(s) <= previewLength {
+		return s
+	}
+	return s[:previewLength] + "\n..."
+}
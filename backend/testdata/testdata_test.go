@@ -0,0 +1,186 @@
+package testdata
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"strings"
+	"testing"
+
+	"learncode/backend/types"
+)
+
+// mapStore keeps payloads in memory.
+type mapStore map[string]string
+
+func (m mapStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader(m[key])), nil
+}
+
+func (m mapStore) Put(ctx context.Context, key string, body io.ReadSeeker) error {
+	data, err := io.ReadAll(body)
+	m[key] = string(data)
+	return err
+}
+
+func checksum(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestOffloadInlineLimit(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   int
+		output  int
+		offload []bool // Whether the input and output go to the store
+	}{
+		{"small", 10, 10, []bool{false, false}},
+		{"at the limit", InlineLimit, InlineLimit, []bool{false, false}},
+		{"input over the limit", InlineLimit + 1, 10, []bool{true, false}},
+		{"output over the limit", 10, InlineLimit + 1, []bool{false, true}},
+		{"both over the limit", InlineLimit + 1, 2 * InlineLimit, []bool{true, true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input, output := strings.Repeat("1", tt.input), strings.Repeat("2", tt.output)
+			problem := &types.Problem{Tests: []types.TestCase{{Input: input, Output: output}}}
+			store := mapStore{}
+
+			if err := Offload(context.Background(), store, problem); err != nil {
+				t.Fatal(err)
+			}
+
+			test := problem.Tests[0]
+			for i, p := range []struct{ data, key, sum, want string }{
+				{test.Input, test.InputKey, test.InputSHA256, input},
+				{test.Output, test.OutputKey, test.OutputSHA256, output},
+			} {
+				if !tt.offload[i] {
+					if p.data != p.want || p.key != "" || p.sum != "" {
+						t.Errorf("payload %d was offloaded, want it inline", i)
+					}
+					continue
+				}
+				if p.data != "" || p.key != "tests/"+checksum(p.want) || p.sum != checksum(p.want) {
+					t.Errorf("payload %d = %q, %q, %q, want it in the store", i, p.data[:min(len(p.data), 10)], p.key, p.sum)
+				}
+				if store[p.key] != p.want {
+					t.Errorf("stored payload %d has %d bytes, want %d", i, len(store[p.key]), len(p.want))
+				}
+			}
+			if want := tt.offload[0] || tt.offload[1]; needsOffload(&types.Problem{Tests: []types.TestCase{{Input: input, Output: output}}}) != want {
+				t.Errorf("needsOffload() = %v, want %v", !want, want)
+			}
+		})
+	}
+}
+
+// sizedProblem returns a problem of exactly size bytes made of tests whose
+// inputs each fit inline, the first test's the largest.
+func sizedProblem(t *testing.T, size int) *types.Problem {
+	t.Helper()
+	problem := &types.Problem{}
+	n := size / (InlineLimit + 100)
+	for i := 0; i < n; i++ {
+		problem.Tests = append(problem.Tests, types.TestCase{
+			Input:  strings.Repeat("1", InlineLimit-i),
+			Output: "1",
+		})
+	}
+	extra := size - itemSize(problem)
+	if extra < 0 || extra >= InlineLimit-n {
+		t.Fatalf("can't pad a %d byte problem to %d bytes", itemSize(problem), size)
+	}
+	problem.Tests[n-1].Output += strings.Repeat("1", extra)
+	if itemSize(problem) != size {
+		t.Fatalf("problem is %d bytes, want %d", itemSize(problem), size)
+	}
+	return problem
+}
+
+func TestOffloadItemLimit(t *testing.T) {
+	tests := []struct {
+		name   string
+		size   int
+		stored int
+	}{
+		{"under the limit", ItemLimit - 1, 0},
+		{"at the limit", ItemLimit, 0},
+		{"over the limit", ItemLimit + 1, 1},
+		{"far over the limit", ItemLimit + 3*InlineLimit, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problem := sizedProblem(t, tt.size)
+			if got, want := needsOffload(problem), tt.stored > 0; got != want {
+				t.Errorf("needsOffload() = %v, want %v", got, want)
+			}
+
+			store := mapStore{}
+			if err := Offload(context.Background(), store, problem); err != nil {
+				t.Fatal(err)
+			}
+			if len(store) != tt.stored {
+				t.Errorf("stored %d payloads, want %d", len(store), tt.stored)
+			}
+			if size := itemSize(problem); size > ItemLimit {
+				t.Errorf("problem is %d bytes after offloading, want at most %d", size, ItemLimit)
+			}
+			// The largest payloads go first
+			for i, test := range problem.Tests {
+				if offloaded := test.InputKey != ""; offloaded != (i < tt.stored) {
+					t.Errorf("test %d offloaded = %v, want %v", i, offloaded, i < tt.stored)
+				}
+			}
+		})
+	}
+}
+
+func TestOffloadLegacyProblem(t *testing.T) {
+	input := strings.Repeat("1", InlineLimit+1)
+	problem := &types.Problem{Input: input, Output: "2"}
+	store := mapStore{}
+
+	if err := Offload(context.Background(), store, problem); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(problem.Tests) != 1 {
+		t.Fatalf("got %d tests, want the legacy test converted", len(problem.Tests))
+	}
+	if test := problem.Tests[0]; store[test.InputKey] != input || test.Output != "2" {
+		t.Errorf("test = %+v, want the input stored and the output inline", test)
+	}
+	if want := input[:previewLength] + "\n..."; problem.Input != want {
+		t.Errorf("legacy input has %d bytes, want a %d byte preview", len(problem.Input), len(want))
+	}
+	if problem.Output != "2" {
+		t.Errorf("legacy output = %q, want it kept", problem.Output)
+	}
+}
+
+func TestOffloadTooLarge(t *testing.T) {
+	problem := &types.Problem{Description: strings.Repeat("x", ItemLimit)}
+	if err := Offload(context.Background(), mapStore{}, problem); err == nil {
+		t.Error("Offload() succeeded, want an error for a statement over the item limit")
+	}
+}
+
+func TestOpenVerifiesChecksum(t *testing.T) {
+	CacheDir = t.TempDir()
+	storeOnce.Do(func() {})
+	defaultStore = mapStore{"tests/a": "payload"}
+	t.Cleanup(func() { defaultStore = nil })
+
+	test := types.TestCase{InputKey: "tests/a", InputSHA256: checksum("payload")}
+	if input, err := ReadInput(context.Background(), test); err != nil || input != "payload" {
+		t.Errorf("ReadInput() = %q, %v, want the stored payload", input, err)
+	}
+
+	test = types.TestCase{OutputKey: "tests/a", OutputSHA256: checksum("other")}
+	if _, err := ReadOutput(context.Background(), test); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("ReadOutput() error = %v, want a checksum mismatch", err)
+	}
+}
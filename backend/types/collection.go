@@ -0,0 +1,52 @@
+package types
+
+import "fmt"
+
+// Collection is an ordered list of problems, such as "Week 1: Arrays".
+// Published collections are shown to learners as study plans.
+type Collection struct {
+	ID          string   `json:"id" dynamodbav:"id"`
+	Title       string   `json:"title" dynamodbav:"title"`
+	Description string   `json:"description" dynamodbav:"description"`
+	ProblemIDs  []string `json:"problem_ids" dynamodbav:"problem_ids"` // In study order
+	OwnerID     string   `json:"owner_id" dynamodbav:"owner_id"`       // User who created it
+	Published   bool     `json:"published" dynamodbav:"published"`
+	CreatedAt   int64    `json:"created_at" dynamodbav:"created_at"` // Unix timestamp
+	UpdatedAt   int64    `json:"updated_at" dynamodbav:"updated_at"` // Unix timestamp
+}
+
+// maxCollectionProblems keeps study plans short enough to compute progress
+// for in one request.
+const maxCollectionProblems = 100
+
+// Validate checks the rules every stored collection has to satisfy. Whether
+// the problems exist is up to the caller.
+func (c *Collection) Validate() error {
+	if c.Title == "" {
+		return fmt.Errorf("Title is required")
+	}
+	if len(c.ProblemIDs) > maxCollectionProblems {
+		return fmt.Errorf("A collection can hold at most %d problems", maxCollectionProblems)
+	}
+
+	seen := map[string]bool{}
+	for _, id := range c.ProblemIDs {
+		if seen[id] {
+			return fmt.Errorf("Problem %s is in the collection twice", id)
+		}
+		seen[id] = true
+	}
+	return nil
+}
+
+// CanEdit reports whether the user may change or delete the collection:
+// admins can edit every collection, instructors their own.
+func (c *Collection) CanEdit(user *User) bool {
+	return user.IsAdmin || (user.Role == RoleInstructor && user.ID == c.OwnerID)
+}
+
+// CanView reports whether the user may see the collection. Unpublished
+// collections are only shown to those who can edit them.
+func (c *Collection) CanView(user *User) bool {
+	return c.Published || c.CanEdit(user)
+}
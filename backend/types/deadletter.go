@@ -0,0 +1,25 @@
+package types
+
+// Stages of judging a submission, naming where a dead-lettered job failed.
+const (
+	StageLoadProblem = "load_problem"
+	StageStart       = "start"  // Marking the submission running
+	StageJudge       = "judge"  // Running the tests
+	StageFinish      = "finish" // Storing the result
+)
+
+// DeadLetter is a submission a runner gave up on after retrying an
+// infrastructure failure. It is keyed by submission ID, so a submission
+// that fails again replaces its earlier dead letter.
+type DeadLetter struct {
+	ID         string      `json:"id" dynamodbav:"id"` // Submission ID
+	ProblemID  string      `json:"problem_id" dynamodbav:"problem_id"`
+	UserID     string      `json:"user_id" dynamodbav:"user_id"`
+	Language   string      `json:"language" dynamodbav:"language"`
+	Stage      string      `json:"stage" dynamodbav:"stage"`
+	Error      string      `json:"error" dynamodbav:"error"`
+	Attempts   int         `json:"attempts" dynamodbav:"attempts"`
+	Submission *Submission `json:"submission" dynamodbav:"submission"`                       // As the runner received it
+	FailedAt   int64       `json:"failed_at" dynamodbav:"failed_at"`                         // Unix timestamp
+	ReplayedAt int64       `json:"replayed_at,omitempty" dynamodbav:"replayed_at,omitempty"` // Unix timestamp an admin last replayed it at
+}
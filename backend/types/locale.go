@@ -0,0 +1,89 @@
+package types
+
+import (
+	"fmt"
+	"sort"
+
+	"golang.org/x/text/language"
+)
+
+// DefaultLocale is the locale of problems that don't set one.
+const DefaultLocale = "en"
+
+// Translation is a problem statement in another locale.
+type Translation struct {
+	Title       string `json:"title" dynamodbav:"title"`
+	Description string `json:"description" dynamodbav:"description"`
+}
+
+// BaseLocale returns the locale the problem's own title and description are
+// written in.
+func (p *Problem) BaseLocale() string {
+	if p.Locale != "" {
+		return p.Locale
+	}
+	return DefaultLocale
+}
+
+// Locales returns every locale the problem can be shown in, its base locale
+// first.
+func (p *Problem) Locales() []string {
+	var translated []string
+	for locale := range p.Translations {
+		translated = append(translated, locale)
+	}
+	sort.Strings(translated)
+	return append([]string{p.BaseLocale()}, translated...)
+}
+
+// MatchLocale picks the available locale that best matches the requested
+// ones, falling back to the base locale. Each preference is a locale such as
+// "pt-BR" or a whole Accept-Language header, most preferred first.
+func (p *Problem) MatchLocale(preferences ...string) string {
+	var desired []language.Tag
+	for _, preference := range preferences {
+		tags, _, err := language.ParseAcceptLanguage(preference)
+		if err == nil {
+			desired = append(desired, tags...)
+		}
+	}
+	if len(desired) == 0 {
+		return p.BaseLocale()
+	}
+
+	locales := p.Locales()
+	available := make([]language.Tag, len(locales))
+	for i, locale := range locales {
+		available[i] = language.Make(locale)
+	}
+	_, index, confidence := language.NewMatcher(available).Match(desired...)
+	if confidence == language.No {
+		return p.BaseLocale()
+	}
+	return locales[index]
+}
+
+// Localized returns a copy of the problem with its title and description in
+// locale, without the other translations. Unknown locales get the base
+// statement.
+func (p *Problem) Localized(locale string) *Problem {
+	localized := *p
+	localized.Locale = p.BaseLocale()
+	if translation, ok := p.Translations[locale]; ok {
+		localized.Title = translation.Title
+		localized.Description = translation.Description
+		localized.Locale = locale
+	}
+	localized.Translations = nil
+	return &localized
+}
+
+// ValidateLocale checks that locale is a well-formed language tag in
+// canonical form, such as "en" or "pt-BR".
+func ValidateLocale(locale string) error {
+	tag, err := language.Parse(locale)
+	if err != nil || tag.String() != locale {
+		return fmt.Errorf("Invalid locale: %s", locale)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package types
+
+import "testing"
+
+func TestMatchLocale(t *testing.T) {
+	problem := &Problem{
+		Translations: map[string]Translation{
+			"pt-BR": {Title: "Soma"},
+			"de":    {Title: "Summe"},
+		},
+	}
+	tests := []struct {
+		name        string
+		preferences []string
+		want        string
+	}{
+		{"no preference", nil, "en"},
+		{"empty preference", []string{""}, "en"},
+		{"exact match", []string{"de"}, "de"},
+		{"region falls back to language", []string{"de-AT"}, "de"},
+		{"language matches region", []string{"pt"}, "pt-BR"},
+		{"unavailable", []string{"ja"}, "en"},
+		{"accept-language order", []string{"ja, de;q=0.8, en;q=0.5"}, "de"},
+		{"accept-language quality", []string{"en;q=0.5, de;q=0.9"}, "de"},
+		{"first preference wins", []string{"pt-BR", "de"}, "pt-BR"},
+		{"malformed header skipped", []string{"!!", "de"}, "de"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := problem.MatchLocale(tt.preferences...); got != tt.want {
+				t.Errorf("MatchLocale(%q) = %q, want %q", tt.preferences, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchLocaleBase(t *testing.T) {
+	problem := &Problem{Locale: "fr", Translations: map[string]Translation{"en": {}}}
+	if got := problem.MatchLocale("ja"); got != "fr" {
+		t.Errorf("MatchLocale(ja) = %q, want the base locale fr", got)
+	}
+	if got := problem.MatchLocale("en-US"); got != "en" {
+		t.Errorf("MatchLocale(en-US) = %q, want en", got)
+	}
+}
+
+func TestValidateLocale(t *testing.T) {
+	for _, locale := range []string{"en", "pt-BR", "zh-Hant"} {
+		if err := ValidateLocale(locale); err != nil {
+			t.Errorf("ValidateLocale(%q) = %v, want nil", locale, err)
+		}
+	}
+	for _, locale := range []string{"", "pt_BR", "pt-br", "EN", "not a locale"} {
+		if err := ValidateLocale(locale); err == nil {
+			t.Errorf("ValidateLocale(%q) = nil, want an error", locale)
+		}
+	}
+}
@@ -1,15 +1,353 @@
 package types
 
+import "fmt"
+
 type Problem struct {
-	ID          string `json:"id" dynamodbav:"id"`
-	Title       string `json:"title" dynamodbav:"title"`
-	Description string `json:"description" dynamodbav:"description"`
-	Difficulty  string `json:"difficulty" dynamodbav:"difficulty"`
-	CreatedAt   int64  `json:"created_at" dynamodbav:"created_at"`                     // Unix timestamp
-	UpdatedAt   int64  `json:"updated_at" dynamodbav:"updated_at"`                     // Unix timestamp
-	DeletedAt   *int64 `json:"deleted_at,omitempty" dynamodbav:"deleted_at,omitempty"` // Optional Unix timestamp
-	Input       string `json:"input" dynamodbav:"input"`
-	Output      string `json:"output" dynamodbav:"output"`
-	ExampleInput       string `json:"example_input" dynamodbav:"example_input"`
-	ExampleOutput      string `json:"example_output" dynamodbav:"example_output"`
+	ID                 string                 `json:"id" dynamodbav:"id"`
+	Title              string                 `json:"title" dynamodbav:"title"`
+	Slug               string                 `json:"slug,omitempty" dynamodbav:"slug,omitempty"`
+	Description        string                 `json:"description" dynamodbav:"description"`
+	Difficulty         string                 `json:"difficulty" dynamodbav:"difficulty"`
+	Rating             int                    `json:"rating,omitempty" dynamodbav:"rating,omitempty"`               // Elo-style difficulty rating; zero until set or computed
+	RatingLocked       bool                   `json:"rating_locked,omitempty" dynamodbav:"rating_locked,omitempty"` // Rating was set by an admin, so the rating job leaves it alone
+	CreatedAt          int64                  `json:"created_at" dynamodbav:"created_at"`                           // Unix timestamp
+	UpdatedAt          int64                  `json:"updated_at" dynamodbav:"updated_at"`                           // Unix timestamp
+	DeletedAt          *int64                 `json:"deleted_at,omitempty" dynamodbav:"deleted_at,omitempty"`       // Optional Unix timestamp
+	Input              string                 `json:"input" dynamodbav:"input"`
+	Output             string                 `json:"output" dynamodbav:"output"`
+	ExampleInput       string                 `json:"example_input" dynamodbav:"example_input"`
+	ExampleOutput      string                 `json:"example_output" dynamodbav:"example_output"`
+	Tests              []TestCase             `json:"tests,omitempty" dynamodbav:"tests,omitempty"`
+	TimeLimitMs        int                    `json:"time_limit_ms,omitempty" dynamodbav:"time_limit_ms,omitempty"`
+	MemoryLimitMB      int                    `json:"memory_limit_mb,omitempty" dynamodbav:"memory_limit_mb,omitempty"`
+	Checker            *Program               `json:"checker,omitempty" dynamodbav:"checker,omitempty"`
+	ReferenceSolutions []ReferenceSolution    `json:"reference_solutions,omitempty" dynamodbav:"reference_solutions,omitempty"`
+	InputValidators    []Program              `json:"input_validators,omitempty" dynamodbav:"input_validators,omitempty"`
+	StarterCode        map[string]string      `json:"starter_code,omitempty" dynamodbav:"starter_code,omitempty"`           // Keyed by language
+	AllowedLanguages   []string               `json:"allowed_languages,omitempty" dynamodbav:"allowed_languages,omitempty"` // Empty allows every language
+	Signature          *Signature             `json:"signature,omitempty" dynamodbav:"signature,omitempty"`                 // Set for function-signature problems
+	Interactor         *Program               `json:"interactor,omitempty" dynamodbav:"interactor,omitempty"`               // Set for interactive problems
+	QueryLimit         int                    `json:"query_limit,omitempty" dynamodbav:"query_limit,omitempty"`             // Most lines an interactive solution may write
+	SQL                *SQLSpec               `json:"sql,omitempty" dynamodbav:"sql,omitempty"`                             // Set for SQL problems
+	Hints              []string               `json:"hints,omitempty" dynamodbav:"hints,omitempty"`                         // Revealed one at a time, in order
+	Editorial          *Editorial             `json:"editorial,omitempty" dynamodbav:"editorial,omitempty"`
+	EditorialAttempts  int                    `json:"editorial_attempts,omitempty" dynamodbav:"editorial_attempts,omitempty"` // Failed SUBMITs that unlock the editorial; zero means only acceptance does
+	Status             string                 `json:"status,omitempty" dynamodbav:"status,omitempty"`                         // Empty for problems created before the review workflow, which count as published
+	PublishAt          int64                  `json:"publish_at,omitempty" dynamodbav:"publish_at,omitempty"`                 // Optional Unix timestamp a published problem becomes visible at
+	Reviews            []Review               `json:"reviews,omitempty" dynamodbav:"reviews,omitempty"`
+	Locale             string                 `json:"locale,omitempty" dynamodbav:"locale,omitempty"`             // Locale of Title and Description; empty means DefaultLocale
+	Translations       map[string]Translation `json:"translations,omitempty" dynamodbav:"translations,omitempty"` // Keyed by locale
+}
+
+// TestCase is a single hidden input/expected output pair. Large payloads
+// are kept in the test data store and referenced by key and SHA-256
+// checksum instead of being stored inline (see package testdata).
+type TestCase struct {
+	Name         string `json:"name,omitempty" dynamodbav:"name,omitempty"`
+	Input        string `json:"input" dynamodbav:"input"`
+	Output       string `json:"output" dynamodbav:"output"`
+	InputKey     string `json:"input_key,omitempty" dynamodbav:"input_key,omitempty"`
+	InputSHA256  string `json:"input_sha256,omitempty" dynamodbav:"input_sha256,omitempty"`
+	OutputKey    string `json:"output_key,omitempty" dynamodbav:"output_key,omitempty"`
+	OutputSHA256 string `json:"output_sha256,omitempty" dynamodbav:"output_sha256,omitempty"`
+}
+
+// Program is a piece of setter-supplied code such as a custom checker or an
+// input validator.
+type Program struct {
+	Language string `json:"language" dynamodbav:"language"`
+	Code     string `json:"code" dynamodbav:"code"`
+}
+
+// ReferenceSolution is a setter solution together with the verdict it is
+// expected to get on the problem's tests.
+type ReferenceSolution struct {
+	Name            string `json:"name" dynamodbav:"name"`
+	Language        string `json:"language" dynamodbav:"language"`
+	Code            string `json:"code" dynamodbav:"code"`
+	ExpectedVerdict string `json:"expected_verdict" dynamodbav:"expected_verdict"`
+}
+
+// SQLSpec makes a problem a SQL problem. Every test runs on a fresh
+// database: the schema is applied first, then the test input as seed data,
+// and the learner's query result is compared with the expected output.
+//
+// Result sets are written as tab-separated text: a header line with the
+// column names, then one line per row, with NULL for null values.
+type SQLSpec struct {
+	Schema  string `json:"schema" dynamodbav:"schema"`
+	Ordered bool   `json:"ordered,omitempty" dynamodbav:"ordered,omitempty"` // Rows must come back in the expected order
+}
+
+// Editorial explains the intended solution. It is only shown to learners
+// who have solved the problem or made enough failed attempts.
+type Editorial struct {
+	Content   string            `json:"content" dynamodbav:"content"`
+	Solutions map[string]string `json:"solutions,omitempty" dynamodbav:"solutions,omitempty"` // Keyed by language
+}
+
+// Review is a reviewer's comment on a problem, optionally approving it for
+// publishing.
+type Review struct {
+	Reviewer  string `json:"reviewer" dynamodbav:"reviewer"` // GitHub login
+	Comment   string `json:"comment,omitempty" dynamodbav:"comment,omitempty"`
+	Approved  bool   `json:"approved" dynamodbav:"approved"`
+	CreatedAt int64  `json:"created_at" dynamodbav:"created_at"` // Unix timestamp
+}
+
+// Problem lifecycle statuses. New problems start as drafts and are only
+// shown to learners once published.
+const (
+	ProblemDraft     = "draft"
+	ProblemInReview  = "in_review"
+	ProblemPublished = "published"
+	ProblemArchived  = "archived"
+)
+
+// problemTransitions lists the statuses a problem can move to from each
+// status. Publishing also needs an approval (see Problem.Approved).
+var problemTransitions = map[string][]string{
+	ProblemDraft:     {ProblemInReview, ProblemArchived},
+	ProblemInReview:  {ProblemDraft, ProblemPublished, ProblemArchived},
+	ProblemPublished: {ProblemPublished, ProblemDraft, ProblemArchived}, // Republishing changes the schedule
+	ProblemArchived:  {ProblemDraft},
+}
+
+// Languages lists every language a submission can be written in.
+var Languages = []string{"nodejs", "cpp", "java", "python", "sql"}
+
+// LanguageSQL is only accepted for SQL problems, and only SQL problems
+// accept it.
+const LanguageSQL = "sql"
+
+func IsSupportedLanguage(language string) bool {
+	for _, l := range Languages {
+		if l == language {
+			return true
+		}
+	}
+	return false
+}
+
+// Judge verdicts for a single run of a program against a problem.
+const (
+	VerdictAccepted          = "accepted"
+	VerdictWrongAnswer       = "wrong_answer"
+	VerdictTimeLimitExceeded = "time_limit_exceeded"
+	VerdictRuntimeError      = "runtime_error"
+	VerdictCompileError      = "compile_error"
+)
+
+// DefaultTimeLimitMs matches the timeout the runners used before per-problem
+// limits existed.
+const DefaultTimeLimitMs = 5000
+
+// TestCases returns the tests a submission is judged against. Problems
+// created before multi-test support only carry the single Input/Output pair.
+func (p *Problem) TestCases() []TestCase {
+	if len(p.Tests) > 0 {
+		return p.Tests
+	}
+	return []TestCase{{Input: p.Input, Output: p.Output}}
+}
+
+// TimeLimit returns the per-test time limit in milliseconds.
+func (p *Problem) TimeLimit() int {
+	if p.TimeLimitMs > 0 {
+		return p.TimeLimitMs
+	}
+	return DefaultTimeLimitMs
+}
+
+// AllowsLanguage reports whether submissions in language are accepted.
+// Function-signature problems are limited to SignatureLanguages.
+func (p *Problem) AllowsLanguage(language string) bool {
+	if !IsSupportedLanguage(language) {
+		return false
+	}
+	if p.Signature != nil && !supportsSignature(language) {
+		return false
+	}
+	if (p.SQL != nil) != (language == LanguageSQL) {
+		return false
+	}
+	if len(p.AllowedLanguages) == 0 {
+		return true
+	}
+	for _, l := range p.AllowedLanguages {
+		if l == language {
+			return true
+		}
+	}
+	return false
+}
+
+// LifecycleStatus returns the problem's status, treating problems created
+// before the review workflow as published.
+func (p *Problem) LifecycleStatus() string {
+	if p.Status == "" {
+		return ProblemPublished
+	}
+	return p.Status
+}
+
+// CanTransition reports whether the problem may move to status.
+func (p *Problem) CanTransition(status string) bool {
+	for _, s := range problemTransitions[p.LifecycleStatus()] {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// Approved reports whether a reviewer has approved the problem since it was
+// last edited.
+func (p *Problem) Approved() bool {
+	for _, review := range p.Reviews {
+		if review.Approved && review.CreatedAt >= p.UpdatedAt {
+			return true
+		}
+	}
+	return false
+}
+
+// Initial ratings for problems that have none yet, by difficulty.
+var difficultyRatings = map[string]int{
+	"Easy":   1200,
+	"Medium": 1600,
+	"Hard":   2000,
+}
+
+// EffectiveRating returns the problem's rating, falling back to one
+// derived from its difficulty for problems that haven't been rated yet.
+func (p *Problem) EffectiveRating() int {
+	if p.Rating > 0 {
+		return p.Rating
+	}
+	if rating, ok := difficultyRatings[p.Difficulty]; ok {
+		return rating
+	}
+	return DefaultRating
+}
+
+// IsVisible reports whether learners can see the problem at the given Unix
+// time.
+func (p *Problem) IsVisible(now int64) bool {
+	return p.LifecycleStatus() == ProblemPublished && p.PublishAt <= now
+}
+
+// Public returns a copy of the problem with judge-only data removed, for
+// sending to learners. Input and Output are the first hidden test, so they
+// go too; learners get ExampleInput and ExampleOutput.
+func (p *Problem) Public() *Problem {
+	public := *p
+	public.Input = ""
+	public.Output = ""
+	public.Tests = nil
+	public.Checker = nil
+	public.ReferenceSolutions = nil
+	public.InputValidators = nil
+	public.Interactor = nil
+	public.Hints = nil
+	public.Editorial = nil
+	public.Reviews = nil
+	return &public
+}
+
+// Validate checks the rules every stored problem has to satisfy.
+func (p *Problem) Validate() error {
+	if p.Title == "" || p.Description == "" || p.Difficulty == "" || p.Input == "" || p.ExampleInput == "" {
+		return fmt.Errorf("All fields are required")
+	}
+	// Interactive problems are judged by the interactor, so they can do
+	// without expected outputs
+	if p.Interactor == nil && (p.Output == "" || p.ExampleOutput == "") {
+		return fmt.Errorf("All fields are required")
+	}
+
+	if p.Difficulty != "Easy" && p.Difficulty != "Medium" && p.Difficulty != "Hard" {
+		return fmt.Errorf("Difficulty must be Easy, Medium, or Hard")
+	}
+
+	for _, language := range p.AllowedLanguages {
+		if !IsSupportedLanguage(language) {
+			return fmt.Errorf("Unsupported allowed language: %s", language)
+		}
+		if p.Signature != nil && !supportsSignature(language) {
+			return fmt.Errorf("Function-signature problems cannot be solved in %s", language)
+		}
+		if (p.SQL != nil) != (language == LanguageSQL) {
+			return fmt.Errorf("SQL problems can only be solved in sql, and only SQL problems in sql")
+		}
+	}
+
+	if p.Signature != nil {
+		if err := p.Signature.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if p.Interactor != nil {
+		if !IsSupportedLanguage(p.Interactor.Language) {
+			return fmt.Errorf("Unsupported interactor language: %s", p.Interactor.Language)
+		}
+		if p.Signature != nil {
+			return fmt.Errorf("Interactive problems cannot have a function signature")
+		}
+	}
+	if p.SQL != nil && (p.Signature != nil || p.Interactor != nil) {
+		return fmt.Errorf("SQL problems cannot have a function signature or an interactor")
+	}
+	if p.SQL != nil && p.SQL.Schema == "" {
+		return fmt.Errorf("SQL problems need a schema")
+	}
+
+	if p.Editorial != nil {
+		for language := range p.Editorial.Solutions {
+			if !IsSupportedLanguage(language) {
+				return fmt.Errorf("Unsupported editorial solution language: %s", language)
+			}
+		}
+	}
+	if _, ok := problemTransitions[p.LifecycleStatus()]; !ok {
+		return fmt.Errorf("Status must be draft, in_review, published, or archived")
+	}
+
+	if p.Locale != "" {
+		if err := ValidateLocale(p.Locale); err != nil {
+			return err
+		}
+	}
+	for locale, translation := range p.Translations {
+		if err := ValidateLocale(locale); err != nil {
+			return err
+		}
+		if locale == p.BaseLocale() {
+			return fmt.Errorf("Translation given for the problem's own locale: %s", locale)
+		}
+		if translation.Title == "" || translation.Description == "" {
+			return fmt.Errorf("Translation %s needs a title and a description", locale)
+		}
+	}
+
+	if p.Rating < 0 {
+		return fmt.Errorf("Rating must not be negative")
+	}
+
+	if p.EditorialAttempts < 0 {
+		return fmt.Errorf("Editorial attempts must not be negative")
+	}
+
+	if p.QueryLimit < 0 || (p.QueryLimit > 0 && p.Interactor == nil) {
+		return fmt.Errorf("Query limit needs an interactor and must be positive")
+	}
+
+	for language := range p.StarterCode {
+		if !p.AllowsLanguage(language) {
+			return fmt.Errorf("Starter code given for language that is not allowed: %s", language)
+		}
+	}
+
+	return nil
 }
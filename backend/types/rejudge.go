@@ -0,0 +1,105 @@
+package types
+
+import (
+	"sort"
+	"time"
+)
+
+// RejudgeIDPrefix starts every rejudge ID.
+const RejudgeIDPrefix = "REJUDGE#"
+
+// Rejudge re-runs a problem's judged submissions, for example after its
+// tests were fixed. The matching submissions are queued when it is
+// created and republished a few at a time, so they don't hold up new
+// submissions.
+type Rejudge struct {
+	ID          string `json:"id" dynamodbav:"id"`
+	ProblemID   string `json:"problem_id" dynamodbav:"problem_id"`
+	Verdict     string `json:"verdict,omitempty" dynamodbav:"verdict,omitempty"`   // Only submissions with this verdict
+	Language    string `json:"language,omitempty" dynamodbav:"language,omitempty"` // Only submissions in this language
+	From        int64  `json:"from,omitempty" dynamodbav:"from,omitempty"`         // Unix timestamp, inclusive
+	To          int64  `json:"to,omitempty" dynamodbav:"to,omitempty"`             // Unix timestamp, inclusive
+	Total       int    `json:"total" dynamodbav:"total"`                           // Submissions queued
+	RequestedBy string `json:"requested_by" dynamodbav:"requested_by"`
+	CreatedAt   int64  `json:"created_at" dynamodbav:"created_at"`                         // Unix timestamp
+	QueuedAt    int64  `json:"queued_at,omitempty" dynamodbav:"queued_at,omitempty"`       // Unix timestamp the last matching submission was queued at
+	PublishedAt int64  `json:"published_at,omitempty" dynamodbav:"published_at,omitempty"` // Unix timestamp the last submission was republished at
+}
+
+// RejudgeQueueTimeout is how long a rejudge can take to queue its
+// submissions. One still not marked queued after that failed partway, and
+// the submissions it did queue are republished anyway.
+const RejudgeQueueTimeout = 5 * time.Minute
+
+// DoneQueueing reports whether no more submissions will be queued for the
+// rejudge.
+func (r *Rejudge) DoneQueueing(now int64) bool {
+	return r.QueuedAt != 0 || now-r.CreatedAt > int64(RejudgeQueueTimeout/time.Second)
+}
+
+// Rejudgeable reports whether the submission can be queued for a rejudge:
+// it has been judged and isn't queued by another rejudge. Submissions that
+// ended in a system error have no verdict to compare with, and are replayed
+// from the dead letter queue instead.
+func (s *Submission) Rejudgeable() bool {
+	return s.Finished() && !s.RejudgeQueued && s.Status != StatusSystemError
+}
+
+// VerdictChange is a rejudged submission whose verdict differs from the
+// one it had before.
+type VerdictChange struct {
+	SubmissionID    string `json:"submission_id"`
+	UserID          string `json:"user_id"`
+	Language        string `json:"language"`
+	PreviousVerdict string `json:"previous_verdict"`
+	Verdict         string `json:"verdict"`
+}
+
+// RejudgeSummary reports how far a rejudge has got and which verdicts it
+// changed.
+type RejudgeSummary struct {
+	*Rejudge
+	Queued      int             `json:"queued"`  // Not republished yet
+	Pending     int             `json:"pending"` // Republished and waiting for a verdict
+	Judged      int             `json:"judged"`
+	Unchanged   int             `json:"unchanged"`
+	Changed     []VerdictChange `json:"changed"`
+	Transitions map[string]int  `json:"transitions"` // Changed submissions by "previous -> new" verdict
+}
+
+// Summarize compares the rejudge's submissions with their previous
+// verdicts.
+func (r *Rejudge) Summarize(submissions []Submission) *RejudgeSummary {
+	summary := &RejudgeSummary{
+		Rejudge:     r,
+		Changed:     []VerdictChange{},
+		Transitions: map[string]int{},
+	}
+	for _, submission := range submissions {
+		switch {
+		case submission.RejudgeQueued:
+			summary.Queued++
+		case !submission.Finished():
+			summary.Pending++
+		default:
+			summary.Judged++
+			verdict := submission.JudgedVerdict()
+			if verdict == submission.PreviousVerdict {
+				summary.Unchanged++
+				continue
+			}
+			summary.Changed = append(summary.Changed, VerdictChange{
+				SubmissionID:    submission.SubmissionID,
+				UserID:          submission.UserID,
+				Language:        submission.Language,
+				PreviousVerdict: submission.PreviousVerdict,
+				Verdict:         verdict,
+			})
+			summary.Transitions[submission.PreviousVerdict+" -> "+verdict]++
+		}
+	}
+	sort.Slice(summary.Changed, func(i, j int) bool {
+		return summary.Changed[i].SubmissionID < summary.Changed[j].SubmissionID
+	})
+	return summary
+}
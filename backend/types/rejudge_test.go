@@ -0,0 +1,114 @@
+package types
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSummarize(t *testing.T) {
+	rejudge := &Rejudge{ID: RejudgeIDPrefix + "1", ProblemID: "prob-001"}
+	submissions := []Submission{
+		{SubmissionID: "s1", Status: StatusCompleted, RejudgeQueued: true, Verdict: VerdictAccepted, PreviousVerdict: VerdictAccepted},
+		{SubmissionID: "s2", Status: StatusPending, PreviousVerdict: VerdictAccepted},
+		{SubmissionID: "s3", Status: StatusRunning, PreviousVerdict: VerdictWrongAnswer},
+		{SubmissionID: "s4", Status: StatusCompleted, Verdict: VerdictAccepted, PreviousVerdict: VerdictAccepted},
+		{SubmissionID: "s6", UserID: "u2", Language: "cpp", Status: StatusError, Verdict: VerdictWrongAnswer, PreviousVerdict: VerdictAccepted},
+		{SubmissionID: "s5", UserID: "u1", Language: "python", Status: StatusCompleted, Verdict: VerdictAccepted, PreviousVerdict: VerdictWrongAnswer},
+		{SubmissionID: "s7", UserID: "u3", Language: "nodejs", Status: StatusError, Verdict: VerdictTimeLimitExceeded, PreviousVerdict: VerdictAccepted},
+		// Judged without storing a verdict, so the status stands in for it
+		{SubmissionID: "s8", Status: statusLegacySuccess, PreviousVerdict: VerdictAccepted},
+	}
+
+	summary := rejudge.Summarize(submissions)
+
+	if summary.Rejudge != rejudge {
+		t.Errorf("Rejudge = %v, want %v", summary.Rejudge, rejudge)
+	}
+	if summary.Queued != 1 || summary.Pending != 2 || summary.Judged != 5 || summary.Unchanged != 2 {
+		t.Errorf("Queued, Pending, Judged, Unchanged = %d, %d, %d, %d, want 1, 2, 5, 2",
+			summary.Queued, summary.Pending, summary.Judged, summary.Unchanged)
+	}
+
+	wantChanged := []VerdictChange{
+		{SubmissionID: "s5", UserID: "u1", Language: "python", PreviousVerdict: VerdictWrongAnswer, Verdict: VerdictAccepted},
+		{SubmissionID: "s6", UserID: "u2", Language: "cpp", PreviousVerdict: VerdictAccepted, Verdict: VerdictWrongAnswer},
+		{SubmissionID: "s7", UserID: "u3", Language: "nodejs", PreviousVerdict: VerdictAccepted, Verdict: VerdictTimeLimitExceeded},
+	}
+	if !reflect.DeepEqual(summary.Changed, wantChanged) {
+		t.Errorf("Changed = %+v, want %+v", summary.Changed, wantChanged)
+	}
+
+	wantTransitions := map[string]int{
+		"wrong_answer -> accepted":        1,
+		"accepted -> wrong_answer":        1,
+		"accepted -> time_limit_exceeded": 1,
+	}
+	if !reflect.DeepEqual(summary.Transitions, wantTransitions) {
+		t.Errorf("Transitions = %v, want %v", summary.Transitions, wantTransitions)
+	}
+}
+
+func TestSummarizeEmpty(t *testing.T) {
+	summary := (&Rejudge{}).Summarize(nil)
+	if summary.Changed == nil || summary.Transitions == nil {
+		t.Errorf("Changed and Transitions must be empty, not nil, so they marshal as [] and {}")
+	}
+}
+
+func TestDoneQueueing(t *testing.T) {
+	timeout := int64(RejudgeQueueTimeout.Seconds())
+	tests := []struct {
+		name    string
+		rejudge Rejudge
+		now     int64
+		want    bool
+	}{
+		{"still queueing", Rejudge{CreatedAt: 1000}, 1000 + timeout, false},
+		{"marked queued", Rejudge{CreatedAt: 1000, QueuedAt: 1010}, 1020, true},
+		{"failed partway", Rejudge{CreatedAt: 1000}, 1000 + timeout + 1, true},
+	}
+	for _, tt := range tests {
+		if got := tt.rejudge.DoneQueueing(tt.now); got != tt.want {
+			t.Errorf("%s: DoneQueueing(%d) = %v, want %v", tt.name, tt.now, got, tt.want)
+		}
+	}
+}
+
+// A queued submission keeps its verdict as the previous one, and whether
+// that verdict was counted decides if the rejudge moves the count or adds a
+// new attempt.
+func TestRejudgeQueue(t *testing.T) {
+	tests := []struct {
+		name        string
+		submission  Submission
+		rejudgeable bool
+		previous    string
+		counted     bool
+	}{
+		{"accepted", Submission{Type: SubmissionSubmit, Status: StatusCompleted, Verdict: VerdictAccepted}, true, VerdictAccepted, true},
+		{"rejected", Submission{Type: SubmissionSubmit, Status: StatusError, Verdict: VerdictWrongAnswer}, true, VerdictWrongAnswer, true},
+		{"legacy success", Submission{Type: SubmissionSubmit, Status: statusLegacySuccess}, true, VerdictAccepted, false},
+		{"legacy wrong answer", Submission{Type: SubmissionSubmit, Status: statusLegacyWrongAnswer}, true, string(statusLegacyWrongAnswer), false},
+		{"test run", Submission{Type: "RUN", Status: StatusCompleted, Verdict: VerdictAccepted}, true, VerdictAccepted, false},
+		{"system error", Submission{Type: SubmissionSubmit, Status: StatusSystemError}, false, "", false},
+		{"pending", Submission{Type: SubmissionSubmit, Status: StatusPending}, false, "", false},
+		{"running", Submission{Type: SubmissionSubmit, Status: StatusRunning}, false, "", false},
+		{"already queued", Submission{Type: SubmissionSubmit, Status: StatusCompleted, Verdict: VerdictAccepted, RejudgeQueued: true}, false, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.submission.Rejudgeable(); got != tt.rejudgeable {
+				t.Fatalf("Rejudgeable() = %v, want %v", got, tt.rejudgeable)
+			}
+			if !tt.rejudgeable {
+				return
+			}
+			if got := tt.submission.JudgedVerdict(); got != tt.previous {
+				t.Errorf("JudgedVerdict() = %q, want %q", got, tt.previous)
+			}
+			if got := tt.submission.VerdictCounted(); got != tt.counted {
+				t.Errorf("VerdictCounted() = %v, want %v", got, tt.counted)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package types
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Signature turns a problem into a function-signature problem: learners
+// implement a single function and the runner generates the code that reads
+// the arguments and prints the result.
+//
+// Each test input holds one JSON value per line, one line per parameter, and
+// the expected output is the JSON encoding of the return value. Linked lists
+// are written as arrays of values and binary trees as level-order arrays with
+// null for missing children, as on LeetCode.
+type Signature struct {
+	Function string  `json:"function" dynamodbav:"function"`
+	Params   []Param `json:"params" dynamodbav:"params"`
+	Returns  string  `json:"returns" dynamodbav:"returns"`
+}
+
+type Param struct {
+	Name string `json:"name" dynamodbav:"name"`
+	Type string `json:"type" dynamodbav:"type"`
+}
+
+// ValueTypes lists the scalar types a parameter or return value can have.
+// Any of them can be made into an array by appending "[]", and "[][]" gives
+// a matrix.
+var ValueTypes = []string{"int", "long", "double", "bool", "string", "ListNode", "TreeNode"}
+
+// SignatureLanguages lists the languages that can generate a harness for
+// function-signature problems.
+var SignatureLanguages = []string{"python", "nodejs", "cpp"}
+
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ElementType strips one level of array from t. It reports false when t is
+// not an array type.
+func ElementType(t string) (string, bool) {
+	if !strings.HasSuffix(t, "[]") {
+		return t, false
+	}
+	return strings.TrimSuffix(t, "[]"), true
+}
+
+func IsValueType(t string) bool {
+	for {
+		element, ok := ElementType(t)
+		if !ok {
+			break
+		}
+		t = element
+	}
+	for _, v := range ValueTypes {
+		if v == t {
+			return true
+		}
+	}
+	return false
+}
+
+func supportsSignature(language string) bool {
+	for _, l := range SignatureLanguages {
+		if l == language {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks that the signature can be turned into a harness in every
+// language.
+func (s *Signature) Validate() error {
+	if !identifier.MatchString(s.Function) {
+		return fmt.Errorf("Invalid function name: %q", s.Function)
+	}
+	if !IsValueType(s.Returns) {
+		return fmt.Errorf("Unsupported return type: %q", s.Returns)
+	}
+
+	seen := map[string]bool{}
+	for _, param := range s.Params {
+		if !identifier.MatchString(param.Name) {
+			return fmt.Errorf("Invalid parameter name: %q", param.Name)
+		}
+		if seen[param.Name] {
+			return fmt.Errorf("Duplicate parameter name: %s", param.Name)
+		}
+		seen[param.Name] = true
+		if !IsValueType(param.Type) {
+			return fmt.Errorf("Unsupported type for parameter %s: %q", param.Name, param.Type)
+		}
+	}
+
+	return nil
+}
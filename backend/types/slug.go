@@ -0,0 +1,66 @@
+package types
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// maxSlugLength keeps slugs readable in URLs; longer titles are cut at a
+// word boundary.
+const maxSlugLength = 60
+
+// Slugify turns a problem title into a URL slug such as "reverse-a-string".
+func Slugify(title string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(title) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+
+	slug := b.String()
+	if len(slug) > maxSlugLength {
+		slug = slug[:maxSlugLength]
+		if i := strings.LastIndexByte(slug, '-'); i > 0 {
+			slug = slug[:i]
+		}
+		slug = strings.ToValidUTF8(slug, "")
+	}
+	if slug == "" {
+		slug = "problem"
+	}
+	return slug
+}
+
+// SlugCandidate returns the n-th slug to try for a title: the plain slug
+// first, then with "-2", "-3" and so on appended.
+func SlugCandidate(title string, n int) string {
+	if n <= 1 {
+		return Slugify(title)
+	}
+	return fmt.Sprintf("%s-%d", Slugify(title), n)
+}
+
+// SlugMatchesTitle reports whether slug was derived from title, so a
+// problem whose title hasn't changed keeps its slug.
+func SlugMatchesTitle(slug string, title string) bool {
+	base := Slugify(title)
+	if slug == base {
+		return true
+	}
+	suffix, ok := strings.CutPrefix(slug, base+"-")
+	if !ok {
+		return false
+	}
+	n, err := strconv.Atoi(suffix)
+	return err == nil && n > 1 && suffix == strconv.Itoa(n)
+}
@@ -0,0 +1,100 @@
+package types
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		name  string
+		title string
+		want  string
+	}{
+		{"plain", "Reverse a String", "reverse-a-string"},
+		{"punctuation", "Two Sum (II): Sorted!", "two-sum-ii-sorted"},
+		{"repeated separators", "A  --  B__C", "a-b-c"},
+		{"leading and trailing separators", "  --Hello World--  ", "hello-world"},
+		{"digits", "3Sum 2024", "3sum-2024"},
+		{"apostrophe", "Dijkstra's Algorithm", "dijkstra-s-algorithm"},
+		{"accents", "Café Déjà Vu", "café-déjà-vu"},
+		{"non-latin", "Сумма двух чисел", "сумма-двух-чисел"},
+		{"cjk", "两数之和", "两数之和"},
+		{"emoji", "Count 🐑 Sheep", "count-sheep"},
+		{"empty", "", "problem"},
+		{"only punctuation", "?!--...", "problem"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Slugify(tt.title); got != tt.want {
+				t.Errorf("Slugify(%q) = %q, want %q", tt.title, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSlugifyLength(t *testing.T) {
+	tests := []struct {
+		name  string
+		title string
+		want  string
+	}{
+		{"cut at a word boundary", strings.Repeat("word ", 20), strings.TrimSuffix(strings.Repeat("word-", 12), "-")},
+		{"exactly the limit", strings.Repeat("a", maxSlugLength), strings.Repeat("a", maxSlugLength)},
+		{"one word past the limit", strings.Repeat("a", maxSlugLength+5), strings.Repeat("a", maxSlugLength)},
+		// "é" is two bytes, so after the "a" the cut falls in the middle of one
+		{"no partial runes", "a" + strings.Repeat("é", maxSlugLength), "a" + strings.Repeat("é", maxSlugLength/2-1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Slugify(tt.title)
+			if got != tt.want {
+				t.Errorf("Slugify(%q) = %q, want %q", tt.title, got, tt.want)
+			}
+			if len(got) > maxSlugLength || !utf8.ValidString(got) || strings.HasSuffix(got, "-") {
+				t.Errorf("Slugify(%q) = %q is not a valid slug", tt.title, got)
+			}
+		})
+	}
+}
+
+func TestSlugCandidate(t *testing.T) {
+	for n, want := range map[int]string{0: "two-sum", 1: "two-sum", 2: "two-sum-2", 10: "two-sum-10"} {
+		if got := SlugCandidate("Two Sum", n); got != want {
+			t.Errorf("SlugCandidate(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
+
+func TestSlugMatchesTitle(t *testing.T) {
+	tests := []struct {
+		slug  string
+		title string
+		want  bool
+	}{
+		{"two-sum", "Two Sum", true},
+		{"two-sum", "Two  Sum!", true},
+		{"two-sum-2", "Two Sum", true},
+		{"two-sum-17", "Two Sum", true},
+		{"problem", "", true},
+		{"problem-3", "???", true},
+		{"café", "Café", true},
+
+		{"two-sum", "Three Sum", false},
+		{"two-sum-1", "Two Sum", false},
+		{"two-sum-0", "Two Sum", false},
+		{"two-sum-02", "Two Sum", false},
+		{"two-sum--2", "Two Sum", false},
+		{"two-sum-x", "Two Sum", false},
+		{"two-sum-ii", "Two Sum", false},
+		{"two-sum-2", "Two Sum 2", true},
+		{"two", "Two Sum", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		if got := SlugMatchesTitle(tt.slug, tt.title); got != tt.want {
+			t.Errorf("SlugMatchesTitle(%q, %q) = %v, want %v", tt.slug, tt.title, got, tt.want)
+		}
+	}
+}
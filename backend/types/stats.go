@@ -0,0 +1,14 @@
+package types
+
+// ProblemStats aggregates the judged SUBMIT submissions of a problem. The
+// counters are updated by the runners as they finish judging.
+type ProblemStats struct {
+	ProblemID string `json:"problem_id" dynamodbav:"problem_id"`
+	Attempts  int    `json:"attempts" dynamodbav:"attempts"`
+	Accepted  int    `json:"accepted" dynamodbav:"accepted"`
+	Solvers   int    `json:"solvers" dynamodbav:"solvers"` // Distinct users with an accepted submission
+	// Verdict counts by language, then verdict
+	Verdicts map[string]map[string]int `json:"verdicts" dynamodbav:"-"`
+	// Share of attempts that were accepted, between 0 and 1
+	AcceptanceRate float64 `json:"acceptance_rate" dynamodbav:"-"`
+}
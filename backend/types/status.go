@@ -0,0 +1,87 @@
+package types
+
+import "sort"
+
+// SubmissionStatus is where a submission is in being judged.
+type SubmissionStatus string
+
+// Submission statuses. A submission is pending until a runner picks it up
+// and running while it is judged. A judged submission is completed if it
+// was accepted and error otherwise, with its Verdict saying why.
+const (
+	StatusPending   SubmissionStatus = "pending"
+	StatusRunning   SubmissionStatus = "running"
+	StatusCompleted SubmissionStatus = "completed"
+	StatusError     SubmissionStatus = "error"
+
+	// StatusSystemError marks a submission that was never judged because
+	// the runners kept failing on it. It is not the submitter's fault, so
+	// it doesn't count towards ratings.
+	StatusSystemError SubmissionStatus = "system_error"
+)
+
+// Statuses the C++, Node.js and SQL runners wrote before they were unified
+// on completed and error. They are still found on old submissions.
+const (
+	statusLegacySuccess     SubmissionStatus = "success"
+	statusLegacyWrongAnswer SubmissionStatus = "wrong_answer"
+)
+
+// submissionTransitions lists the statuses a submission can move to from
+// each status. Any submission can go back to pending to be judged again by
+// the reaper, a rejudge or a dead letter replay.
+var submissionTransitions = map[SubmissionStatus][]SubmissionStatus{
+	StatusPending:           {StatusPending, StatusRunning, StatusSystemError},
+	StatusRunning:           {StatusPending, StatusRunning, StatusCompleted, StatusError, StatusSystemError}, // A redelivered job starts running again
+	StatusCompleted:         {StatusPending},
+	StatusError:             {StatusPending},
+	StatusSystemError:       {StatusPending},
+	statusLegacySuccess:     {StatusPending},
+	statusLegacyWrongAnswer: {StatusPending},
+}
+
+// StatusChange is an entry in a submission's status history.
+type StatusChange struct {
+	Status SubmissionStatus `json:"status" dynamodbav:"status"`
+	At     int64            `json:"at" dynamodbav:"at"` // Unix timestamp
+}
+
+// VerdictStatus is the status a submission ends in once judged with the
+// verdict.
+func VerdictStatus(verdict string) SubmissionStatus {
+	if verdict == VerdictAccepted {
+		return StatusCompleted
+	}
+	return StatusError
+}
+
+// CanTransition reports whether a submission can move from one status to
+// another.
+func CanTransition(from SubmissionStatus, to SubmissionStatus) bool {
+	for _, s := range submissionTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Judging reports whether a submission in the status is waiting to be
+// judged or being judged.
+func (s SubmissionStatus) Judging() bool {
+	return s == StatusPending || s == StatusRunning
+}
+
+// StatusesBefore returns the statuses a submission can move to status from.
+func StatusesBefore(status SubmissionStatus) []SubmissionStatus {
+	var from []SubmissionStatus
+	for s := range submissionTransitions {
+		if CanTransition(s, status) {
+			from = append(from, s)
+		}
+	}
+	sort.Slice(from, func(i, j int) bool {
+		return from[i] < from[j]
+	})
+	return from
+}
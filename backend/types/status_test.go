@@ -0,0 +1,100 @@
+package types
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		name string
+		from SubmissionStatus
+		to   SubmissionStatus
+		want bool
+	}{
+		{"runner picks up", StatusPending, StatusRunning, true},
+		{"accepted", StatusRunning, StatusCompleted, true},
+		{"rejected", StatusRunning, StatusError, true},
+		{"runner gives up", StatusRunning, StatusSystemError, true},
+		{"redelivered job", StatusRunning, StatusRunning, true},
+		{"reaper retries pending", StatusPending, StatusPending, true},
+		{"reaper retries running", StatusRunning, StatusPending, true},
+		{"reaper gives up", StatusPending, StatusSystemError, true},
+		{"rejudge completed", StatusCompleted, StatusPending, true},
+		{"rejudge error", StatusError, StatusPending, true},
+		{"dead letter replay", StatusSystemError, StatusPending, true},
+		{"rejudge legacy success", statusLegacySuccess, StatusPending, true},
+		{"rejudge legacy wrong answer", statusLegacyWrongAnswer, StatusPending, true},
+
+		{"completed back to running", StatusCompleted, StatusRunning, false},
+		{"error back to running", StatusError, StatusRunning, false},
+		{"system error back to running", StatusSystemError, StatusRunning, false},
+		{"legacy back to running", statusLegacySuccess, StatusRunning, false},
+		{"completed to error", StatusCompleted, StatusError, false},
+		{"judged without running", StatusPending, StatusCompleted, false},
+		{"unknown status", "queued", StatusPending, false},
+		{"to unknown status", StatusPending, "queued", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CanTransition(tt.from, tt.to); got != tt.want {
+				t.Errorf("CanTransition(%q, %q) = %v, want %v", tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatusesBefore(t *testing.T) {
+	tests := []struct {
+		status SubmissionStatus
+		want   []SubmissionStatus
+	}{
+		{StatusRunning, []SubmissionStatus{StatusPending, StatusRunning}},
+		{StatusCompleted, []SubmissionStatus{StatusRunning}},
+		{StatusSystemError, []SubmissionStatus{StatusPending, StatusRunning}},
+		{StatusPending, []SubmissionStatus{
+			StatusCompleted, StatusError, StatusPending, StatusRunning,
+			statusLegacySuccess, StatusSystemError, statusLegacyWrongAnswer,
+		}},
+		{"queued", nil},
+	}
+	for _, tt := range tests {
+		if got := StatusesBefore(tt.status); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("StatusesBefore(%q) = %q, want %q", tt.status, got, tt.want)
+		}
+	}
+}
+
+func TestVerdictStatus(t *testing.T) {
+	tests := []struct {
+		verdict string
+		want    SubmissionStatus
+	}{
+		{VerdictAccepted, StatusCompleted},
+		{VerdictWrongAnswer, StatusError},
+		{VerdictCompileError, StatusError},
+		{VerdictTimeLimitExceeded, StatusError},
+	}
+	for _, tt := range tests {
+		if got := VerdictStatus(tt.verdict); got != tt.want {
+			t.Errorf("VerdictStatus(%q) = %q, want %q", tt.verdict, got, tt.want)
+		}
+	}
+}
+
+func TestStatusesJudgedAs(t *testing.T) {
+	tests := []struct {
+		verdict string
+		want    []SubmissionStatus
+	}{
+		{VerdictAccepted, []SubmissionStatus{StatusCompleted, statusLegacySuccess}},
+		{VerdictWrongAnswer, []SubmissionStatus{statusLegacyWrongAnswer}},
+		{VerdictTimeLimitExceeded, nil},
+		{string(StatusPending), nil},
+	}
+	for _, tt := range tests {
+		if got := StatusesJudgedAs(tt.verdict); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("StatusesJudgedAs(%q) = %q, want %q", tt.verdict, got, tt.want)
+		}
+	}
+}
@@ -1,14 +1,131 @@
 package types
 
+import (
+	"sort"
+	"strings"
+)
+
+// SubmissionIDPrefix starts every stored submission ID.
+const SubmissionIDPrefix = "SUBMISSION#"
+
+// Submission types. Runs are checked against the problem's tests like
+// submissions but don't count towards statistics or ratings.
+const (
+	SubmissionRun    = "RUN"
+	SubmissionSubmit = "SUBMIT"
+)
+
+// MaxCodeBytes is the largest submission accepted.
+const MaxCodeBytes = 64 << 10
+
+// FullSubmissionID adds the SubmissionIDPrefix if id lacks it, so that URLs
+// can use the bare UUID.
+func FullSubmissionID(id string) string {
+	if strings.HasPrefix(id, SubmissionIDPrefix) {
+		return id
+	}
+	return SubmissionIDPrefix + id
+}
+
 type Submission struct {
-	SubmissionID string  `json:"submission_id" dynamodbav:"submission_id"`
-	UserID      string  `json:"user_id" dynamodbav:"user_id"`
-	ProblemID   string  `json:"problem_id" dynamodbav:"problem_id"`
-	Language    string  `json:"language" dynamodbav:"language"`
-	Code        string  `json:"code" dynamodbav:"code"`
-	Status      string  `json:"status" dynamodbav:"status"` // pending, running, completed, error
-	CreatedAt   int64   `json:"created_at" dynamodbav:"created_at"`
-	UpdatedAt   int64   `json:"updated_at" dynamodbav:"updated_at"`
-	Result      *string `json:"result,omitempty" dynamodbav:"result,omitempty"`
-	Type        string  `json:"type" dynamodbav:"type"` // RUN, SUBMIT
-} 
\ No newline at end of file
+	SubmissionID string           `json:"submission_id" dynamodbav:"submission_id"`
+	UserID       string           `json:"user_id" dynamodbav:"user_id"`
+	ProblemID    string           `json:"problem_id" dynamodbav:"problem_id"`
+	Language     string           `json:"language" dynamodbav:"language"`
+	Code         string           `json:"code" dynamodbav:"code"`
+	Status       SubmissionStatus `json:"status" dynamodbav:"status"`
+	CreatedAt    int64            `json:"created_at" dynamodbav:"created_at"`
+	UpdatedAt    int64            `json:"updated_at" dynamodbav:"updated_at"`
+	Result       *string          `json:"result,omitempty" dynamodbav:"result,omitempty"`
+	Type         string           `json:"type" dynamodbav:"type"`                             // RUN, SUBMIT
+	Verdict      string           `json:"verdict,omitempty" dynamodbav:"verdict,omitempty"`   // Judge verdict once judged, e.g. accepted
+	RatedAt      int64            `json:"rated_at,omitempty" dynamodbav:"rated_at,omitempty"` // Unix timestamp the rating job counted it at
+	Tests        []TestResult     `json:"tests,omitempty" dynamodbav:"tests,omitempty"`
+	Public       bool             `json:"public" dynamodbav:"public"` // Owner lets other users see the code
+
+	// Set while and after an admin rejudges the submission
+	RejudgeID       string `json:"rejudge_id,omitempty" dynamodbav:"rejudge_id,omitempty"`
+	RejudgeQueued   bool   `json:"rejudge_queued,omitempty" dynamodbav:"rejudge_queued,omitempty"` // Waiting to be republished
+	PreviousVerdict string `json:"previous_verdict,omitempty" dynamodbav:"previous_verdict,omitempty"`
+	PreviousCounted bool   `json:"previous_counted,omitempty" dynamodbav:"previous_counted,omitempty"` // PreviousVerdict is in the problem's statistics
+
+	// Set by the reaper for submissions that got stuck before a verdict
+	Retries     int    `json:"retries,omitempty" dynamodbav:"retries,omitempty"`
+	ErrorReason string `json:"error_reason,omitempty" dynamodbav:"error_reason,omitempty"` // Why it ended in system_error
+
+	StatusHistory []StatusChange `json:"status_history,omitempty" dynamodbav:"status_history,omitempty"` // Oldest first; missing on older submissions
+}
+
+// TestResult is the outcome of one test.
+type TestResult struct {
+	Name    string `json:"name,omitempty" dynamodbav:"name,omitempty"`
+	Verdict string `json:"verdict" dynamodbav:"verdict"`
+	TimeMs  int64  `json:"time_ms" dynamodbav:"time_ms"`
+}
+
+// CanView reports whether the user may see the whole submission: its owner
+// and admins can.
+func (s *Submission) CanView(user *User) bool {
+	return user.IsAdmin || user.ID == s.UserID
+}
+
+// Redacted returns the view of a public submission shown to other users:
+// the code and verdicts, without the program output or test names, which
+// can give hidden tests away.
+func (s *Submission) Redacted() *Submission {
+	redacted := *s
+	redacted.Result = nil
+	redacted.Tests = make([]TestResult, len(s.Tests))
+	for i, test := range s.Tests {
+		redacted.Tests[i] = TestResult{Verdict: test.Verdict, TimeMs: test.TimeMs}
+	}
+	return &redacted
+}
+
+// Accepted reports whether the runner accepted the submission. Older
+// submissions judged by the C++, Node.js or SQL runners say "success".
+func (s *Submission) Accepted() bool {
+	return s.Status == StatusCompleted || s.Status == statusLegacySuccess
+}
+
+// JudgedVerdict is the verdict of a finished submission. Submissions judged
+// before verdicts were stored, or by a runner that doesn't store them, fall
+// back to their status.
+func (s *Submission) JudgedVerdict() string {
+	if s.Verdict != "" {
+		return s.Verdict
+	}
+	if s.Accepted() {
+		return VerdictAccepted
+	}
+	return string(s.Status)
+}
+
+// StatusesJudgedAs returns the statuses that JudgedVerdict maps to verdict
+// for finished submissions without a stored verdict.
+func StatusesJudgedAs(verdict string) []SubmissionStatus {
+	var statuses []SubmissionStatus
+	for status := range submissionTransitions {
+		submission := Submission{Status: status}
+		if submission.Finished() && submission.JudgedVerdict() == verdict {
+			statuses = append(statuses, status)
+		}
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i] < statuses[j]
+	})
+	return statuses
+}
+
+// VerdictCounted reports whether the submission's verdict is counted in its
+// problem's statistics. Only SUBMIT verdicts are, and only those stored on
+// the submission, since the statistics were added together with the stored
+// verdict.
+func (s *Submission) VerdictCounted() bool {
+	return s.Type == SubmissionSubmit && s.Verdict != ""
+}
+
+// Finished reports whether the submission has been judged.
+func (s *Submission) Finished() bool {
+	return s.Status != StatusPending && s.Status != StatusRunning
+}
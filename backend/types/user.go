@@ -1,9 +1,41 @@
 package types
 
 type User struct {
-	ID          string  `json:"id" dynamodbav:"id"`
-	Login       string `json:"login" dynamodbav:"login"`
-	CreatedAt   int64  `json:"created_at" dynamodbav:"created_at"`
-	LastLoginAt int64  `json:"last_login_at" dynamodbav:"last_login_at"`
-	IsAdmin     bool   `json:"isAdmin" dynamodbav:"is_admin"`
+	ID             string         `json:"id" dynamodbav:"id"`
+	Login          string         `json:"login" dynamodbav:"login"`
+	CreatedAt      int64          `json:"created_at" dynamodbav:"created_at"`
+	LastLoginAt    int64          `json:"last_login_at" dynamodbav:"last_login_at"`
+	IsAdmin        bool           `json:"isAdmin" dynamodbav:"is_admin"`
+	Role           string         `json:"role,omitempty" dynamodbav:"role,omitempty"`                     // RoleInstructor, or empty for learners
+	RevealedHints  map[string]int `json:"revealed_hints,omitempty" dynamodbav:"revealed_hints,omitempty"` // Hints revealed so far, by problem ID
+	Rating         int            `json:"rating,omitempty" dynamodbav:"rating,omitempty"`                 // Skill rating; zero until the first rated submission
+	RatedSolves    []string       `json:"-" dynamodbav:"rated_solves,stringset,omitempty"`                // Problems whose first acceptance has been rated
+	SolvedProblems []string       `json:"solved_problems,omitempty" dynamodbav:"solved_problems,stringset,omitempty"`
+}
+
+// Roles. A user's Role is RoleInstructor or empty for learners; admins are
+// marked by IsAdmin instead. RoleLearner and RoleAdmin name the roles of
+// those users elsewhere, such as in rate limit quotas.
+const (
+	RoleLearner    = "learner"
+	RoleInstructor = "instructor" // Curates collections of problems without being an admin
+	RoleAdmin      = "admin"
+)
+
+// IsAssignableRole reports whether admins can give users role. The empty
+// role makes a user a learner again.
+func IsAssignableRole(role string) bool {
+	return role == "" || role == RoleInstructor
+}
+
+// DefaultRating is the skill rating of users without rated submissions.
+const DefaultRating = 1500
+
+// EffectiveRating returns the user's rating, or DefaultRating for users
+// without rated submissions.
+func (u *User) EffectiveRating() int {
+	if u.Rating > 0 {
+		return u.Rating
+	}
+	return DefaultRating
 }
@@ -0,0 +1,24 @@
+package types
+
+import (
+	"sort"
+	"strings"
+)
+
+// FieldErrors maps request fields to what is wrong with them, so clients
+// can show each error next to its field.
+type FieldErrors map[string]string
+
+func (e FieldErrors) Error() string {
+	fields := make([]string, 0, len(e))
+	for field := range e {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	messages := make([]string, len(fields))
+	for i, field := range fields {
+		messages[i] = field + ": " + e[field]
+	}
+	return strings.Join(messages, "; ")
+}
@@ -0,0 +1,127 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"learncode/backend/db"
+	"learncode/backend/types"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// BearerToken extracts the token from the request's Authorization header.
+func BearerToken(headers map[string]string) (string, error) {
+	// Check both cases since API Gateway might normalize header names
+	authHeader := headers["Authorization"]
+	if authHeader == "" {
+		authHeader = headers["authorization"]
+	}
+
+	if authHeader == "" {
+		return "", fmt.Errorf("No authorization token provided")
+	}
+
+	parts := strings.Split(authHeader, " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		return "", fmt.Errorf("Invalid token format")
+	}
+
+	return parts[1], nil
+}
+
+// AuthenticateUser verifies the caller's GitHub token and loads them from
+// the users table. Callers who never went through the login flow get a user
+// without any stored data. When the token is bad it returns the response the
+// handler should send back instead.
+func AuthenticateUser(ctx context.Context, headers map[string]string) (*types.User, *events.APIGatewayProxyResponse) {
+	token, err := BearerToken(headers)
+	if err != nil {
+		return nil, &events.APIGatewayProxyResponse{
+			StatusCode: 401,
+			Body:       fmt.Sprintf(`{"error": %q}`, err.Error()),
+		}
+	}
+
+	githubUser, err := GetGithubUser(token)
+	if err != nil {
+		return nil, &events.APIGatewayProxyResponse{
+			StatusCode: 401,
+			Body:       fmt.Sprintf(`{"error": "Failed to verify token: %v"}`, err),
+		}
+	}
+
+	dbUser, err := db.GetUser(ctx, githubUser.ID)
+	if err != nil {
+		return nil, &events.APIGatewayProxyResponse{
+			StatusCode: 500,
+			Body:       fmt.Sprintf(`{"error": "Failed to get user from database: %v"}`, err),
+		}
+	}
+
+	if dbUser == nil {
+		return githubUser, nil
+	}
+
+	return dbUser, nil
+}
+
+// AuthenticateAdmin verifies the caller's GitHub token and checks their admin
+// flag in the users table. When the caller is not an admin it returns the
+// response the handler should send back instead.
+func AuthenticateAdmin(ctx context.Context, headers map[string]string) (*types.User, *events.APIGatewayProxyResponse) {
+	user, errResponse := AuthenticateUser(ctx, headers)
+	if errResponse != nil {
+		return nil, errResponse
+	}
+
+	if !user.IsAdmin {
+		return nil, &events.APIGatewayProxyResponse{
+			StatusCode: 403,
+			Body:       `{"error": "Unauthorized: Admin access required"}`,
+		}
+	}
+
+	return user, nil
+}
+
+// AuthenticateInstructor is AuthenticateAdmin for endpoints that
+// instructors may use as well as admins.
+func AuthenticateInstructor(ctx context.Context, headers map[string]string) (*types.User, *events.APIGatewayProxyResponse) {
+	user, errResponse := AuthenticateUser(ctx, headers)
+	if errResponse != nil {
+		return nil, errResponse
+	}
+
+	if !user.IsAdmin && user.Role != types.RoleInstructor {
+		return nil, &events.APIGatewayProxyResponse{
+			StatusCode: 403,
+			Body:       `{"error": "Unauthorized: Instructor access required"}`,
+		}
+	}
+
+	return user, nil
+}
+
+// CanViewProblem reports whether the user may see the problem. Published
+// problems are visible to everyone, others only to admins.
+func CanViewProblem(ctx context.Context, userID string, problem *types.Problem) (bool, error) {
+	if problem.IsVisible(time.Now().Unix()) {
+		return true, nil
+	}
+
+	user, err := db.GetUser(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return user != nil && user.IsAdmin, nil
+}
+
+// ClientIP returns the address the request came from, as seen by API
+// Gateway. It takes the HTTP API's own payload, since X-Forwarded-For can be
+// set by the client.
+func ClientIP(event events.APIGatewayV2HTTPRequest) string {
+	return event.RequestContext.HTTP.SourceIP
+}
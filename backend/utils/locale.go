@@ -0,0 +1,20 @@
+package utils
+
+// RequestedLocales returns the caller's locale preferences, most preferred
+// first: the locale query parameter, then the Accept-Language header.
+func RequestedLocales(query map[string]string, headers map[string]string) []string {
+	var locales []string
+	if locale := query["locale"]; locale != "" {
+		locales = append(locales, locale)
+	}
+
+	// Check both cases since API Gateway might normalize header names
+	acceptLanguage := headers["Accept-Language"]
+	if acceptLanguage == "" {
+		acceptLanguage = headers["accept-language"]
+	}
+	if acceptLanguage != "" {
+		locales = append(locales, acceptLanguage)
+	}
+	return locales
+}
@@ -7,10 +7,13 @@ import (
 	"learncode/backend/types"
 	"os"
 	"sync"
+	"time"
 
 	"github.com/momentohq/client-sdk-go/auth"
 	"github.com/momentohq/client-sdk-go/config"
 	"github.com/momentohq/client-sdk-go/momento"
+	authresponses "github.com/momentohq/client-sdk-go/responses/auth"
+	momentoutils "github.com/momentohq/client-sdk-go/utils"
 )
 
 var momentoClient *momento.TopicClient
@@ -35,7 +38,20 @@ func initMomentoClient() error {
 	return nil
 }
 
+// MomentoCache is the cache that holds the submission topics.
+const MomentoCache = "learncode-cache"
+
 func PublishToMomento(ctx context.Context, submission types.Submission) error {
+	// Create topic name based on language
+	topicName := fmt.Sprintf("learncode-%s", submission.Language)
+
+	// Publish submission to appropriate topic
+	message, _ := json.Marshal(submission)
+	return PublishToTopic(ctx, topicName, message)
+}
+
+// PublishToTopic publishes a message to a topic in MomentoCache.
+func PublishToTopic(ctx context.Context, topicName string, message []byte) error {
 	var initErr error
 	momentoOnce.Do(func() {
 		initErr = initMomentoClient()
@@ -49,13 +65,8 @@ func PublishToMomento(ctx context.Context, submission types.Submission) error {
 		return fmt.Errorf("momento client not initialized")
 	}
 
-	// Create topic name based on language
-	topicName := fmt.Sprintf("learncode-%s", submission.Language)
-
-	// Publish submission to appropriate topic
-	message, _ := json.Marshal(submission)
 	if _, err := (*momentoClient).Publish(ctx, &momento.TopicPublishRequest{
-		CacheName: "learncode-cache",
+		CacheName: MomentoCache,
 		TopicName: topicName,
 		Value:     momento.Bytes(message),
 	}); err != nil {
@@ -64,3 +75,52 @@ func PublishToMomento(ctx context.Context, submission types.Submission) error {
 
 	return nil
 }
+
+// SubscribeToken is a short-lived Momento token that can only subscribe to
+// one topic.
+type SubscribeToken struct {
+	Token     string `json:"token"`
+	Endpoint  string `json:"endpoint"`
+	Cache     string `json:"cache"`
+	Topic     string `json:"topic"`
+	ExpiresAt int64  `json:"expires_at"`
+}
+
+// NewSubscribeToken mints a token that lets a browser subscribe to a topic in
+// MomentoCache for ttl.
+func NewSubscribeToken(ctx context.Context, topicName string, ttl time.Duration) (*SubscribeToken, error) {
+	credentialProvider, err := auth.NewEnvMomentoTokenProvider("MOMENTO_AUTH_TOKEN")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load Momento auth token: %v", err)
+	}
+
+	authClient, err := momento.NewAuthClient(config.AuthDefault(), credentialProvider)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Momento auth client: %v", err)
+	}
+	defer authClient.Close()
+
+	response, err := authClient.GenerateDisposableToken(ctx, &momento.GenerateDisposableTokenRequest{
+		ExpiresIn: momentoutils.ExpiresInSeconds(int64(ttl.Seconds())),
+		Scope: momento.TopicSubscribeOnly(
+			momento.CacheName{Name: MomentoCache},
+			momento.TopicName{Name: topicName},
+		),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %v", err)
+	}
+
+	success, ok := response.(*authresponses.GenerateDisposableTokenSuccess)
+	if !ok {
+		return nil, fmt.Errorf("unexpected token response: %T", response)
+	}
+
+	return &SubscribeToken{
+		Token:     success.ApiKey,
+		Endpoint:  success.Endpoint,
+		Cache:     MomentoCache,
+		Topic:     topicName,
+		ExpiresAt: int64(success.ValidUntil),
+	}, nil
+}
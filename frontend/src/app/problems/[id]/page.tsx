@@ -5,6 +5,7 @@ import Editor from "@monaco-editor/react"
 import { Play, Loader2 } from "lucide-react"
 import { useRouter, usePathname } from 'next/navigation'
 import { Problem, Submission, User } from '@/types'
+import { subscribeToSubmissionEvents } from '@/lib/submission-events'
 import { loader } from '@monaco-editor/react'
 import ReactMarkdown from 'react-markdown'
 import { setLoading } from "@/store/auth-slice"
@@ -181,8 +182,11 @@ type Language = typeof SUPPORTED_LANGUAGES[number]['id']
 export default function ProblemPage() {
   const router = useRouter()
   const pathname = usePathname()
-  const problemId = pathname.split('/').pop()
+  // The path holds the problem's ID or slug; everything after loading the
+  // problem uses its ID
+  const problemRef = pathname.split('/').pop()
   const [problem, setProblem] = useState<Problem | null>(null)
+  const problemId = problem?.id
   const { loading } = useAppSelector(state => state.auth) as { user: User | null, loading: boolean }
   const [error, setError] = useState<string | null>(null)
   const [code, setCode] = useState('')
@@ -201,7 +205,7 @@ export default function ProblemPage() {
   const [currentSubmission, setCurrentSubmission] = useState<Submission | null>(null)
   const [submissions, setSubmissions] = useState<Submission[]>([])
   const [selectedLanguage, setSelectedLanguage] = useState<Language>('javascript')
-  const [isPolling, setIsPolling] = useState(false)
+  const runIdRef = useRef<string | null>(null)
 
   useEffect(() => {
     const fetchProblem = async () => {
@@ -213,7 +217,7 @@ export default function ProblemPage() {
           return
         }
 
-        const response = await fetch(`${process.env.API_URL}/problems/${problemId}`, {
+        const response = await fetch(`${process.env.API_URL}/problems/${problemRef}`, {
           headers: {
             'Authorization': `Bearer ${token}`,
             'Content-Type': 'application/json',
@@ -229,11 +233,8 @@ export default function ProblemPage() {
         console.log(data)
         
         setProblem(data.problem)
-        setTestInput(data.problem.input)
-        setExpectedOutput(data.problem.output)
-        
-        // Fetch submissions after problem is loaded
-        fetchSubmissions()
+        setTestInput(data.problem.example_input)
+        setExpectedOutput(data.problem.example_output)
       } catch (err) {
         console.error('Fetch error:', err)
         setError(err instanceof Error ? err.message : 'An error occurred')
@@ -243,7 +244,7 @@ export default function ProblemPage() {
     }
 
     fetchProblem()
-  }, [problemId, router])
+  }, [problemRef, router])
 
   useEffect(() => {
     const selectedLang = SUPPORTED_LANGUAGES.find(l => l.id === selectedLanguage)
@@ -253,7 +254,7 @@ export default function ProblemPage() {
   }, [selectedLanguage])
 
   const handleSubmit = async () => {
-    if (!code) return
+    if (!code || !problemId) return
     
     try {
       setIsSubmitting(true)
@@ -317,7 +318,7 @@ export default function ProblemPage() {
     document.removeEventListener('mouseup', handleMouseUp)
   }, [handleMouseMove])
 
-  const pollSubmissionStatus = useCallback(async (problemId: string, submissionId: string) => {
+  const fetchRunResult = useCallback(async (submissionId: string) => {
     const response = await fetch(
       `${process.env.API_URL}/submissions?problem_id=${problemId}&submission_id=${submissionId}&type=RUN`,
       {
@@ -329,12 +330,16 @@ export default function ProblemPage() {
     if (!response.ok) {
       const msg = await response.json()
       console.log(msg)
-      throw new Error('Failed to fetch submission status')
+      throw new Error('Failed to fetch submission result')
     }
-    return await response.json()
-  }, [])
+    const submission: Submission = (await response.json()).submissions[0]
+    setCurrentSubmission(submission)
+    setOutput(submission.result || submission.error_reason || 'No output')
+    setIsRunning(false)
+  }, [problemId])
 
   const handleRun = async () => {
+    if (!problemId) return
     try {
       setIsRunning(true)
       setOutput(null)
@@ -361,30 +366,18 @@ export default function ProblemPage() {
         throw new Error('Failed to submit code')
       }
       
+      // The result arrives as a submission event
       const data = await response.json()
+      runIdRef.current = data.submission.submission_id
       setCurrentSubmission(data.submission)
-
-
-      // Poll for results
-      while (true) {
-        await new Promise(resolve => setTimeout(resolve, 1000)) // Wait 1 second between polls
-        const submission = (await pollSubmissionStatus(data.submission.problem_id, data.submission.submission_id)).submissions[0]
-        console.log(submission)
-        setCurrentSubmission(submission)
-
-        if (submission.status === 'completed' || submission.status === 'error') {
-          setOutput(submission.result || 'No output')
-          setIsRunning(false)
-          break
-        }
-      }
     } catch (error) {
       setOutput(error instanceof Error ? error.message : 'An error occurred')
       setIsRunning(false)
     }
   }
 
-  const fetchSubmissions = async () => {
+  const fetchSubmissions = useCallback(async () => {
+    if (!problemId) return
     try {
       const response = await fetch(
         `${process.env.API_URL}/submissions?problem_id=${problemId}&type=SUBMIT`,
@@ -401,37 +394,52 @@ export default function ProblemPage() {
 
       const data = await response.json()
       setSubmissions(data?.submissions ? data.submissions : [])
-      
-      // Check if we need to continue polling
-      const hasInProgressSubmission = data?.submissions?.some(
-        (sub: Submission) => sub.status === 'pending' || sub.status === 'running'
-      )
-      setIsPolling(hasInProgressSubmission)
     } catch (err) {
       console.error('Failed to fetch submissions:', err)
-      setIsPolling(false)
     }
-  }
+  }, [problemId])
 
+  // Follow this problem's submissions as they are judged instead of polling
   useEffect(() => {
-    let pollInterval: NodeJS.Timeout | null = null;
-    
-    if (isPolling && activeTab === 'submissions') {
-      pollInterval = setInterval(fetchSubmissions, 2000) // Poll every 2 seconds
-    }
+    return subscribeToSubmissionEvents(event => {
+      if (!problemId || event.problem_id !== problemId) return
+      const judged = event.status !== 'pending' && event.status !== 'running' && event.status !== 'testing'
+
+      if (event.submission_id === runIdRef.current) {
+        if (judged) {
+          runIdRef.current = null
+          fetchRunResult(event.submission_id).catch(err => {
+            setOutput(err instanceof Error ? err.message : 'An error occurred')
+            setIsRunning(false)
+          })
+        } else if (event.status !== 'testing') {
+          setCurrentSubmission(prev => prev && { ...prev, status: event.status as Submission['status'] })
+        }
+        return
+      }
 
-    return () => {
-      if (pollInterval) {
-        clearInterval(pollInterval)
+      if (judged) {
+        fetchSubmissions()
+      } else if (event.status !== 'testing') {
+        setSubmissions(prev => prev.map(sub =>
+          sub.submission_id === event.submission_id ? { ...sub, status: event.status as Submission['status'] } : sub
+        ))
       }
-    }
-  }, [isPolling, activeTab])
+    }, err => {
+      console.error('Submission events failed:', err)
+    })
+  }, [problemId, fetchRunResult, fetchSubmissions])
+
+  // Fetch submissions once the problem is loaded, and again on opening the tab
+  useEffect(() => {
+    fetchSubmissions()
+  }, [fetchSubmissions])
 
   useEffect(() => {
     if (activeTab === 'submissions') {
       fetchSubmissions()
     }
-  }, [activeTab, problemId])
+  }, [activeTab, fetchSubmissions])
 
   return (
     <div className="flex h-full w-full">
@@ -639,8 +647,8 @@ export default function ProblemPage() {
                         <div className="flex justify-between items-center mb-2">
                           <div className="flex items-center gap-4">
                             <span className={
-                              submission.status === 'completed' ? 'text-green-500' :
-                              submission.status === 'error' ? 'text-red-500' :
+                              submission.status === 'completed' || submission.status === 'success' ? 'text-green-500' :
+                              submission.status === 'error' || submission.status === 'wrong_answer' || submission.status === 'system_error' ? 'text-red-500' :
                               'text-yellow-500'
                             }>
                               Status: {submission.status}
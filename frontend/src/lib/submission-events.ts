@@ -0,0 +1,89 @@
+import { Configurations, CredentialProvider, TopicClient, TopicSubscribe } from '@gomomento/sdk-web'
+import { SubmissionEvent } from '@/types'
+
+interface SubscribeToken {
+  token: string
+  endpoint: string
+  cache: string
+  topic: string
+  expires_at: number // Unix timestamp
+}
+
+// Resubscribe with a fresh token this long before the old one expires
+const RENEW_BEFORE_MS = 60 * 1000
+
+// Wait this long before resubscribing after the subscription fails
+const RETRY_DELAY_MS = 5 * 1000
+
+// subscribeToSubmissionEvents follows the caller's submissions as they are
+// judged (see GET /me/submissions/events) and calls onEvent for every
+// event. It returns a function that stops the subscription.
+export function subscribeToSubmissionEvents(
+  onEvent: (event: SubmissionEvent) => void,
+  onError: (error: Error) => void,
+): () => void {
+  let stopped = false
+  let unsubscribe: (() => void) | null = null
+  let timer: ReturnType<typeof setTimeout> | null = null
+
+  const schedule = (delay: number) => {
+    if (!stopped) {
+      timer = setTimeout(subscribe, Math.max(delay, 0))
+    }
+  }
+
+  const subscribe = async () => {
+    unsubscribe?.()
+    unsubscribe = null
+    try {
+      const response = await fetch(`${process.env.API_URL}/me/submissions/events`, {
+        headers: {
+          'Authorization': `Bearer ${localStorage.getItem('auth_token')}`
+        }
+      })
+      if (!response.ok) {
+        throw new Error('Failed to get a submission events token')
+      }
+      const token: SubscribeToken = await response.json()
+
+      const client = new TopicClient({
+        configuration: Configurations.Browser.v1(),
+        credentialProvider: CredentialProvider.fromString({ apiKey: token.token }),
+      })
+      const subscription = await client.subscribe(token.cache, token.topic, {
+        onItem: item => {
+          try {
+            onEvent(JSON.parse(item.valueString()))
+          } catch (err) {
+            console.error('Invalid submission event:', err)
+          }
+        },
+        onError: err => {
+          onError(new Error(err.message()))
+          schedule(RETRY_DELAY_MS)
+        },
+      })
+      if (!(subscription instanceof TopicSubscribe.Subscription)) {
+        throw new Error('Failed to subscribe to submission events')
+      }
+      if (stopped) {
+        subscription.unsubscribe()
+        return
+      }
+      unsubscribe = () => subscription.unsubscribe()
+      schedule(token.expires_at * 1000 - Date.now() - RENEW_BEFORE_MS)
+    } catch (err) {
+      onError(err instanceof Error ? err : new Error('Failed to subscribe to submission events'))
+      schedule(RETRY_DELAY_MS)
+    }
+  }
+
+  subscribe()
+  return () => {
+    stopped = true
+    if (timer) {
+      clearTimeout(timer)
+    }
+    unsubscribe?.()
+  }
+}
@@ -6,8 +6,8 @@ export interface Problem {
   created_at: number  // Unix timestamp
   updated_at: number  // Unix timestamp
   deleted_at?: number // Optional Unix timestamp
-  input: string
-  output: string
+  input?: string  // The first hidden test; empty in learner views
+  output?: string
   example_input: string
   example_output: string
 }
@@ -28,8 +28,10 @@ export interface ProblemsResponse {
 
 export interface Submission {
   submission_id: string
-  status: 'pending' | 'running' | 'completed' | 'error'
+  // success and wrong_answer only appear on older submissions
+  status: 'pending' | 'running' | 'completed' | 'success' | 'wrong_answer' | 'error' | 'system_error'
   result?: string
+  error_reason?: string
   type: 'submit' | 'run' | 'RUN' | 'SUBMIT'
   code: string
   language: string
@@ -37,4 +39,14 @@ export interface Submission {
   updated_at: number
   problem_id: string
   user_id: string
-}
\ No newline at end of file
+}
+// A submission's status change, see GET /me/submissions/events
+export interface SubmissionEvent {
+  submission_id: string
+  problem_id: string
+  // pending, running, testing once per test, then the final status
+  status: 'testing' | Submission['status']
+  verdict?: string
+  test_index?: number // 1-based
+  time: number
+}